@@ -12,6 +12,11 @@ import (
 
 	"github.com/joho/godotenv"
 	"github.com/darkphotonKN/seeyoulatte-app/config"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/googleauth"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/jwtauth"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/mailer"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/payment"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/ratelimit"
 )
 
 func main() {
@@ -33,7 +38,41 @@ func main() {
 	}
 	defer db.Close()
 
-	router := config.SetupRoutes(db, logger)
+	gateway, err := payment.NewGatewayFromEnv(logger)
+	if err != nil {
+		logger.Error("failed to configure payment gateway", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	tokens, err := jwtauth.NewManagerFromEnv(logger)
+	if err != nil {
+		logger.Error("failed to configure jwt keys", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	mail, err := mailer.NewMailerFromEnv(logger)
+	if err != nil {
+		logger.Error("failed to configure mailer", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	googleVerifier, err := googleauth.NewVerifierFromEnv(logger)
+	if err != nil {
+		logger.Error("failed to configure Google sign-in", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	limiter, err := ratelimit.NewLimiterFromEnv(db, logger)
+	if err != nil {
+		logger.Error("failed to configure rate limiter", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	router := config.SetupRoutes(db, logger, gateway, tokens, mail, googleVerifier, limiter)
+
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	config.StartWorkers(workerCtx, db, logger, gateway, tokens, mail, googleVerifier, limiter)
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -58,6 +97,7 @@ func main() {
 	<-quit
 
 	logger.Info("shutting down server...")
+	stopWorkers()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
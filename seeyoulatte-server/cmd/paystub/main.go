@@ -0,0 +1,43 @@
+// Command paystub runs the mock payment provider behind HTTP so the API can be
+// pointed at it with PAYMENT_PROVIDER=http and PAYMENT_HTTP_URL=http://localhost:8090.
+// Failures and latency are configured with the PAYMENT_MOCK_* variables.
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/payment"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	_ = godotenv.Load()
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	config, err := payment.MockConfigFromEnv()
+	if err != nil {
+		logger.Error("invalid stub configuration", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	port := os.Getenv("PAYSTUB_PORT")
+	if port == "" {
+		port = "8090"
+	}
+
+	handler := payment.NewStubHandler(payment.NewMockGateway(config), logger)
+
+	logger.Info("starting payment stub",
+		slog.String("port", port),
+		slog.Duration("latency", config.Latency),
+		slog.Float64("failure_rate", config.FailureRate))
+
+	if err := http.ListenAndServe(fmt.Sprintf(":%s", port), handler); err != nil {
+		logger.Error("payment stub stopped", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+}
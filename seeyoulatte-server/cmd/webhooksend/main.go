@@ -0,0 +1,99 @@
+// Command webhooksend signs a payment event and posts it to the local API,
+// simulating an out-of-band confirmation from the payment provider.
+//
+//	go run ./cmd/webhooksend -type payment.succeeded -order <order id> -amount 64.00
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/money"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/webhook"
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	_ = godotenv.Load()
+
+	url := flag.String("url", "http://localhost:8080/api/webhooks/payments", "webhook endpoint")
+	secret := flag.String("secret", os.Getenv("PAYMENT_WEBHOOK_SECRET"), "signing secret (defaults to PAYMENT_WEBHOOK_SECRET)")
+	eventType := flag.String("type", string(webhook.EventTypePaymentSucceeded), "payment.succeeded, payment.failed or refund.settled")
+	orderID := flag.String("order", "", "order id (required)")
+	reference := flag.String("reference", "", "provider reference (generated when empty)")
+	amount := flag.Float64("amount", 0, "amount captured or refunded")
+	currency := flag.String("currency", "", "ISO 4217 currency of the amount, omitted when empty")
+	reason := flag.String("reason", "", "failure reason for payment.failed")
+	eventID := flag.String("id", "", "event id, reuse one to test deduplication (generated when empty)")
+	skew := flag.Duration("skew", 0, "shift the signed timestamp, e.g. -10m to test expiry")
+	flag.Parse()
+
+	if err := run(*url, *secret, *eventType, *orderID, *reference, *amount, *currency, *reason, *eventID, *skew); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func run(url, secret, eventType, orderID, reference string, amount float64, currency, reason, eventID string, skew time.Duration) error {
+	if secret == "" {
+		return fmt.Errorf("a signing secret is required, set -secret or PAYMENT_WEBHOOK_SECRET")
+	}
+
+	parsedOrderID, err := uuid.Parse(orderID)
+	if err != nil {
+		return fmt.Errorf("invalid -order: %w", err)
+	}
+
+	if eventID == "" {
+		eventID = "evt_" + uuid.New().String()
+	}
+	if reference == "" && eventType != string(webhook.EventTypePaymentFailed) {
+		reference = "ext_" + uuid.New().String()
+	}
+
+	event := webhook.PaymentEvent{
+		ID:        eventID,
+		Type:      webhook.EventType(eventType),
+		OrderID:   parsedOrderID,
+		Reference: reference,
+		Amount:    amount,
+		Currency:  money.Currency(currency),
+		Reason:    reason,
+		CreatedAt: time.Now(),
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encoding event: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("building request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhook.SignatureHeader, webhook.Sign([]byte(secret), time.Now().Add(skew), body))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending event: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	fmt.Printf("sent %s %s\n", event.Type, event.ID)
+	fmt.Printf("%s %s\n", resp.Status, bytes.TrimSpace(respBody))
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook rejected with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -3,17 +3,36 @@ package config
 import (
 	"log/slog"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/darkphotonKN/seeyoulatte-app/internal/account"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/apikey"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/audit"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/dispute"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/googleauth"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/jwtauth"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/ledger"
 	"github.com/darkphotonKN/seeyoulatte-app/internal/listing"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/mailer"
 	"github.com/darkphotonKN/seeyoulatte-app/internal/middleware"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/moderation"
 	"github.com/darkphotonKN/seeyoulatte-app/internal/order"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/payment"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/profile"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/promotion"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/ratelimit"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/settlement"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/transfer"
 	"github.com/darkphotonKN/seeyoulatte-app/internal/user"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/wallet"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/webhook"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
 )
 
-func SetupRoutes(db *sqlx.DB, logger *slog.Logger) *gin.Engine {
+func SetupRoutes(db *sqlx.DB, logger *slog.Logger, gateway payment.PaymentGateway, tokens *jwtauth.Manager, mail mailer.Mailer, googleVerifier *googleauth.Verifier, limiter ratelimit.Limiter) *gin.Engine {
 	// Set Gin mode based on environment
 	if os.Getenv("ENVIRONMENT") == "production" {
 		gin.SetMode(gin.ReleaseMode)
@@ -23,6 +42,17 @@ func SetupRoutes(db *sqlx.DB, logger *slog.Logger) *gin.Engine {
 
 	router := gin.New()
 
+	// Only trust X-Forwarded-For from known proxies, otherwise clients could pick
+	// their own address and dodge per-IP rate limits
+	var trustedProxies []string
+	if v := os.Getenv("TRUSTED_PROXIES"); v != "" {
+		trustedProxies = strings.Split(v, ",")
+	}
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		logger.Error("invalid TRUSTED_PROXIES, trusting none", slog.String("error", err.Error()))
+		_ = router.SetTrustedProxies(nil)
+	}
+
 	// Add middleware
 	router.Use(middleware.RequestID())
 	router.Use(middleware.RequestLogger(logger))
@@ -32,7 +62,7 @@ func SetupRoutes(db *sqlx.DB, logger *slog.Logger) *gin.Engine {
 	// Initialize services
 	// User/Auth service
 	userRepo := user.NewRepository(db)
-	userService := user.NewService(userRepo, logger)
+	userService := user.NewService(userRepo, db, logger, tokens, mail, googleVerifier, limiter)
 	userHandler := user.NewHandler(userService, logger)
 
 	// Listing service
@@ -40,26 +70,132 @@ func SetupRoutes(db *sqlx.DB, logger *slog.Logger) *gin.Engine {
 	listingService := listing.NewService(listingRepo, logger)
 	listingHandler := listing.NewHandler(listingService, logger)
 
+	// Public profiles
+	profileRepo := profile.NewRepository(db)
+	profileService := profile.NewService(profileRepo, logger, userService, listingService)
+	profileHandler := profile.NewHandler(profileService, logger)
+
+	// Ledger service
+	ledgerRepo := ledger.NewRepository(db)
+	ledgerService := ledger.NewService(ledgerRepo, db, logger)
+	ledgerHandler := ledger.NewHandler(ledgerService, logger)
+
+	// Promotion service
+	promotionRepo := promotion.NewRepository(db)
+	promotionService := promotion.NewService(promotionRepo, logger)
+	promotionHandler := promotion.NewHandler(promotionService, logger)
+
+	// Wallet service
+	walletRepo := wallet.NewRepository(db)
+	walletService := wallet.NewService(walletRepo, ledgerService, logger)
+	walletHandler := wallet.NewHandler(walletService, logger)
+
+	// API keys for seller scripts
+	apiKeyRepo := apikey.NewRepository(db)
+	apiKeyService := apikey.NewService(apiKeyRepo, logger, userService)
+	apiKeyHandler := apikey.NewHandler(apiKeyService, logger)
+
+	// Account export and deletion (spans the user's listings, orders and wallet)
+	accountRepo := account.NewRepository(db)
+	accountService := account.NewService(accountRepo, db, logger, userService, listingService, walletService)
+	accountHandler := account.NewHandler(accountService, logger)
+
+	// Refunds and payouts, queued by order transitions and sent by the transfer worker
+	transferService := transfer.NewService(transfer.NewRepository(db), db, gateway, logger)
+
 	// Order service
 	orderRepo := order.NewRepository(db)
-	orderService := order.NewService(orderRepo, db, logger, listingService, userService)
+	orderService := order.NewService(orderRepo, db, logger, listingService, userService, ledgerService, promotionService, walletService, transferService, gateway)
 	orderHandler := order.NewHandler(orderService, logger)
 
+	// Dispute service
+	disputeRepo := dispute.NewRepository(db)
+	disputeService := dispute.NewService(disputeRepo, db, logger, orderService)
+	disputeHandler := dispute.NewHandler(disputeService, logger)
+
+	// Moderation service (freezing cascades into the user's orders)
+	moderationService := moderation.NewService(userService, orderService, logger)
+	moderationHandler := moderation.NewHandler(moderationService, logger)
+
+	// Settlement service
+	settlementRepo := settlement.NewRepository(db)
+	settlementService := settlement.NewService(settlementRepo, db, logger)
+	settlementHandler := settlement.NewHandler(settlementService, logger)
+
+	// Admin audit log
+	auditRepo := audit.NewRepository(db)
+	auditService := audit.NewService(auditRepo, logger)
+	auditHandler := audit.NewHandler(auditService, logger)
+
+	// Payment webhook service
+	webhookRepo := webhook.NewRepository(db)
+	webhookService := webhook.NewService(webhookRepo, db, logger, orderService, []byte(os.Getenv("PAYMENT_WEBHOOK_SECRET")))
+	webhookHandler := webhook.NewHandler(webhookService, logger)
+
+	// Rejects access tokens revoked by logout-all or freezing, and places the user's principal on the context
+	authRequired := middleware.AuthRequired(tokens, userService, logger)
+
+	// Endpoints scripts may call with an API key holding the scope, see apikey.Scope
+	authOrAPIKey := func(scope apikey.Scope) gin.HandlerFunc {
+		return middleware.AuthRequiredOrAPIKey(authRequired, apiKeyService, string(scope), logger)
+	}
+
+	// Rate limits. Failed sign-ins are also limited per account, see user.SignInFailureLimit
+	authRateLimit := middleware.RateLimit(limiter, "auth", ratelimit.Limit{Burst: 20, Per: 5 * time.Minute}, middleware.ByClientIP, logger)
+	orderRateLimit := middleware.RateLimit(limiter, "orders:create", ratelimit.Limit{Burst: 10, Per: 10 * time.Minute}, middleware.ByUser, logger)
+
+	// Public signing keys
+	jwksHandler := jwtauth.NewHandler(tokens)
+
 	// Health check
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
 
+	// Public keys for verifying RS256/EdDSA access tokens
+	router.GET("/.well-known/jwks.json", jwksHandler.JWKS)
+
 	// API routes
 	api := router.Group("/api")
 	{
 		// Auth endpoints (public)
 		auth := api.Group("/auth")
 		{
-			auth.POST("/signup", userHandler.SignUp)
-			auth.POST("/signin", userHandler.SignIn)
-			auth.POST("/google", userHandler.GoogleAuth)
-			auth.GET("/me", middleware.AuthRequired(), userHandler.GetCurrentUser)
+			auth.POST("/signup", authRateLimit, userHandler.SignUp)
+			auth.POST("/signin", authRateLimit, userHandler.SignIn)
+			auth.POST("/google", authRateLimit, userHandler.GoogleAuth)
+			auth.POST("/refresh", authRateLimit, userHandler.Refresh)
+			auth.POST("/logout", userHandler.Logout)
+			auth.POST("/logout-all", authRequired, userHandler.LogoutAll)
+			auth.POST("/password/forgot", authRateLimit, userHandler.ForgotPassword)
+			auth.POST("/password/reset", authRateLimit, userHandler.ResetPassword)
+			auth.GET("/verify", userHandler.VerifyEmail)
+			auth.POST("/verify/resend", authRequired, userHandler.ResendVerification)
+			auth.POST("/2fa/verify", authRateLimit, userHandler.VerifyTwoFactor)
+			auth.GET("/me", authRequired, userHandler.GetCurrentUser)
+		}
+
+		// Current user's own profile, two-factor settings, data export and deletion
+		me := api.Group("/me")
+		me.Use(authRequired)
+		{
+			me.GET("", userHandler.GetCurrentUser)
+			me.PATCH("", userHandler.UpdateCurrentUser)
+			me.DELETE("", accountHandler.Delete)
+			me.GET("/export", accountHandler.Export)
+			me.POST("/2fa/enroll", userHandler.EnrollTwoFactor)
+			me.POST("/2fa/confirm", userHandler.ConfirmTwoFactor)
+			me.POST("/2fa/recovery-codes", userHandler.RegenerateRecoveryCodes)
+			me.POST("/2fa/disable", userHandler.DisableTwoFactor)
+			me.POST("/api-keys", apiKeyHandler.CreateAPIKey)
+			me.GET("/api-keys", apiKeyHandler.ListAPIKeys)
+			me.DELETE("/api-keys/:id", apiKeyHandler.RevokeAPIKey)
+		}
+
+		// Public user profiles
+		users := api.Group("/users")
+		{
+			users.GET("/:id/profile", profileHandler.GetPublicProfile)
 		}
 
 		// Listing endpoints
@@ -69,21 +205,78 @@ func SetupRoutes(db *sqlx.DB, logger *slog.Logger) *gin.Engine {
 			listings.GET("", listingHandler.GetAllListings) // Get all public listings
 			listings.GET("/:id", listingHandler.GetListing) // Get single listing
 
-			// Protected endpoints (auth required)
-			listings.POST("", middleware.AuthRequired(), listingHandler.CreateListing)
-			listings.GET("/my", middleware.AuthRequired(), listingHandler.GetMyListings)
-			listings.PUT("/:id", middleware.AuthRequired(), listingHandler.UpdateListing)
-			listings.DELETE("/:id", middleware.AuthRequired(), listingHandler.DeleteListing)
+			// Protected endpoints (auth required, or an API key with the listings scopes).
+			// The listing service checks the principal may sell.
+			listings.POST("", authOrAPIKey(apikey.ScopeListingsWrite), listingHandler.CreateListing)
+			listings.GET("/my", authOrAPIKey(apikey.ScopeListingsRead), listingHandler.GetMyListings)
+			listings.PUT("/:id", authOrAPIKey(apikey.ScopeListingsWrite), listingHandler.UpdateListing)
+			listings.DELETE("/:id", authOrAPIKey(apikey.ScopeListingsWrite), listingHandler.DeleteListing)
 		}
 
-		// Order endpoints (all require authentication)
+		// Order endpoints (all require authentication, reading and the seller's
+		// transitions also accept an API key with the orders scopes)
 		orders := api.Group("/orders")
-		orders.Use(middleware.AuthRequired())
 		{
-			orders.POST("", orderHandler.CreateOrder)
-			orders.GET("", orderHandler.GetAllOrders)
-			orders.PUT("/:id", orderHandler.UpdateOrder)
-			orders.DELETE("/:id", orderHandler.DeleteOrder)
+			orders.POST("", authRequired, orderRateLimit, orderHandler.CreateOrder)
+			orders.GET("", authOrAPIKey(apikey.ScopeOrdersRead), orderHandler.GetMyOrders)
+			orders.DELETE("/:id", authRequired, orderHandler.DeleteOrder)
+
+			// State transitions
+			orders.POST("/:id/pay", authRequired, orderHandler.PayOrder)
+			orders.POST("/:id/pay-wallet", authRequired, orderHandler.PayOrderWithWallet)
+			orders.POST("/:id/accept", authOrAPIKey(apikey.ScopeOrdersFulfill), orderHandler.AcceptOrder)
+			orders.POST("/:id/decline", authOrAPIKey(apikey.ScopeOrdersFulfill), orderHandler.DeclineOrder)
+			orders.POST("/:id/cancel", authRequired, orderHandler.CancelOrder)
+			orders.POST("/:id/fulfill", authOrAPIKey(apikey.ScopeOrdersFulfill), orderHandler.FulfillOrder)
+			orders.POST("/:id/tip", authRequired, orderHandler.TipOrder)
+			orders.POST("/:id/dispute", authRequired, disputeHandler.OpenDispute)
+		}
+
+		// Webhook endpoints (verified by signature, no user auth)
+		webhooks := api.Group("/webhooks")
+		{
+			webhooks.POST("/payments", webhookHandler.PaymentEvent)
+		}
+
+		// Seller endpoints (all require authentication)
+		seller := api.Group("/seller")
+		seller.Use(authRequired)
+		{
+			seller.POST("/onboard", userHandler.OnboardSeller)
+			seller.GET("/balance", settlementHandler.GetBalance)
+			seller.GET("/settlements", settlementHandler.GetSettlements)
+			seller.GET("/settlements/:id/csv", settlementHandler.DownloadStatement)
+		}
+
+		// Wallet endpoints (store credit, all require authentication)
+		walletGroup := api.Group("/wallet")
+		walletGroup.Use(authRequired)
+		{
+			walletGroup.GET("", walletHandler.GetWallets)
+			walletGroup.GET("/:currency/statement", walletHandler.GetStatement)
+		}
+
+		// Admin endpoints (admin flag is checked against the database on every request,
+		// every request needs an X-Admin-Reason header and is written to the audit log)
+		admin := api.Group("/admin")
+		admin.Use(authRequired, middleware.RequireAdmin(logger), middleware.AuditAdmin(auditService, logger))
+		{
+			admin.GET("/users", userHandler.ListUsers)
+			admin.POST("/users/:id/freeze", moderationHandler.FreezeUser)
+			admin.POST("/users/:id/unfreeze", moderationHandler.UnfreezeUser)
+			admin.GET("/orders/:id", orderHandler.GetOrder)
+			admin.GET("/orders/:id/ledger", ledgerHandler.GetOrderLedger)
+			admin.GET("/review-queue", orderHandler.GetReviewQueue)
+			admin.POST("/review-queue/:id/resolve", orderHandler.ResolveReview)
+			admin.GET("/listings/:id", listingHandler.GetListing)
+			admin.GET("/audit", auditHandler.GetAuditLog)
+
+			admin.GET("/ledger/export", ledgerHandler.ExportLedger)
+			admin.POST("/promotions", promotionHandler.CreatePromotion)
+			admin.GET("/promotions", promotionHandler.GetPromotions)
+			admin.POST("/promotions/:id/deactivate", promotionHandler.DeactivatePromotion)
+			admin.GET("/disputes", disputeHandler.GetDisputes)
+			admin.POST("/disputes/:id/resolve", disputeHandler.ResolveDispute)
 		}
 	}
 
@@ -93,8 +286,7 @@ func SetupRoutes(db *sqlx.DB, logger *slog.Logger) *gin.Engine {
 func corsMiddleware() gin.HandlerFunc {
 	config := cors.DefaultConfig()
 	config.AllowOrigins = []string{"*"}
-	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Content-Type", "Authorization"}
+	config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	config.AllowHeaders = []string{"Content-Type", "Authorization", middleware.AdminReasonHeader, middleware.APIKeyHeader}
 	return cors.New(config)
 }
-
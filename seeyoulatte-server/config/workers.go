@@ -0,0 +1,72 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/googleauth"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/jwtauth"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/ledger"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/listing"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/mailer"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/order"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/payment"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/promotion"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/ratelimit"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/settlement"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/transfer"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/user"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/wallet"
+	"github.com/jmoiron/sqlx"
+)
+
+// StartWorkers launches the background jobs. They stop when ctx is cancelled.
+func StartWorkers(ctx context.Context, db *sqlx.DB, logger *slog.Logger, gateway payment.PaymentGateway, tokens *jwtauth.Manager, mail mailer.Mailer, googleVerifier *googleauth.Verifier, limiter ratelimit.Limiter) {
+	// Order timeouts (auto-cancel unaccepted, auto-complete fulfilled)
+	userService := user.NewService(user.NewRepository(db), db, logger, tokens, mail, googleVerifier, limiter)
+	listingService := listing.NewService(listing.NewRepository(db), logger)
+	ledgerService := ledger.NewService(ledger.NewRepository(db), db, logger)
+	promotionService := promotion.NewService(promotion.NewRepository(db), logger)
+	walletService := wallet.NewService(wallet.NewRepository(db), ledgerService, logger)
+	transferService := transfer.NewService(transfer.NewRepository(db), db, gateway, logger)
+	orderService := order.NewService(order.NewRepository(db), db, logger, listingService, userService, ledgerService, promotionService, walletService, transferService, gateway)
+	orderWorker := order.NewWorker(orderService, durationFromEnv(logger, "WORKER_INTERVAL", time.Minute), logger)
+	go orderWorker.Start(ctx)
+
+	// Refunds and payouts queued by order transitions
+	transferWorker := transfer.NewWorker(transferService, durationFromEnv(logger, "TRANSFER_INTERVAL", 30*time.Second), logger)
+	go transferWorker.Start(ctx)
+
+	// Settlement statements
+	settlementRepo := settlement.NewRepository(db)
+	settlementService := settlement.NewService(settlementRepo, db, logger)
+	settlementWorker := settlement.NewWorker(settlementService, durationFromEnv(logger, "SETTLEMENT_INTERVAL", 24*time.Hour), logger)
+	go settlementWorker.Start(ctx)
+
+	// Idle rate limit buckets, only the Postgres store keeps them in a table
+	if pruner, ok := limiter.(*ratelimit.PostgresLimiter); ok {
+		pruneWorker := ratelimit.NewWorker(pruner, durationFromEnv(logger, "RATE_LIMIT_PRUNE_INTERVAL", time.Hour), 24*time.Hour, logger)
+		go pruneWorker.Start(ctx)
+	}
+}
+
+// durationFromEnv reads a time.ParseDuration value ("24h", "1m") falling back to def
+func durationFromEnv(logger *slog.Logger, key string, def time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		logger.Warn("invalid duration in environment, using default",
+			slog.String("key", key),
+			slog.String("value", value),
+			slog.Duration("default", def))
+		return def
+	}
+
+	return d
+}
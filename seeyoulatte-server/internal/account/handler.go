@@ -0,0 +1,100 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/principal"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Service interface {
+	Export(ctx context.Context, userID uuid.UUID) (*Export, error)
+	Delete(ctx context.Context, userID uuid.UUID) error
+}
+
+type Handler struct {
+	service Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service Service, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// Export - GET /api/me/export (requires auth), downloads the user's personal data as JSON
+func (h *Handler) Export(c *gin.Context) {
+	p, ok := principal.FromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Not authenticated",
+		})
+		return
+	}
+	userID := p.UserID
+
+	export, err := h.service.Export(c.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, errorutils.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "User not found",
+			})
+			return
+		}
+
+		h.logger.Error("data export failed",
+			slog.String("user_id", userID.String()),
+			slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to export data",
+		})
+		return
+	}
+
+	filename := fmt.Sprintf("seeyoulatte-export-%s.json", export.ExportedAt.Format("2006-01-02"))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.JSON(http.StatusOK, export)
+}
+
+// Delete - DELETE /api/me (requires auth), anonymizes the account and signs it out everywhere
+func (h *Handler) Delete(c *gin.Context) {
+	p, ok := principal.FromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Not authenticated",
+		})
+		return
+	}
+	userID := p.UserID
+
+	if err := h.service.Delete(c.Request.Context(), userID); err != nil {
+		switch {
+		case errors.Is(err, errorutils.ErrAccountDeletionBlocked):
+			c.JSON(http.StatusConflict, gin.H{
+				"error": err.Error(),
+			})
+		case errors.Is(err, errorutils.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "User not found",
+			})
+		default:
+			h.logger.Error("account deletion failed",
+				slog.String("user_id", userID.String()),
+				slog.String("error", err.Error()))
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to delete account",
+			})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,38 @@
+package account
+
+import (
+	"time"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/dispute"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/listing"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/order"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/user"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/wallet"
+	"github.com/google/uuid"
+)
+
+// Export is the personal data archive returned by GET /api/me/export
+type Export struct {
+	ExportedAt time.Time         `json:"exported_at"`
+	Profile    *user.User        `json:"profile"`
+	Listings   []listing.Listing `json:"listings"`
+	Orders     []order.Order     `json:"orders"`   // As buyer and as seller
+	Reviews    []Review          `json:"reviews"`  // Written by the user or left on their sales
+	Disputes   []dispute.Dispute `json:"disputes"` // On the user's orders
+	Wallets    []wallet.Balance  `json:"wallets"`
+}
+
+type Review struct {
+	ID         uuid.UUID `db:"id" json:"id"`
+	OrderID    uuid.UUID `db:"order_id" json:"order_id"`
+	ReviewerID uuid.UUID `db:"reviewer_id" json:"reviewer_id"`
+	Rating     int       `db:"rating" json:"rating"`
+	Comment    *string   `db:"comment" json:"comment,omitempty"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+}
+
+// DeletionBlockers are the reasons an account cannot be deleted yet
+type DeletionBlockers struct {
+	OpenOrders   int `db:"open_orders"`   // Orders as buyer or seller that have not reached a final state
+	EscrowOrders int `db:"escrow_orders"` // Orders that still hold escrow or an unpaid tip
+}
@@ -0,0 +1,127 @@
+package account
+
+import (
+	"context"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/dispute"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/order"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *repository {
+	return &repository{db: db}
+}
+
+// GetOrdersByUser returns the orders the user bought or sold, newest first
+func (r *repository) GetOrdersByUser(ctx context.Context, userID uuid.UUID) ([]order.Order, error) {
+	var orders []order.Order
+	query := `
+		SELECT
+			id, listing_id, buyer_id, seller_id, quantity, subtotal, discount_amount, amount, currency,
+			tip_amount, promotion_id, refund_to, cancellation_policy, starts_at, state, seller_respond_by, review_ends_at, payment_reference, refund_settled_at, held_at, held_reason, created_at
+		FROM orders
+		WHERE buyer_id = $1 OR seller_id = $1
+		ORDER BY created_at DESC
+	`
+
+	err := r.db.SelectContext(ctx, &orders, query, userID)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return orders, nil
+}
+
+// GetReviewsByUser returns the reviews the user wrote and the ones left on orders they sold
+func (r *repository) GetReviewsByUser(ctx context.Context, userID uuid.UUID) ([]Review, error) {
+	var reviews []Review
+	query := `
+		SELECT
+			rv.id, rv.order_id, rv.reviewer_id, rv.rating, rv.comment, rv.created_at
+		FROM reviews as rv
+		JOIN orders as o
+		ON o.id = rv.order_id
+		WHERE rv.reviewer_id = $1 OR o.seller_id = $1
+		ORDER BY rv.created_at DESC
+	`
+
+	err := r.db.SelectContext(ctx, &reviews, query, userID)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return reviews, nil
+}
+
+// GetDisputesByUser returns the disputes on orders the user bought or sold
+func (r *repository) GetDisputesByUser(ctx context.Context, userID uuid.UUID) ([]dispute.Dispute, error) {
+	var disputes []dispute.Dispute
+	query := `
+		SELECT
+			d.id, d.order_id, d.reason, d.status, d.refund_amount, d.resolution_notes, d.resolved_by, d.resolved_at, d.created_at
+		FROM disputes as d
+		JOIN orders as o
+		ON o.id = d.order_id
+		WHERE o.buyer_id = $1 OR o.seller_id = $1
+		ORDER BY d.created_at DESC
+	`
+
+	err := r.db.SelectContext(ctx, &disputes, query, userID)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return disputes, nil
+}
+
+// GetDeletionBlockersTx counts the user's orders that are still open or still hold money in escrow.
+// The escrow balance is the ledger's, ESCROW and TIP less what was paid out, refunded or reversed.
+func (r *repository) GetDeletionBlockersTx(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID) (*DeletionBlockers, error) {
+	var blockers DeletionBlockers
+	query := `
+		SELECT
+			COUNT(*) FILTER (
+				WHERE o.state NOT IN ('completed', 'cancelled', 'refunded', 'partially_refunded')
+			) as open_orders,
+			COUNT(*) FILTER (WHERE held.balance > 0) as escrow_orders
+		FROM orders as o
+		CROSS JOIN LATERAL (
+			SELECT COALESCE(SUM(
+				CASE
+					WHEN le.entry_type IN ('ESCROW', 'TIP') THEN le.amount
+					WHEN le.entry_type IN ('PAYOUT', 'REFUND', 'REVERSAL', 'TIP_PAYOUT') THEN -le.amount
+					ELSE 0
+				END
+			), 0) as balance
+			FROM ledger_entries as le
+			WHERE le.order_id = o.id
+		) as held
+		WHERE o.buyer_id = $1 OR o.seller_id = $1
+	`
+
+	err := tx.GetContext(ctx, &blockers, query, userID)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return &blockers, nil
+}
+
+// ClearReviewCommentsTx removes the text of the user's reviews. Ratings stay, they feed
+// the seller's average.
+func (r *repository) ClearReviewCommentsTx(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID) error {
+	query := `UPDATE reviews SET comment = NULL WHERE reviewer_id = $1`
+
+	_, err := tx.ExecContext(ctx, query, userID)
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/dispute"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/listing"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/order"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/user"
+	dbutils "github.com/darkphotonKN/seeyoulatte-app/internal/utils/db"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/wallet"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	GetOrdersByUser(ctx context.Context, userID uuid.UUID) ([]order.Order, error)
+	GetReviewsByUser(ctx context.Context, userID uuid.UUID) ([]Review, error)
+	GetDisputesByUser(ctx context.Context, userID uuid.UUID) ([]dispute.Dispute, error)
+	GetDeletionBlockersTx(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID) (*DeletionBlockers, error)
+	ClearReviewCommentsTx(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID) error
+}
+
+type UserService interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*user.User, error)
+	GetByIDForUpdateTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (*user.User, error)
+	DeleteAccountTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, at time.Time) error
+}
+
+type ListingService interface {
+	GetMyListings(ctx context.Context, sellerID uuid.UUID) ([]listing.Listing, error)
+	DeactivateBySellerTx(ctx context.Context, tx *sqlx.Tx, sellerID uuid.UUID) error
+}
+
+type WalletService interface {
+	GetBalances(ctx context.Context, userID uuid.UUID) ([]wallet.Balance, error)
+	GetBalancesForUpdateTx(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID) ([]wallet.Balance, error)
+}
+
+type service struct {
+	repo           Repository
+	db             *sqlx.DB
+	logger         *slog.Logger
+	userService    UserService
+	listingService ListingService
+	walletService  WalletService
+}
+
+func NewService(repo Repository, db *sqlx.DB, logger *slog.Logger, userService UserService, listingService ListingService, walletService WalletService) *service {
+	return &service{
+		repo:           repo,
+		db:             db,
+		logger:         logger,
+		userService:    userService,
+		listingService: listingService,
+		walletService:  walletService,
+	}
+}
+
+// Export gathers everything held about the user. Empty sections are empty arrays, not null.
+func (s *service) Export(ctx context.Context, userID uuid.UUID) (*Export, error) {
+	u, err := s.userService.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("getting user: %w", err)
+	}
+	if u == nil || u.DeletedAt != nil {
+		return nil, errorutils.ErrNotFound
+	}
+
+	export := &Export{
+		ExportedAt: time.Now(),
+		Profile:    u,
+		Listings:   []listing.Listing{},
+		Orders:     []order.Order{},
+		Reviews:    []Review{},
+		Disputes:   []dispute.Dispute{},
+		Wallets:    []wallet.Balance{},
+	}
+
+	if listings, err := s.listingService.GetMyListings(ctx, userID); err != nil {
+		return nil, err
+	} else if listings != nil {
+		export.Listings = listings
+	}
+
+	if orders, err := s.repo.GetOrdersByUser(ctx, userID); err != nil {
+		return nil, fmt.Errorf("getting orders: %w", err)
+	} else if orders != nil {
+		export.Orders = orders
+	}
+
+	if reviews, err := s.repo.GetReviewsByUser(ctx, userID); err != nil {
+		return nil, fmt.Errorf("getting reviews: %w", err)
+	} else if reviews != nil {
+		export.Reviews = reviews
+	}
+
+	if disputes, err := s.repo.GetDisputesByUser(ctx, userID); err != nil {
+		return nil, fmt.Errorf("getting disputes: %w", err)
+	} else if disputes != nil {
+		export.Disputes = disputes
+	}
+
+	if balances, err := s.walletService.GetBalances(ctx, userID); err != nil {
+		return nil, err
+	} else if balances != nil {
+		export.Wallets = balances
+	}
+
+	s.logger.Info("personal data exported", slog.String("user_id", userID.String()))
+
+	return export, nil
+}
+
+// Delete anonymizes the account. Orders, ledger entries, disputes and review ratings are kept
+// for the records the marketplace must retain, they only point at an anonymized user.
+// The user row is locked first, so a concurrent order for one of the seller's listings either
+// finishes before the check or sees the account frozen. The wallets are locked next, so store
+// credit cannot arrive between the balance check and the deletion.
+func (s *service) Delete(ctx context.Context, userID uuid.UUID) error {
+	now := time.Now()
+	err := dbutils.ExecTx(ctx, s.db, func(tx *sqlx.Tx) error {
+		u, err := s.userService.GetByIDForUpdateTx(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		if u == nil || u.DeletedAt != nil {
+			return errorutils.ErrNotFound
+		}
+
+		// store credit would be lost with the account, it has to be spent first
+		balances, err := s.walletService.GetBalancesForUpdateTx(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+
+		blockers, err := s.repo.GetDeletionBlockersTx(ctx, tx, userID)
+		if err != nil {
+			return fmt.Errorf("checking open orders: %w", err)
+		}
+		if reasons := deletionBlockReasons(blockers, balances); len(reasons) > 0 {
+			return fmt.Errorf("%w: %s", errorutils.ErrAccountDeletionBlocked, strings.Join(reasons, ", "))
+		}
+
+		if err := s.userService.DeleteAccountTx(ctx, tx, userID, now); err != nil {
+			return err
+		}
+		if err := s.listingService.DeactivateBySellerTx(ctx, tx, userID); err != nil {
+			return err
+		}
+		return s.repo.ClearReviewCommentsTx(ctx, tx, userID)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("account deleted", slog.String("user_id", userID.String()))
+
+	return nil
+}
+
+func deletionBlockReasons(blockers *DeletionBlockers, balances []wallet.Balance) []string {
+	var reasons []string
+	if blockers.OpenOrders > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d open orders", blockers.OpenOrders))
+	}
+	if blockers.EscrowOrders > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d orders with funds in escrow", blockers.EscrowOrders))
+	}
+	for _, b := range balances {
+		if b.Money().IsPositive() {
+			reasons = append(reasons, fmt.Sprintf("wallet balance of %s", b.Money()))
+		}
+	}
+	return reasons
+}
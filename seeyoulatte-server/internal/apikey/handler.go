@@ -0,0 +1,139 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/principal"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Service interface {
+	Create(ctx context.Context, userID uuid.UUID, req *CreateAPIKeyRequest) (*CreatedAPIKey, error)
+	List(ctx context.Context, userID uuid.UUID) ([]APIKey, error)
+	Revoke(ctx context.Context, id uuid.UUID, userID uuid.UUID) error
+}
+
+type Handler struct {
+	service Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service Service, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// CreateAPIKey - POST /api/me/api-keys (requires auth), the key is in this response only
+func (h *Handler) CreateAPIKey(c *gin.Context) {
+	p, ok := principal.FromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Not authenticated",
+		})
+		return
+	}
+	userID := p.UserID
+
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	key, err := h.service.Create(c.Request.Context(), userID, &req)
+	if err != nil {
+		if errors.Is(err, errorutils.ErrInvalidInput) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		h.logger.Error("failed to create API key",
+			slog.String("user_id", userID.String()),
+			slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create API key",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+// ListAPIKeys - GET /api/me/api-keys (requires auth), active keys without the key itself
+func (h *Handler) ListAPIKeys(c *gin.Context) {
+	p, ok := principal.FromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Not authenticated",
+		})
+		return
+	}
+	userID := p.UserID
+
+	keys, err := h.service.List(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.Error("failed to list API keys",
+			slog.String("user_id", userID.String()),
+			slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get API keys",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"api_keys": keys,
+		"count":    len(keys),
+	})
+}
+
+// RevokeAPIKey - DELETE /api/me/api-keys/:id (requires auth)
+func (h *Handler) RevokeAPIKey(c *gin.Context) {
+	p, ok := principal.FromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Not authenticated",
+		})
+		return
+	}
+	userID := p.UserID
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid API key ID",
+		})
+		return
+	}
+
+	if err := h.service.Revoke(c.Request.Context(), id, userID); err != nil {
+		if errors.Is(err, errorutils.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "API key not found",
+			})
+			return
+		}
+
+		h.logger.Error("failed to revoke API key",
+			slog.String("user_id", userID.String()),
+			slog.String("key_id", id.String()),
+			slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to revoke API key",
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,60 @@
+package apikey
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Scope grants an API key access to one group of endpoints. Sessions signed in with a
+// password or Google are not limited by scopes.
+type Scope string
+
+const (
+	ScopeListingsRead  Scope = "listings:read"  // List the user's own listings
+	ScopeListingsWrite Scope = "listings:write" // Create and update listings, e.g. to sync inventory
+	ScopeOrdersRead    Scope = "orders:read"    // List the user's orders
+	ScopeOrdersFulfill Scope = "orders:fulfill" // Accept, decline and fulfill orders as the seller
+)
+
+func (s Scope) IsValid() bool {
+	switch s {
+	case ScopeListingsRead, ScopeListingsWrite, ScopeOrdersRead, ScopeOrdersFulfill:
+		return true
+	}
+	return false
+}
+
+type APIKey struct {
+	ID         uuid.UUID      `db:"id" json:"id"`
+	UserID     uuid.UUID      `db:"user_id" json:"user_id"`
+	Name       string         `db:"name" json:"name"`
+	Prefix     string         `db:"prefix" json:"prefix"`
+	KeyHash    string         `db:"key_hash" json:"-"`
+	Scopes     pq.StringArray `db:"scopes" json:"scopes"`
+	LastUsedAt *time.Time     `db:"last_used_at" json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time     `db:"revoked_at" json:"revoked_at,omitempty"`
+	CreatedAt  time.Time      `db:"created_at" json:"created_at"`
+}
+
+// HasScope reports whether the key was granted the scope
+func (k *APIKey) HasScope(scope Scope) bool {
+	for _, s := range k.Scopes {
+		if Scope(s) == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name" binding:"required,min=1,max=100"`
+	Scopes []string `json:"scopes" binding:"required,min=1,dive,required"`
+}
+
+// CreatedAPIKey is the only response that includes the key itself
+type CreatedAPIKey struct {
+	*APIKey
+	Key string `json:"key"`
+}
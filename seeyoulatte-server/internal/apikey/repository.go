@@ -0,0 +1,135 @@
+package apikey
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(ctx context.Context, key *APIKey) error {
+	query := `
+		INSERT INTO api_keys (
+			user_id, name, prefix, key_hash, scopes
+		) VALUES (
+			$1, $2, $3, $4, $5
+		) RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		key.UserID,
+		key.Name,
+		key.Prefix,
+		key.KeyHash,
+		key.Scopes,
+	).Scan(&key.ID, &key.CreatedAt)
+
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
+
+// GetActiveByHash returns the unrevoked key with the hash, or nil
+func (r *repository) GetActiveByHash(ctx context.Context, keyHash string) (*APIKey, error) {
+	var key APIKey
+	query := `
+		SELECT
+			id, user_id, name, prefix, key_hash, scopes, last_used_at, revoked_at, created_at
+		FROM api_keys
+		WHERE key_hash = $1 AND revoked_at IS NULL
+	`
+
+	err := r.db.GetContext(ctx, &key, query, keyHash)
+	if err != nil {
+		dbErr := errorutils.AnalyzeDBErr(err)
+		if dbErr == errorutils.ErrNotFound {
+			return nil, nil
+		}
+		return nil, dbErr
+	}
+
+	return &key, nil
+}
+
+// GetActiveByUser returns the user's unrevoked keys, newest first
+func (r *repository) GetActiveByUser(ctx context.Context, userID uuid.UUID) ([]APIKey, error) {
+	var keys []APIKey
+	query := `
+		SELECT
+			id, user_id, name, prefix, key_hash, scopes, last_used_at, revoked_at, created_at
+		FROM api_keys
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY created_at DESC
+	`
+
+	err := r.db.SelectContext(ctx, &keys, query, userID)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return keys, nil
+}
+
+func (r *repository) CountActiveByUser(ctx context.Context, userID uuid.UUID) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM api_keys WHERE user_id = $1 AND revoked_at IS NULL`
+
+	err := r.db.GetContext(ctx, &count, query, userID)
+	if err != nil {
+		return 0, errorutils.AnalyzeDBErr(err)
+	}
+
+	return count, nil
+}
+
+// Revoke revokes one of the user's keys. Keys of other users are reported as not found.
+func (r *repository) Revoke(ctx context.Context, id uuid.UUID, userID uuid.UUID, at time.Time) error {
+	query := `UPDATE api_keys SET revoked_at = $1 WHERE id = $2 AND user_id = $3 AND revoked_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, at, id, userID)
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return errorutils.ErrNotFound
+	}
+
+	return nil
+}
+
+// TouchLastUsed records a use at most once a minute, so busy scripts do not write on every request
+func (r *repository) TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error {
+	query := `
+		UPDATE api_keys
+		SET last_used_at = $1
+		WHERE id = $2 AND (last_used_at IS NULL OR last_used_at < $1 - INTERVAL '1 minute')
+	`
+
+	_, err := r.db.ExecContext(ctx, query, at, id)
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,194 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/principal"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	// KeyPrefix marks SeeYouLatte keys, so a leaked key is easy to recognise in logs and secret scanners
+	KeyPrefix = "syl_"
+
+	// MaxActiveKeys caps how many unrevoked keys a user can hold
+	MaxActiveKeys = 10
+
+	displayPrefixLen = len(KeyPrefix) + 8
+)
+
+type Repository interface {
+	Create(ctx context.Context, key *APIKey) error
+	GetActiveByHash(ctx context.Context, keyHash string) (*APIKey, error)
+	GetActiveByUser(ctx context.Context, userID uuid.UUID) ([]APIKey, error)
+	CountActiveByUser(ctx context.Context, userID uuid.UUID) (int, error)
+	Revoke(ctx context.Context, id uuid.UUID, userID uuid.UUID, at time.Time) error
+	TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error
+}
+
+type UserService interface {
+	LoadPrincipal(ctx context.Context, id uuid.UUID, method principal.Method) (*principal.Principal, error)
+}
+
+type service struct {
+	repo        Repository
+	logger      *slog.Logger
+	userService UserService
+}
+
+func NewService(repo Repository, logger *slog.Logger, userService UserService) *service {
+	return &service{
+		repo:        repo,
+		logger:      logger,
+		userService: userService,
+	}
+}
+
+// Create issues a new key. The key is returned this once, only its hash is stored.
+func (s *service) Create(ctx context.Context, userID uuid.UUID, req *CreateAPIKeyRequest) (*CreatedAPIKey, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name cannot be blank", errorutils.ErrInvalidInput)
+	}
+	scopes, err := parseScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := s.repo.CountActiveByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("counting API keys: %w", err)
+	}
+	if count >= MaxActiveKeys {
+		return nil, fmt.Errorf("%w: at most %d API keys can be active, revoke one first", errorutils.ErrInvalidInput, MaxActiveKeys)
+	}
+
+	raw, err := newKey()
+	if err != nil {
+		return nil, err
+	}
+
+	key := &APIKey{
+		UserID:  userID,
+		Name:    name,
+		Prefix:  raw[:displayPrefixLen],
+		KeyHash: hashKey(raw),
+		Scopes:  scopes,
+	}
+	if err := s.repo.Create(ctx, key); err != nil {
+		return nil, fmt.Errorf("storing API key: %w", err)
+	}
+
+	s.logger.Info("API key created",
+		slog.String("user_id", userID.String()),
+		slog.String("key_id", key.ID.String()),
+		slog.String("scopes", strings.Join(scopes, ",")))
+
+	return &CreatedAPIKey{APIKey: key, Key: raw}, nil
+}
+
+func (s *service) List(ctx context.Context, userID uuid.UUID) ([]APIKey, error) {
+	keys, err := s.repo.GetActiveByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("getting API keys: %w", err)
+	}
+	if keys == nil {
+		keys = []APIKey{}
+	}
+	return keys, nil
+}
+
+func (s *service) Revoke(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
+	if err := s.repo.Revoke(ctx, id, userID, time.Now()); err != nil {
+		return err
+	}
+
+	s.logger.Info("API key revoked",
+		slog.String("user_id", userID.String()),
+		slog.String("key_id", id.String()))
+
+	return nil
+}
+
+// Authenticate resolves a key to its owner's principal for a request that needs the scope. Keys
+// of frozen users stop working with the freeze, which also covers deleted accounts.
+func (s *service) Authenticate(ctx context.Context, rawKey string, scope string) (*principal.Principal, error) {
+	if !strings.HasPrefix(rawKey, KeyPrefix) {
+		return nil, errorutils.ErrInvalidAPIKey
+	}
+
+	key, err := s.repo.GetActiveByHash(ctx, hashKey(rawKey))
+	if err != nil {
+		return nil, fmt.Errorf("getting API key: %w", err)
+	}
+	if key == nil {
+		return nil, errorutils.ErrInvalidAPIKey
+	}
+	if !key.HasScope(Scope(scope)) {
+		return nil, errorutils.ErrInsufficientScope
+	}
+
+	p, err := s.userService.LoadPrincipal(ctx, key.UserID, principal.MethodAPIKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.RequireActive(); err != nil {
+		return nil, err
+	}
+	p.APIKeyID = &key.ID
+
+	// A failed write only loses the timestamp, the request itself is fine
+	if err := s.repo.TouchLastUsed(ctx, key.ID, time.Now()); err != nil {
+		s.logger.Error("failed to record API key use",
+			slog.String("key_id", key.ID.String()),
+			slog.String("error", err.Error()))
+	}
+
+	return p, nil
+}
+
+// parseScopes validates and de-duplicates the requested scopes
+func parseScopes(requested []string) (pq.StringArray, error) {
+	seen := make(map[Scope]bool, len(requested))
+	scopes := pq.StringArray{}
+	for _, r := range requested {
+		scope := Scope(strings.TrimSpace(r))
+		if !scope.IsValid() {
+			return nil, fmt.Errorf("%w: unknown scope %q", errorutils.ErrInvalidInput, r)
+		}
+		if seen[scope] {
+			continue
+		}
+		seen[scope] = true
+		scopes = append(scopes, string(scope))
+	}
+	// a key without scopes could not call anything
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", errorutils.ErrInvalidInput)
+	}
+	return scopes, nil
+}
+
+// newKey returns "syl_" followed by 256 random bits
+func newKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating API key: %w", err)
+	}
+	return KeyPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
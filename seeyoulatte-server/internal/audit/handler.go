@@ -0,0 +1,64 @@
+package audit
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Service interface {
+	GetAll(ctx context.Context, filter Filter) ([]Entry, error)
+}
+
+type Handler struct {
+	service Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service Service, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// GetAuditLog - GET /api/admin/audit?admin_id=&target_id=&limit= (requires admin)
+func (h *Handler) GetAuditLog(c *gin.Context) {
+	var filter Filter
+
+	if v := c.Query("admin_id"); v != "" {
+		adminID, err := uuid.Parse(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid admin_id"})
+			return
+		}
+		filter.AdminID = &adminID
+	}
+
+	filter.TargetID = c.Query("target_id")
+
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	entries, err := h.service.GetAll(c.Request.Context(), filter)
+	if err != nil {
+		h.logger.Error("failed to get audit log", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get audit log"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"count":   len(entries),
+	})
+}
@@ -0,0 +1,34 @@
+package audit
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Entry is one admin request. It is written before the handler runs, so an action
+// can never take effect without its audit row, and completed with the response status.
+type Entry struct {
+	ID         int64           `db:"id" json:"id"`
+	AdminID    uuid.UUID       `db:"admin_id" json:"admin_id"`
+	Action     string          `db:"action" json:"action"` // Method and route, e.g. "POST /api/admin/users/:id/freeze"
+	Target     json.RawMessage `db:"target" json:"target"` // Route and query parameters
+	Reason     string          `db:"reason" json:"reason"`
+	RequestID  *string         `db:"request_id" json:"request_id,omitempty"`
+	StatusCode *int            `db:"status_code" json:"status_code,omitempty"` // Nil if the request never finished
+	CreatedAt  time.Time       `db:"created_at" json:"created_at"`
+}
+
+// Filter narrows the audit log, zero values match everything
+type Filter struct {
+	AdminID  *uuid.UUID
+	TargetID string // Matches the "id" parameter of the audited route
+	Limit    int
+}
+
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+	MaxReasonLen = 500
+)
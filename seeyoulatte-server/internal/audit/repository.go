@@ -0,0 +1,74 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/jmoiron/sqlx"
+)
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(ctx context.Context, entry *Entry) error {
+	query := `
+		INSERT INTO admin_audit_log (
+			admin_id, action, target, reason, request_id
+		) VALUES (
+			$1, $2, $3, $4, $5
+		) RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		entry.AdminID,
+		entry.Action,
+		[]byte(entry.Target),
+		entry.Reason,
+		entry.RequestID,
+	).Scan(&entry.ID, &entry.CreatedAt)
+
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
+
+func (r *repository) UpdateStatusCode(ctx context.Context, id int64, statusCode int) error {
+	query := `UPDATE admin_audit_log SET status_code = $1 WHERE id = $2`
+
+	_, err := r.db.ExecContext(ctx, query, statusCode, id)
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
+
+// GetAll returns the newest entries first
+func (r *repository) GetAll(ctx context.Context, filter Filter) ([]Entry, error) {
+	var entries []Entry
+	query := `
+		SELECT
+			id, admin_id, action, target, reason, request_id, status_code, created_at
+		FROM admin_audit_log
+		WHERE ($1::uuid IS NULL OR admin_id = $1)
+			AND ($2 = '' OR target->>'id' = $2)
+		ORDER BY id DESC
+		LIMIT $3
+	`
+
+	err := r.db.SelectContext(ctx, &entries, query, filter.AdminID, filter.TargetID, filter.Limit)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return entries, nil
+}
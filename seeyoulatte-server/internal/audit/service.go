@@ -0,0 +1,82 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/google/uuid"
+)
+
+type Repository interface {
+	Create(ctx context.Context, entry *Entry) error
+	UpdateStatusCode(ctx context.Context, id int64, statusCode int) error
+	GetAll(ctx context.Context, filter Filter) ([]Entry, error)
+}
+
+type service struct {
+	repo   Repository
+	logger *slog.Logger
+}
+
+func NewService(repo Repository, logger *slog.Logger) *service {
+	return &service{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// StartAdminAction records an admin request before it is handled and returns the entry id
+func (s *service) StartAdminAction(ctx context.Context, adminID uuid.UUID, action string, target map[string]string, reason string, requestID string) (int64, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return 0, fmt.Errorf("%w: a reason is required for admin actions", errorutils.ErrInvalidInput)
+	}
+	if len(reason) > MaxReasonLen {
+		return 0, fmt.Errorf("%w: reason must be at most %d characters", errorutils.ErrInvalidInput, MaxReasonLen)
+	}
+
+	targetJSON, err := json.Marshal(target)
+	if err != nil {
+		return 0, fmt.Errorf("encoding audit target: %w", err)
+	}
+
+	entry := &Entry{
+		AdminID: adminID,
+		Action:  action,
+		Target:  targetJSON,
+		Reason:  reason,
+	}
+	if requestID != "" {
+		entry.RequestID = &requestID
+	}
+
+	if err := s.repo.Create(ctx, entry); err != nil {
+		return 0, fmt.Errorf("writing audit entry: %w", err)
+	}
+
+	return entry.ID, nil
+}
+
+// FinishAdminAction stores the response status on an entry
+func (s *service) FinishAdminAction(ctx context.Context, id int64, statusCode int) error {
+	return s.repo.UpdateStatusCode(ctx, id, statusCode)
+}
+
+func (s *service) GetAll(ctx context.Context, filter Filter) ([]Entry, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultLimit
+	}
+	if filter.Limit > MaxLimit {
+		filter.Limit = MaxLimit
+	}
+
+	entries, err := s.repo.GetAll(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("getting audit log: %w", err)
+	}
+	return entries, nil
+}
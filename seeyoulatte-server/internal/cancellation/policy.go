@@ -0,0 +1,137 @@
+package cancellation
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/money"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+)
+
+// Policy is a seller's cancellation policy, chosen per listing and copied onto each order
+type Policy string
+
+const (
+	PolicyFlexible Policy = "flexible"
+	PolicyModerate Policy = "moderate"
+	PolicyStrict   Policy = "strict"
+
+	DefaultPolicy = PolicyFlexible
+)
+
+// Tier refunds Percent of the order amount when the buyer cancels at least Before the start time
+type Tier struct {
+	Before  time.Duration `json:"-"`
+	Hours   int           `json:"hours_before"`
+	Percent int           `json:"refund_percent"`
+}
+
+// Terms are the refund rules of a policy, tiers are ordered from the earliest cancellation
+type Terms struct {
+	Policy       Policy `json:"policy"`
+	Tiers        []Tier `json:"tiers"`
+	AfterPercent int    `json:"refund_percent_after"` // Once every tier has passed
+	Description  string `json:"description"`
+}
+
+func tier(hours int, percent int) Tier {
+	return Tier{Before: time.Duration(hours) * time.Hour, Hours: hours, Percent: percent}
+}
+
+var terms = map[Policy]Terms{
+	PolicyFlexible: {
+		Policy:       PolicyFlexible,
+		Tiers:        []Tier{tier(24, 100)},
+		AfterPercent: 50,
+		Description:  "Full refund until 24 hours before the start, 50% after that.",
+	},
+	PolicyModerate: {
+		Policy:       PolicyModerate,
+		Tiers:        []Tier{tier(120, 100), tier(24, 50)},
+		AfterPercent: 0,
+		Description:  "Full refund until 5 days before the start, 50% until 24 hours before, no refund after that.",
+	},
+	PolicyStrict: {
+		Policy:       PolicyStrict,
+		Tiers:        []Tier{tier(168, 50)},
+		AfterPercent: 0,
+		Description:  "50% refund until 7 days before the start, no refund after that.",
+	},
+}
+
+// ParsePolicy validates a policy name, an empty name means the default
+func ParsePolicy(name string) (Policy, error) {
+	if name == "" {
+		return DefaultPolicy, nil
+	}
+	p := Policy(name)
+	if !p.IsValid() {
+		return "", fmt.Errorf("%w: unknown cancellation policy %q", errorutils.ErrInvalidInput, name)
+	}
+	return p, nil
+}
+
+func (p Policy) IsValid() bool {
+	_, ok := terms[p]
+	return ok
+}
+
+// Terms returns the refund rules, unknown policies fall back to the default
+func (p Policy) Terms() Terms {
+	if t, ok := terms[p]; ok {
+		return t
+	}
+	return terms[DefaultPolicy]
+}
+
+// CancelledBy is the party whose action cancelled the order
+type CancelledBy string
+
+const (
+	ByBuyer  CancelledBy = "buyer"
+	BySeller CancelledBy = "seller"
+	BySystem CancelledBy = "system" // Seller did not respond in time
+)
+
+// Cancellation describes one cancellation being evaluated against a policy
+type Cancellation struct {
+	Policy   Policy
+	By       CancelledBy
+	StartsAt *time.Time // Experience start, nil when the listing has none
+	Accepted bool       // Whether the seller had already accepted the order
+	At       time.Time
+}
+
+// RefundPercent returns how much of the order amount goes back to the buyer.
+// Only buyer cancellations are subject to the policy, when the seller declines or never
+// responds the buyer always gets everything back. Until the seller accepts, a buyer may
+// also cancel for a full refund, however close the start is. After that the policy counts
+// down to the start, or applies its final percentage when there is no start time.
+func RefundPercent(c Cancellation) int {
+	if c.By != ByBuyer || !c.Accepted {
+		return 100
+	}
+
+	t := c.Policy.Terms()
+
+	if c.StartsAt == nil {
+		return t.AfterPercent
+	}
+
+	remaining := c.StartsAt.Sub(c.At)
+	for _, tier := range t.Tiers {
+		if remaining >= tier.Before {
+			return tier.Percent
+		}
+	}
+	return t.AfterPercent
+}
+
+// Split divides an order amount into the buyer's refund and the seller's payout.
+// The refund is rounded down to a minor unit and the seller gets the remainder,
+// so the two always add up to the total.
+func Split(total money.Money, percent int) (refund money.Money, payout money.Money) {
+	refund = money.Money{Minor: total.Minor * int64(percent) / 100, Currency: total.Currency}
+	payout = money.Money{Minor: total.Minor - refund.Minor, Currency: total.Currency}
+	return refund, payout
+}
@@ -0,0 +1,128 @@
+package cancellation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/money"
+)
+
+func TestRefundPercent(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	startsIn := func(d time.Duration) *time.Time {
+		at := now.Add(d)
+		return &at
+	}
+
+	tests := []struct {
+		name string
+		c    Cancellation
+		want int
+	}{
+		{
+			name: "seller decline refunds in full under any policy",
+			c:    Cancellation{Policy: PolicyStrict, By: BySeller, StartsAt: startsIn(time.Hour), Accepted: true, At: now},
+			want: 100,
+		},
+		{
+			name: "system timeout refunds in full",
+			c:    Cancellation{Policy: PolicyStrict, By: BySystem, At: now},
+			want: 100,
+		},
+		{
+			name: "no start time, before the seller accepts",
+			c:    Cancellation{Policy: PolicyStrict, By: ByBuyer, At: now},
+			want: 100,
+		},
+		{
+			name: "before the seller accepts, inside the strict window",
+			c:    Cancellation{Policy: PolicyStrict, By: ByBuyer, StartsAt: startsIn(time.Hour), At: now},
+			want: 100,
+		},
+		{
+			name: "no start time, after the seller accepts",
+			c:    Cancellation{Policy: PolicyFlexible, By: ByBuyer, Accepted: true, At: now},
+			want: 50,
+		},
+		{
+			name: "flexible, exactly 24 hours before",
+			c:    Cancellation{Policy: PolicyFlexible, By: ByBuyer, Accepted: true, StartsAt: startsIn(24 * time.Hour), At: now},
+			want: 100,
+		},
+		{
+			name: "flexible, just under 24 hours before",
+			c:    Cancellation{Policy: PolicyFlexible, By: ByBuyer, Accepted: true, StartsAt: startsIn(24*time.Hour - time.Second), At: now},
+			want: 50,
+		},
+		{
+			name: "moderate, more than 5 days before",
+			c:    Cancellation{Policy: PolicyModerate, By: ByBuyer, Accepted: true, StartsAt: startsIn(6 * 24 * time.Hour), At: now},
+			want: 100,
+		},
+		{
+			name: "moderate, between 5 days and 24 hours before",
+			c:    Cancellation{Policy: PolicyModerate, By: ByBuyer, Accepted: true, StartsAt: startsIn(48 * time.Hour), At: now},
+			want: 50,
+		},
+		{
+			name: "moderate, inside 24 hours",
+			c:    Cancellation{Policy: PolicyModerate, By: ByBuyer, Accepted: true, StartsAt: startsIn(time.Hour), At: now},
+			want: 0,
+		},
+		{
+			name: "strict, 7 days before",
+			c:    Cancellation{Policy: PolicyStrict, By: ByBuyer, Accepted: true, StartsAt: startsIn(7 * 24 * time.Hour), At: now},
+			want: 50,
+		},
+		{
+			name: "strict, after the start",
+			c:    Cancellation{Policy: PolicyStrict, By: ByBuyer, Accepted: true, StartsAt: startsIn(-time.Hour), At: now},
+			want: 0,
+		},
+		{
+			name: "unknown policy falls back to flexible",
+			c:    Cancellation{Policy: "lenient", By: ByBuyer, Accepted: true, StartsAt: startsIn(time.Hour), At: now},
+			want: 50,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RefundPercent(tt.c); got != tt.want {
+				t.Errorf("RefundPercent() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name       string
+		total      money.Money
+		percent    int
+		wantRefund int64
+		wantPayout int64
+	}{
+		{name: "full refund", total: money.Money{Minor: 5000, Currency: "USD"}, percent: 100, wantRefund: 5000, wantPayout: 0},
+		{name: "no refund", total: money.Money{Minor: 5000, Currency: "USD"}, percent: 0, wantRefund: 0, wantPayout: 5000},
+		{name: "half", total: money.Money{Minor: 5000, Currency: "USD"}, percent: 50, wantRefund: 2500, wantPayout: 2500},
+		{name: "odd cent goes to the seller", total: money.Money{Minor: 1001, Currency: "USD"}, percent: 50, wantRefund: 500, wantPayout: 501},
+		{name: "no minor units", total: money.Money{Minor: 1501, Currency: "JPY"}, percent: 50, wantRefund: 750, wantPayout: 751},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refund, payout := Split(tt.total, tt.percent)
+
+			if refund.Minor != tt.wantRefund || payout.Minor != tt.wantPayout {
+				t.Fatalf("Split() = %d/%d, want %d/%d", refund.Minor, payout.Minor, tt.wantRefund, tt.wantPayout)
+			}
+			if refund.Minor+payout.Minor != tt.total.Minor {
+				t.Fatalf("Split() parts add up to %d, want %d", refund.Minor+payout.Minor, tt.total.Minor)
+			}
+			if refund.Currency != tt.total.Currency || payout.Currency != tt.total.Currency {
+				t.Fatalf("Split() changed the currency to %s/%s", refund.Currency, payout.Currency)
+			}
+		})
+	}
+}
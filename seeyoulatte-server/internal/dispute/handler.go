@@ -0,0 +1,154 @@
+package dispute
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/principal"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Service interface {
+	Open(ctx context.Context, orderID uuid.UUID, buyerID uuid.UUID, req *CreateDisputeRequest) (*Dispute, error)
+	Resolve(ctx context.Context, id uuid.UUID, p *principal.Principal, req *ResolveDisputeRequest) (*Dispute, error)
+	GetAll(ctx context.Context, status Status) ([]Dispute, error)
+}
+
+type Handler struct {
+	service Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service Service, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// OpenDispute - POST /api/orders/:id/dispute (buyer, during the review period)
+func (h *Handler) OpenDispute(c *gin.Context) {
+	buyerID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	var req CreateDisputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	dispute, err := h.service.Open(c.Request.Context(), orderID, buyerID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, errorutils.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		case errors.Is(err, errorutils.ErrForbidden):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only the buyer can dispute this order"})
+		case errors.Is(err, errorutils.ErrInvalidStateTransition),
+			errors.Is(err, errorutils.ErrDuplicateResource):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			h.logger.Error("failed to open dispute",
+				slog.String("error", err.Error()),
+				slog.String("order_id", orderID.String()),
+				slog.String("buyer_id", buyerID.String()))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open dispute"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, dispute)
+}
+
+// GetDisputes - GET /api/admin/disputes?status= (requires admin)
+func (h *Handler) GetDisputes(c *gin.Context) {
+	disputes, err := h.service.GetAll(c.Request.Context(), Status(c.Query("status")))
+	if err != nil {
+		if errors.Is(err, errorutils.ErrInvalidInput) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.Error("failed to get disputes", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get disputes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"disputes": disputes,
+		"count":    len(disputes),
+	})
+}
+
+// ResolveDispute - POST /api/admin/disputes/:id/resolve (requires admin)
+func (h *Handler) ResolveDispute(c *gin.Context) {
+	p, ok := principal.FromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dispute ID"})
+		return
+	}
+
+	var req ResolveDisputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	dispute, err := h.service.Resolve(c.Request.Context(), id, p, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, errorutils.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Dispute not found"})
+		case errors.Is(err, errorutils.ErrForbidden), errors.Is(err, errorutils.ErrTwoFactorRequired):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+		case errors.Is(err, errorutils.ErrInvalidInput),
+			errors.Is(err, errorutils.ErrInvalidAmount):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		case errors.Is(err, errorutils.ErrDisputeNotOpen),
+			errors.Is(err, errorutils.ErrInvalidStateTransition),
+			errors.Is(err, errorutils.ErrInsufficientEscrow),
+			errors.Is(err, errorutils.ErrDuplicatePayout):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, errorutils.ErrPaymentDeclined):
+			c.JSON(http.StatusPaymentRequired, gin.H{"error": err.Error()})
+		case errors.Is(err, errorutils.ErrPaymentGatewayUnavailable):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		default:
+			h.logger.Error("failed to resolve dispute",
+				slog.String("error", err.Error()),
+				slog.String("dispute_id", id.String()),
+				slog.String("admin_id", p.UserID.String()))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve dispute"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, dispute)
+}
+
+// userID extracts the authenticated user ID, writing the error response if it is missing
+func (h *Handler) userID(c *gin.Context) (uuid.UUID, bool) {
+	p, ok := principal.FromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return uuid.Nil, false
+	}
+	return p.UserID, true
+}
@@ -0,0 +1,56 @@
+package dispute
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Status is where a dispute stands, every status but open is final
+type Status string
+
+const (
+	StatusOpen             Status = "open"
+	StatusResolvedRefund   Status = "resolved_refund"   // Buyer refunded in full
+	StatusResolvedRejected Status = "resolved_rejected" // Seller paid out in full
+	StatusResolvedPartial  Status = "resolved_partial"  // Part refunded, the remainder paid out
+)
+
+func (s Status) IsValid() bool {
+	switch s {
+	case StatusOpen, StatusResolvedRefund, StatusResolvedRejected, StatusResolvedPartial:
+		return true
+	}
+	return false
+}
+
+type Dispute struct {
+	ID              uuid.UUID  `db:"id" json:"id"`
+	OrderID         uuid.UUID  `db:"order_id" json:"order_id"`
+	Reason          string     `db:"reason" json:"reason"`
+	Status          Status     `db:"status" json:"status"`
+	RefundAmount    *float64   `db:"refund_amount" json:"refund_amount,omitempty"` // In the order's currency, set on resolution
+	ResolutionNotes *string    `db:"resolution_notes" json:"resolution_notes,omitempty"`
+	ResolvedBy      *uuid.UUID `db:"resolved_by" json:"resolved_by,omitempty"`
+	ResolvedAt      *time.Time `db:"resolved_at" json:"resolved_at,omitempty"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+}
+
+type CreateDisputeRequest struct {
+	Reason string `json:"reason" binding:"required,min=10,max=2000"`
+}
+
+// Outcome is the admin's decision on an open dispute
+type Outcome string
+
+const (
+	OutcomeRefund  Outcome = "refund"
+	OutcomeReject  Outcome = "reject"
+	OutcomePartial Outcome = "partial"
+)
+
+type ResolveDisputeRequest struct {
+	Resolution   Outcome `json:"resolution" binding:"required,oneof=refund reject partial"`
+	RefundAmount float64 `json:"refund_amount,omitempty" binding:"omitempty,gt=0"` // Required for partial, in the order's currency
+	Notes        *string `json:"notes,omitempty" binding:"omitempty,max=2000"`
+}
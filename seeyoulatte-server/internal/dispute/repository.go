@@ -0,0 +1,122 @@
+package dispute
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *repository {
+	return &repository{db: db}
+}
+
+func (r *repository) CreateTx(ctx context.Context, tx *sqlx.Tx, dispute *Dispute) error {
+	query := `
+		INSERT INTO disputes (
+			order_id, reason, status
+		) VALUES (
+			$1, $2, $3
+		) RETURNING id, created_at
+	`
+
+	err := tx.QueryRowContext(
+		ctx,
+		query,
+		dispute.OrderID,
+		dispute.Reason,
+		dispute.Status,
+	).Scan(&dispute.ID, &dispute.CreatedAt)
+
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
+
+func (r *repository) GetByIDForUpdateTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (*Dispute, error) {
+	var dispute Dispute
+	query := `
+		SELECT
+			id, order_id, reason, status, refund_amount, resolution_notes, resolved_by, resolved_at, created_at
+		FROM disputes
+		WHERE id = $1
+		FOR UPDATE
+	`
+
+	err := tx.GetContext(ctx, &dispute, query, id)
+	if err != nil {
+		dbErr := errorutils.AnalyzeDBErr(err)
+		if dbErr == errorutils.ErrNotFound {
+			return nil, nil
+		}
+		return nil, dbErr
+	}
+
+	return &dispute, nil
+}
+
+// GetAll lists disputes newest first, an empty status returns every dispute
+func (r *repository) GetAll(ctx context.Context, status Status) ([]Dispute, error) {
+	var disputes []Dispute
+	query := `
+		SELECT
+			id, order_id, reason, status, refund_amount, resolution_notes, resolved_by, resolved_at, created_at
+		FROM disputes
+		WHERE ($1 = '' OR status = $1)
+		ORDER BY created_at DESC
+	`
+
+	err := r.db.SelectContext(ctx, &disputes, query, status)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return disputes, nil
+}
+
+// ResolveTx records the outcome on a dispute that is still open
+func (r *repository) ResolveTx(ctx context.Context, tx *sqlx.Tx, dispute *Dispute) error {
+	query := `
+		UPDATE disputes SET
+			status = $2,
+			refund_amount = $3,
+			resolution_notes = $4,
+			resolved_by = $5,
+			resolved_at = $6
+		WHERE id = $1 AND status = 'open'
+	`
+
+	result, err := tx.ExecContext(
+		ctx,
+		query,
+		dispute.ID,
+		dispute.Status,
+		dispute.RefundAmount,
+		dispute.ResolutionNotes,
+		dispute.ResolvedBy,
+		dispute.ResolvedAt,
+	)
+
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return errorutils.ErrDisputeNotOpen
+	}
+
+	return nil
+}
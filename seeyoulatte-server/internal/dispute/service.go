@@ -0,0 +1,170 @@
+package dispute
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/order"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/principal"
+	dbutils "github.com/darkphotonKN/seeyoulatte-app/internal/utils/db"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	CreateTx(ctx context.Context, tx *sqlx.Tx, dispute *Dispute) error
+	GetByIDForUpdateTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (*Dispute, error)
+	GetAll(ctx context.Context, status Status) ([]Dispute, error)
+	ResolveTx(ctx context.Context, tx *sqlx.Tx, dispute *Dispute) error
+}
+
+type OrderService interface {
+	ApplyDisputeEventTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, event order.Event, actor order.Actor, resolution order.DisputeResolution) (*order.Order, error)
+}
+
+type service struct {
+	repo         Repository
+	db           *sqlx.DB
+	orderService OrderService
+	logger       *slog.Logger
+}
+
+func NewService(repo Repository, db *sqlx.DB, logger *slog.Logger, orderService OrderService) *service {
+	return &service{
+		repo:         repo,
+		db:           db,
+		orderService: orderService,
+		logger:       logger,
+	}
+}
+
+// Open files a dispute for the buyer and freezes the order's payout.
+// The order transition enforces who may dispute and that the review period is still running.
+func (s *service) Open(ctx context.Context, orderID uuid.UUID, buyerID uuid.UUID, req *CreateDisputeRequest) (*Dispute, error) {
+	dispute := &Dispute{
+		OrderID: orderID,
+		Reason:  req.Reason,
+		Status:  StatusOpen,
+	}
+
+	err := dbutils.ExecTx(ctx, s.db, func(tx *sqlx.Tx) error {
+		if _, err := s.orderService.ApplyDisputeEventTx(ctx, tx, orderID, order.EventDispute, order.UserActor(buyerID), order.DisputeResolution{}); err != nil {
+			return err
+		}
+
+		if err := s.repo.CreateTx(ctx, tx, dispute); err != nil {
+			return fmt.Errorf("creating dispute: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("dispute opened",
+		slog.String("dispute_id", dispute.ID.String()),
+		slog.String("order_id", orderID.String()),
+		slog.String("buyer_id", buyerID.String()))
+
+	return dispute, nil
+}
+
+// Resolve applies an admin's decision. The order's REFUND and PAYOUT entries and the dispute
+// outcome are written in one transaction, so a dispute is never marked resolved without its money moving.
+func (s *service) Resolve(ctx context.Context, id uuid.UUID, p *principal.Principal, req *ResolveDisputeRequest) (*Dispute, error) {
+	if err := p.RequireAdmin(); err != nil {
+		return nil, err
+	}
+	adminID := p.UserID
+
+	var event order.Event
+	var status Status
+
+	switch req.Resolution {
+	case OutcomeRefund:
+		event, status = order.EventResolveRefund, StatusResolvedRefund
+	case OutcomeReject:
+		event, status = order.EventResolveReject, StatusResolvedRejected
+	case OutcomePartial:
+		if req.RefundAmount <= 0 {
+			return nil, fmt.Errorf("%w: refund_amount is required for a partial resolution", errorutils.ErrInvalidInput)
+		}
+		event, status = order.EventResolvePartial, StatusResolvedPartial
+	default:
+		return nil, fmt.Errorf("%w: unknown resolution %q", errorutils.ErrInvalidInput, req.Resolution)
+	}
+
+	var dispute *Dispute
+
+	err := dbutils.ExecTx(ctx, s.db, func(tx *sqlx.Tx) error {
+		d, err := s.repo.GetByIDForUpdateTx(ctx, tx, id)
+		if err != nil {
+			return fmt.Errorf("getting dispute: %w", err)
+		}
+		if d == nil {
+			return errorutils.ErrNotFound
+		}
+		if d.Status != StatusOpen {
+			return errorutils.ErrDisputeNotOpen
+		}
+
+		o, err := s.orderService.ApplyDisputeEventTx(ctx, tx, d.OrderID, event, order.PrincipalActor(p),
+			order.DisputeResolution{RefundAmount: req.RefundAmount})
+		if err != nil {
+			return err
+		}
+
+		// what the buyer got back, kept on the dispute for reporting
+		refunded := 0.0
+		switch status {
+		case StatusResolvedRefund:
+			refunded = o.Amount
+		case StatusResolvedPartial:
+			refunded = req.RefundAmount
+		}
+
+		now := time.Now()
+		d.Status = status
+		d.RefundAmount = &refunded
+		d.ResolutionNotes = req.Notes
+		d.ResolvedBy = &adminID
+		d.ResolvedAt = &now
+
+		if err := s.repo.ResolveTx(ctx, tx, d); err != nil {
+			return err
+		}
+
+		dispute = d
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("dispute resolved",
+		slog.String("dispute_id", id.String()),
+		slog.String("order_id", dispute.OrderID.String()),
+		slog.String("status", string(dispute.Status)),
+		slog.Float64("refund_amount", *dispute.RefundAmount),
+		slog.String("admin_id", adminID.String()))
+
+	return dispute, nil
+}
+
+func (s *service) GetAll(ctx context.Context, status Status) ([]Dispute, error) {
+	if status != "" && !status.IsValid() {
+		return nil, fmt.Errorf("%w: unknown dispute status %q", errorutils.ErrInvalidInput, status)
+	}
+
+	disputes, err := s.repo.GetAll(ctx, status)
+	if err != nil {
+		return nil, fmt.Errorf("getting disputes: %w", err)
+	}
+	return disputes, nil
+}
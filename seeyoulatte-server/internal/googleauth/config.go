@@ -0,0 +1,43 @@
+package googleauth
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// NewVerifierFromEnv builds the ID token verifier:
+//
+//	GOOGLE_CLIENT_ID       accepted audiences, comma separated for web and mobile clients
+//	GOOGLE_JWKS_FILE       verify against a local JWKS document instead of Google's keys (offline development)
+//	GOOGLE_JWKS_URL        override the key endpoint, defaults to Google's
+func NewVerifierFromEnv(logger *slog.Logger) (*Verifier, error) {
+	var clientIDs []string
+	for _, id := range strings.Split(os.Getenv("GOOGLE_CLIENT_ID"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			clientIDs = append(clientIDs, id)
+		}
+	}
+	if len(clientIDs) == 0 {
+		logger.Warn("GOOGLE_CLIENT_ID is not set, Google sign-in is disabled")
+	}
+
+	if path := os.Getenv("GOOGLE_JWKS_FILE"); path != "" {
+		keys, err := LoadKeyFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("loading GOOGLE_JWKS_FILE: %w", err)
+		}
+
+		logger.Info("verifying Google ID tokens against a local key file", slog.String("path", path))
+
+		return NewVerifier(keys, clientIDs), nil
+	}
+
+	url := os.Getenv("GOOGLE_JWKS_URL")
+	if url == "" {
+		url = GoogleCertsURL
+	}
+
+	return NewVerifier(NewJWKSKeySource(url), clientIDs), nil
+}
@@ -0,0 +1,190 @@
+package googleauth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GoogleCertsURL publishes the keys Google signs ID tokens with
+const GoogleCertsURL = "https://www.googleapis.com/oauth2/v3/certs"
+
+var ErrUnknownKey = errors.New("no Google signing key with this kid")
+
+// KeySource resolves the public key an ID token was signed with
+type KeySource interface {
+	Key(ctx context.Context, kid string) (*rsa.PublicKey, error)
+}
+
+// StaticKeySource serves a fixed key set, for tests and offline development
+type StaticKeySource map[string]*rsa.PublicKey
+
+func (s StaticKeySource) Key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	key, ok := s[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return key, nil
+}
+
+// LoadKeyFile reads a JWKS document from disk into a StaticKeySource
+func LoadKeyFile(path string) (StaticKeySource, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading key file: %w", err)
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return nil, err
+	}
+	return StaticKeySource(keys), nil
+}
+
+// JWKSKeySource fetches Google's keys and caches them for as long as the response's
+// Cache-Control max-age allows. An unknown kid triggers a refetch, at most once per
+// minRefresh, so rotated keys are picked up without hammering Google.
+type JWKSKeySource struct {
+	url        string
+	client     *http.Client
+	minRefresh time.Duration
+
+	mu        sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	expiresAt time.Time
+	fetchedAt time.Time
+}
+
+func NewJWKSKeySource(url string) *JWKSKeySource {
+	return &JWKSKeySource{
+		url:        url,
+		client:     &http.Client{Timeout: 5 * time.Second},
+		minRefresh: time.Minute,
+	}
+}
+
+func (s *JWKSKeySource) Key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	key, ok := s.keys[kid]
+	if ok && now.Before(s.expiresAt) {
+		return key, nil
+	}
+
+	// Unknown kids refetch at most once per minRefresh
+	if !ok && now.Sub(s.fetchedAt) < s.minRefresh {
+		return nil, ErrUnknownKey
+	}
+
+	if err := s.refresh(ctx, now); err != nil {
+		// An expired cached key beats failing every sign-in while Google is unreachable
+		if ok {
+			return key, nil
+		}
+		return nil, err
+	}
+
+	key, ok = s.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return key, nil
+}
+
+func (s *JWKSKeySource) refresh(ctx context.Context, now time.Time) error {
+	s.fetchedAt = now
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return fmt.Errorf("building key request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetching Google keys: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching Google keys: status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("reading Google keys: %w", err)
+	}
+
+	keys, err := parseJWKS(body)
+	if err != nil {
+		return err
+	}
+
+	s.keys = keys
+	s.expiresAt = now.Add(maxAge(resp.Header.Get("Cache-Control")))
+
+	return nil
+}
+
+// maxAge reads max-age from a Cache-Control header, defaulting to an hour
+func maxAge(cacheControl string) time.Duration {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		directive = strings.TrimSpace(directive)
+		if v, ok := strings.CutPrefix(directive, "max-age="); ok {
+			if seconds, err := strconv.Atoi(v); err == nil && seconds > 0 {
+				return time.Duration(seconds) * time.Second
+			}
+		}
+	}
+	return time.Hour
+}
+
+func parseJWKS(data []byte) (map[string]*rsa.PublicKey, error) {
+	var set struct {
+		Keys []struct {
+			KID string `json:"kid"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("decoding key set: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("decoding modulus of key %q: %w", k.KID, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("decoding exponent of key %q: %w", k.KID, err)
+		}
+		keys[k.KID] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("key set contains no RSA keys")
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,185 @@
+package googleauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testClientID = "client-123.apps.googleusercontent.com"
+
+func newTestKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	return key
+}
+
+func signIDToken(t *testing.T, key *rsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("signing token: %v", err)
+	}
+	return signed
+}
+
+// validClaims are what Google puts in an ID token for a verified account
+func validClaims() jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":            "https://accounts.google.com",
+		"aud":            testClientID,
+		"sub":            "110169484474386276334",
+		"email":          "ada@example.com",
+		"email_verified": true,
+		"name":           "Ada Lovelace",
+		"picture":        "https://example.com/ada.png",
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+	}
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	key := newTestKey(t)
+	otherKey := newTestKey(t)
+	verifier := NewVerifier(StaticKeySource{"key-1": &key.PublicKey}, []string{"other-client", testClientID})
+
+	with := func(changes jwt.MapClaims) jwt.MapClaims {
+		claims := validClaims()
+		for name, value := range changes {
+			if value == nil {
+				delete(claims, name)
+				continue
+			}
+			claims[name] = value
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name              string
+		token             string
+		wantErr           bool
+		wantEmailVerified bool
+	}{
+		{
+			name:              "valid token",
+			token:             signIDToken(t, key, "key-1", validClaims()),
+			wantEmailVerified: true,
+		},
+		{
+			name:              "short issuer form",
+			token:             signIDToken(t, key, "key-1", with(jwt.MapClaims{"iss": "accounts.google.com"})),
+			wantEmailVerified: true,
+		},
+		{
+			name:              "email_verified as the string true",
+			token:             signIDToken(t, key, "key-1", with(jwt.MapClaims{"email_verified": "true"})),
+			wantEmailVerified: true,
+		},
+		{
+			name:              "email_verified false is reported, not refused",
+			token:             signIDToken(t, key, "key-1", with(jwt.MapClaims{"email_verified": false})),
+			wantEmailVerified: false,
+		},
+		{
+			name:    "wrong audience",
+			token:   signIDToken(t, key, "key-1", with(jwt.MapClaims{"aud": "someone-elses-client"})),
+			wantErr: true,
+		},
+		{
+			name:    "wrong issuer",
+			token:   signIDToken(t, key, "key-1", with(jwt.MapClaims{"iss": "https://evil.example.com"})),
+			wantErr: true,
+		},
+		{
+			name:    "expired",
+			token:   signIDToken(t, key, "key-1", with(jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()})),
+			wantErr: true,
+		},
+		{
+			name:    "no expiry",
+			token:   signIDToken(t, key, "key-1", with(jwt.MapClaims{"exp": nil})),
+			wantErr: true,
+		},
+		{
+			name:    "no subject",
+			token:   signIDToken(t, key, "key-1", with(jwt.MapClaims{"sub": nil})),
+			wantErr: true,
+		},
+		{
+			name:    "unknown kid",
+			token:   signIDToken(t, key, "key-2", validClaims()),
+			wantErr: true,
+		},
+		{
+			name:    "signed with another key",
+			token:   signIDToken(t, otherKey, "key-1", validClaims()),
+			wantErr: true,
+		},
+		{
+			name:    "not a JWT",
+			token:   "not-a-token",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := verifier.VerifyIDToken(context.Background(), tt.token)
+
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidIDToken) {
+					t.Fatalf("VerifyIDToken() error = %v, want %v", err, ErrInvalidIDToken)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("VerifyIDToken() error = %v", err)
+			}
+			if identity.Subject != "110169484474386276334" || identity.Email != "ada@example.com" || identity.Name != "Ada Lovelace" {
+				t.Fatalf("VerifyIDToken() = %+v, want the token's subject, email and name", identity)
+			}
+			if identity.EmailVerified != tt.wantEmailVerified {
+				t.Fatalf("VerifyIDToken() EmailVerified = %v, want %v", identity.EmailVerified, tt.wantEmailVerified)
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenRefusesSymmetricSignatures(t *testing.T) {
+	key := newTestKey(t)
+	verifier := NewVerifier(StaticKeySource{"key-1": &key.PublicKey}, []string{testClientID})
+
+	// a token MACed with something public must not pass as an RS256 signature
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, validClaims())
+	token.Header["kid"] = "key-1"
+	signed, err := token.SignedString([]byte("not-a-google-key"))
+	if err != nil {
+		t.Fatalf("signing token: %v", err)
+	}
+
+	if _, err := verifier.VerifyIDToken(context.Background(), signed); !errors.Is(err, ErrInvalidIDToken) {
+		t.Fatalf("VerifyIDToken() error = %v, want %v", err, ErrInvalidIDToken)
+	}
+}
+
+func TestVerifyIDTokenRequiresClientID(t *testing.T) {
+	key := newTestKey(t)
+	verifier := NewVerifier(StaticKeySource{"key-1": &key.PublicKey}, nil)
+
+	if _, err := verifier.VerifyIDToken(context.Background(), signIDToken(t, key, "key-1", validClaims())); err == nil {
+		t.Fatal("VerifyIDToken() error = nil, want an error without a configured client ID")
+	}
+}
@@ -0,0 +1,121 @@
+package jwtauth
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// devSecret keeps local development working without configuration. It is refused in production.
+const devSecret = "your-secret-key-change-in-production"
+
+// minSecretLength is the shortest HS256 secret accepted in production
+const minSecretLength = 32
+
+// NewManagerFromEnv builds the key set from the environment:
+//
+//	JWT_SECRET       HS256 secret, registered under kid "default"
+//	JWT_KEYS         extra keys as "kid:alg:value" separated by commas, where alg is
+//	                 HS256 (value is the secret), RS256 or EdDSA (value is a PEM private key file)
+//	JWT_SIGNING_KID  kid that signs new tokens, defaults to the first JWT_KEYS entry or "default"
+//
+// With ENVIRONMENT=production at least one key is required and the development secret is refused.
+func NewManagerFromEnv(logger *slog.Logger) (*Manager, error) {
+	production := os.Getenv("ENVIRONMENT") == "production"
+
+	var keys []*Key
+	signingKID := os.Getenv("JWT_SIGNING_KID")
+
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		if production && (secret == devSecret || len(secret) < minSecretLength) {
+			return nil, fmt.Errorf("JWT_SECRET must be at least %d characters and not the development default in production", minSecretLength)
+		}
+		keys = append(keys, NewHMACKey(DefaultKID, []byte(secret)))
+	}
+
+	if v := os.Getenv("JWT_KEYS"); v != "" {
+		for i, entry := range strings.Split(v, ",") {
+			key, err := parseKey(strings.TrimSpace(entry), production)
+			if err != nil {
+				return nil, fmt.Errorf("parsing JWT_KEYS: %w", err)
+			}
+			keys = append(keys, key)
+			if i == 0 && signingKID == "" {
+				signingKID = key.ID
+			}
+		}
+	}
+
+	if len(keys) == 0 {
+		if production {
+			return nil, fmt.Errorf("JWT_SECRET or JWT_KEYS environment variable is required in production")
+		}
+		logger.Warn("no JWT key configured, using the insecure development secret")
+		keys = append(keys, NewHMACKey(DefaultKID, []byte(devSecret)))
+	}
+
+	if signingKID == "" {
+		signingKID = DefaultKID
+	}
+
+	manager, err := NewManager(keys, signingKID)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("jwt keys loaded",
+		slog.Int("keys", len(keys)),
+		slog.String("signing_kid", manager.SigningKID()),
+		slog.String("signing_alg", manager.signing.Method.Alg()))
+
+	return manager, nil
+}
+
+func parseKey(entry string, production bool) (*Key, error) {
+	parts := strings.SplitN(entry, ":", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+		return nil, fmt.Errorf("key entries must look like kid:alg:value, got %q", entry)
+	}
+	kid, alg, value := parts[0], parts[1], parts[2]
+
+	switch alg {
+	case jwt.SigningMethodHS256.Alg():
+		if production && len(value) < minSecretLength {
+			return nil, fmt.Errorf("HS256 secret for kid %q must be at least %d characters in production", kid, minSecretLength)
+		}
+		return NewHMACKey(kid, []byte(value)), nil
+
+	case jwt.SigningMethodRS256.Alg():
+		pem, err := os.ReadFile(value)
+		if err != nil {
+			return nil, fmt.Errorf("reading RS256 key for kid %q: %w", kid, err)
+		}
+		private, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("parsing RS256 key for kid %q: %w", kid, err)
+		}
+		return NewRSAKey(kid, private), nil
+
+	case jwt.SigningMethodEdDSA.Alg():
+		pem, err := os.ReadFile(value)
+		if err != nil {
+			return nil, fmt.Errorf("reading EdDSA key for kid %q: %w", kid, err)
+		}
+		private, err := jwt.ParseEdPrivateKeyFromPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("parsing EdDSA key for kid %q: %w", kid, err)
+		}
+		edKey, ok := private.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("EdDSA key for kid %q is not an Ed25519 key", kid)
+		}
+		return NewEdDSAKey(kid, edKey), nil
+
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q for kid %q, use HS256, RS256 or EdDSA", alg, kid)
+	}
+}
@@ -0,0 +1,21 @@
+package jwtauth
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	manager *Manager
+}
+
+func NewHandler(manager *Manager) *Handler {
+	return &Handler{manager: manager}
+}
+
+// JWKS - GET /.well-known/jwks.json (public), empty while only HMAC keys are configured
+func (h *Handler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.manager.JWKS())
+}
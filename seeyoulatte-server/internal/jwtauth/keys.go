@@ -0,0 +1,116 @@
+package jwtauth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// DefaultKID names the key built from JWT_SECRET. Tokens without a kid header,
+// issued before key rotation existed, are verified against it.
+const DefaultKID = "default"
+
+// ClaimTokenUse tells tokens signed by the same keys apart. Only UseAccess tokens
+// authenticate API requests.
+const (
+	ClaimTokenUse         = "token_use"
+	UseAccess             = "access"
+	UseTwoFactorChallenge = "2fa_challenge"
+)
+
+var ErrInvalidToken = errors.New("token is invalid or expired")
+
+// Key is one signing key. Every configured key verifies, only the manager's signing key signs.
+type Key struct {
+	ID        string
+	Method    jwt.SigningMethod
+	signKey   any
+	verifyKey any
+}
+
+func NewHMACKey(id string, secret []byte) *Key {
+	return &Key{ID: id, Method: jwt.SigningMethodHS256, signKey: secret, verifyKey: secret}
+}
+
+func NewRSAKey(id string, private *rsa.PrivateKey) *Key {
+	return &Key{ID: id, Method: jwt.SigningMethodRS256, signKey: private, verifyKey: &private.PublicKey}
+}
+
+func NewEdDSAKey(id string, private ed25519.PrivateKey) *Key {
+	return &Key{ID: id, Method: jwt.SigningMethodEdDSA, signKey: private, verifyKey: private.Public()}
+}
+
+// Manager issues and verifies every JWT in the application. Rotating keys means adding
+// the new key, making it the signing key, and removing the old one once its tokens expired.
+type Manager struct {
+	keys    map[string]*Key
+	signing *Key
+	methods []string
+}
+
+func NewManager(keys []*Key, signingKID string) (*Manager, error) {
+	m := &Manager{keys: make(map[string]*Key, len(keys))}
+
+	for _, key := range keys {
+		if key.ID == "" {
+			return nil, fmt.Errorf("JWT keys need a kid")
+		}
+		if _, ok := m.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate JWT kid %q", key.ID)
+		}
+		m.keys[key.ID] = key
+		m.methods = append(m.methods, key.Method.Alg())
+	}
+
+	signing, ok := m.keys[signingKID]
+	if !ok {
+		return nil, fmt.Errorf("JWT signing kid %q is not configured", signingKID)
+	}
+	m.signing = signing
+
+	return m, nil
+}
+
+// Sign issues a token with the signing key, identified by the kid header
+func (m *Manager) Sign(claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(m.signing.Method, claims)
+	token.Header["kid"] = m.signing.ID
+	return token.SignedString(m.signing.signKey)
+}
+
+// Parse verifies the token against the key named by its kid. The algorithm has to match
+// the key's, so an RS256 public key can never be used as an HS256 secret.
+func (m *Manager) Parse(tokenString string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			kid = DefaultKID
+		}
+
+		key, ok := m.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown kid %q", kid)
+		}
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, jwt.ErrTokenSignatureInvalid
+		}
+		return key.verifyKey, nil
+	}, jwt.WithValidMethods(m.methods), jwt.WithLeeway(5*time.Second))
+
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// SigningKID is the kid new tokens are issued with
+func (m *Manager) SigningKID() string {
+	return m.signing.ID
+}
@@ -0,0 +1,217 @@
+package ledger
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Service interface {
+	Export(ctx context.Context, filter ExportFilter, emit func(*ExportRow) error) error
+	GetOrderLedger(ctx context.Context, orderID uuid.UUID) ([]LedgerEntry, error)
+	CalculateOrderBalance(ctx context.Context, orderID uuid.UUID) (*BalanceCalculation, error)
+}
+
+type Handler struct {
+	service Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service Service, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+var exportCSVHeader = []string{
+	"id", "created_at", "order_id", "buyer_id", "seller_id",
+	"entry_type", "amount", "discount_amount", "currency", "wallet_id", "actor_id", "actor_type", "notes",
+}
+
+// GetOrderLedger - GET /api/admin/orders/:id/ledger (requires admin)
+// Returns every entry for the order with its current escrow and tip balances.
+func (h *Handler) GetOrderLedger(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	entries, err := h.service.GetOrderLedger(c.Request.Context(), orderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get order ledger"})
+		return
+	}
+
+	balance, err := h.service.CalculateOrderBalance(c.Request.Context(), orderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get order balance"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"order_id": orderID,
+		"entries":  entries,
+		"count":    len(entries),
+		"balance":  balance,
+	})
+}
+
+// ExportLedger - GET /api/admin/ledger/export?from=&to=&format=csv|jsonl&cursor= (requires admin)
+// from is inclusive and to exclusive, both as YYYY-MM-DD or RFC3339 in UTC. to is capped at a
+// few minutes ago and the cap is sent back in the X-Export-To header.
+// Rows are ordered by id. If a download is interrupted, pass the last id received as cursor
+// with the same from and the X-Export-To value as to, to continue where it stopped.
+func (h *Handler) ExportLedger(c *gin.Context) {
+	format := ExportFormat(c.DefaultQuery("format", string(ExportFormatCSV)))
+	if !format.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or jsonl"})
+		return
+	}
+
+	var filter ExportFilter
+	var err error
+
+	if filter.From, err = parseExportTime(c.Query("from")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from, use YYYY-MM-DD or RFC3339"})
+		return
+	}
+	if filter.To, err = parseExportTime(c.Query("to")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to, use YYYY-MM-DD or RFC3339"})
+		return
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be before to"})
+		return
+	}
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		filter.After, err = strconv.Atoi(cursor)
+		if err != nil || filter.After < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+	}
+
+	filter = filter.Settled(time.Now())
+	c.Header("X-Export-To", filter.To.UTC().Format(time.RFC3339Nano))
+
+	filename := fmt.Sprintf("ledger-%s.%s", time.Now().UTC().Format("20060102T150405Z"), format)
+	if format == ExportFormatCSV {
+		c.Header("Content-Type", "text/csv")
+	} else {
+		c.Header("Content-Type", "application/x-ndjson")
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	var emit func(*ExportRow) error
+	var flush func() error
+
+	if format == ExportFormatCSV {
+		w := csv.NewWriter(c.Writer)
+		_ = w.Write(exportCSVHeader)
+		emit = func(row *ExportRow) error {
+			return w.Write(exportCSVRecord(row))
+		}
+		flush = func() error {
+			w.Flush()
+			return w.Error()
+		}
+	} else {
+		enc := json.NewEncoder(c.Writer)
+		emit = func(row *ExportRow) error {
+			return enc.Encode(row)
+		}
+		flush = func() error { return nil }
+	}
+
+	rows := 0
+	lastID := filter.After
+	err = h.service.Export(c.Request.Context(), filter, func(row *ExportRow) error {
+		if err := emit(row); err != nil {
+			return err
+		}
+		rows++
+		lastID = row.ID
+
+		// push each full page to the client so large exports do not sit in memory
+		if rows%exportPageSize == 0 {
+			if err := flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+
+	if err != nil {
+		// headers are already sent, the client resumes from the last id it received
+		h.logger.Error("ledger export interrupted",
+			slog.String("error", err.Error()),
+			slog.Int("rows", rows),
+			slog.Int("last_id", lastID))
+		return
+	}
+
+	h.logger.Info("ledger export completed",
+		slog.String("format", string(format)),
+		slog.Int("rows", rows),
+		slog.Int("last_id", lastID))
+}
+
+func exportCSVRecord(row *ExportRow) []string {
+	record := []string{
+		strconv.Itoa(row.ID),
+		row.CreatedAt.UTC().Format(time.RFC3339),
+		row.OrderID.String(),
+		row.BuyerID.String(),
+		row.SellerID.String(),
+		string(row.EntryType),
+		strconv.FormatFloat(row.Amount, 'f', row.Currency.MinorUnits(), 64),
+		strconv.FormatFloat(row.DiscountAmount, 'f', row.Currency.MinorUnits(), 64),
+		string(row.Currency),
+		"", "", "", "",
+	}
+	if row.WalletID != nil {
+		record[9] = row.WalletID.String()
+	}
+	if row.ActorID != nil {
+		record[10] = row.ActorID.String()
+	}
+	if row.ActorType != nil {
+		record[11] = string(*row.ActorType)
+	}
+	if row.Notes != nil {
+		record[12] = *row.Notes
+	}
+	return record
+}
+
+// parseExportTime accepts a date or a full timestamp, an empty value means unbounded
+func parseExportTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	for _, layout := range []string{"2006-01-02", time.RFC3339} {
+		if t, err := time.Parse(layout, value); err == nil {
+			t = t.UTC()
+			return &t, nil
+		}
+	}
+
+	return nil, fmt.Errorf("invalid time %q", value)
+}
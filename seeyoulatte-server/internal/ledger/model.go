@@ -1,8 +1,10 @@
 package ledger
 
 import (
+	"fmt"
 	"time"
 
+	"github.com/darkphotonKN/seeyoulatte-app/internal/money"
 	"github.com/google/uuid"
 )
 
@@ -14,6 +16,10 @@ const (
 	EntryTypePayout   EntryType = "PAYOUT"
 	EntryTypeRefund   EntryType = "REFUND"
 	EntryTypeReversal EntryType = "REVERSAL"
+
+	// Tips are escrowed and paid out apart from the order amount, so disputes never touch them
+	EntryTypeTip       EntryType = "TIP"
+	EntryTypeTipPayout EntryType = "TIP_PAYOUT"
 )
 
 // ActorType represents who triggered the ledger entry
@@ -29,14 +35,22 @@ const (
 // LedgerEntry represents an immutable financial record
 // This is an append-only table - entries are never updated or deleted
 type LedgerEntry struct {
-	ID         int        `db:"id" json:"id"`
-	OrderID    uuid.UUID  `db:"order_id" json:"order_id"`
-	EntryType  EntryType  `db:"entry_type" json:"entry_type"`
-	Amount     float64    `db:"amount" json:"amount"` // Always positive, direction implied by entry_type
-	ActorID    *uuid.UUID `db:"actor_id" json:"actor_id,omitempty"`
-	ActorType  *ActorType `db:"actor_type" json:"actor_type,omitempty"`
-	Notes      *string    `db:"notes" json:"notes,omitempty"`
-	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+	ID             int            `db:"id" json:"id"`
+	OrderID        uuid.UUID      `db:"order_id" json:"order_id"`
+	EntryType      EntryType      `db:"entry_type" json:"entry_type"`
+	Amount         float64        `db:"amount" json:"amount"`                   // Always positive, direction implied by entry_type
+	DiscountAmount float64        `db:"discount_amount" json:"discount_amount"` // ESCROW only, promo discount already taken off Amount
+	Currency       money.Currency `db:"currency" json:"currency"`               // Always the order's currency
+	WalletID       *uuid.UUID     `db:"wallet_id" json:"wallet_id,omitempty"`   // ESCROW paid from, or REFUND credited to, a buyer wallet
+	ActorID        *uuid.UUID     `db:"actor_id" json:"actor_id,omitempty"`
+	ActorType      *ActorType     `db:"actor_type" json:"actor_type,omitempty"`
+	Notes          *string        `db:"notes" json:"notes,omitempty"`
+	CreatedAt      time.Time      `db:"created_at" json:"created_at"`
+}
+
+// Money returns the entry amount in its currency's minor units
+func (e *LedgerEntry) Money() money.Money {
+	return money.FromDecimal(e.Amount, e.Currency)
 }
 
 // CreateLedgerEntryRequest represents the request to create a new ledger entry
@@ -52,7 +66,7 @@ type CreateLedgerEntryRequest struct {
 // Validate checks if the entry type is valid
 func (e EntryType) IsValid() bool {
 	switch e {
-	case EntryTypeEscrow, EntryTypePayout, EntryTypeRefund, EntryTypeReversal:
+	case EntryTypeEscrow, EntryTypePayout, EntryTypeRefund, EntryTypeReversal, EntryTypeTip, EntryTypeTipPayout:
 		return true
 	default:
 		return false
@@ -71,10 +85,119 @@ func (a ActorType) IsValid() bool {
 
 // BalanceCalculation represents the result of calculating an order's escrow balance
 type BalanceCalculation struct {
-	OrderID       uuid.UUID `json:"order_id"`
-	EscrowBalance float64   `json:"escrow_balance"`
-	TotalEscrow   float64   `json:"total_escrow"`
-	TotalPayout   float64   `json:"total_payout"`
-	TotalRefund   float64   `json:"total_refund"`
-	TotalReversal float64   `json:"total_reversal"`
-}
\ No newline at end of file
+	OrderID       uuid.UUID      `json:"order_id"`
+	Currency      money.Currency `json:"currency"`
+	EscrowBalance float64        `json:"escrow_balance"`
+	TotalEscrow   float64        `json:"total_escrow"`
+	TotalPayout   float64        `json:"total_payout"`
+	TotalRefund   float64        `json:"total_refund"`
+	TotalReversal float64        `json:"total_reversal"`
+
+	// Tip escrow, never part of EscrowBalance
+	TipBalance     float64 `json:"tip_balance"`
+	TotalTip       float64 `json:"total_tip"`
+	TotalTipPayout float64 `json:"total_tip_payout"`
+}
+
+// Escrow returns the remaining escrow in the order's currency minor units
+func (b *BalanceCalculation) Escrow() money.Money {
+	return money.FromDecimal(b.EscrowBalance, b.Currency)
+}
+
+// Tips returns the tip escrow not yet paid out, in minor units
+func (b *BalanceCalculation) Tips() money.Money {
+	return money.FromDecimal(b.TipBalance, b.Currency)
+}
+
+// ExportFormat is the file format of an accounting export
+type ExportFormat string
+
+const (
+	ExportFormatCSV   ExportFormat = "csv"
+	ExportFormatJSONL ExportFormat = "jsonl"
+)
+
+func (f ExportFormat) IsValid() bool {
+	return f == ExportFormatCSV || f == ExportFormatJSONL
+}
+
+// ExportFilter selects the entries for an accounting export
+// From is inclusive and To exclusive, so consecutive monthly exports never overlap.
+// After is the resume cursor: only entries with a greater id are returned.
+type ExportFilter struct {
+	From  *time.Time
+	To    *time.Time
+	After int
+}
+
+// ExportSettleDelay is how far behind now an export stops. Ids are handed out when a row is
+// inserted but it only becomes visible at commit, so a recent id can appear after a larger one
+// was already exported. Ledger transactions commit within seconds, so five minutes is a
+// heuristic margin that makes a skipped entry unlikely, not a guarantee: a transaction held
+// open for longer than that can still commit below the cursor.
+const ExportSettleDelay = 5 * time.Minute
+
+// Settled caps To at now minus ExportSettleDelay. An export that is resumed must reuse the
+// capped To, otherwise entries that settled in between could land below the cursor.
+func (f ExportFilter) Settled(now time.Time) ExportFilter {
+	settled := now.Add(-ExportSettleDelay)
+	if f.To == nil || f.To.After(settled) {
+		f.To = &settled
+	}
+	return f
+}
+
+// ExportRow is a ledger entry joined with the parties of its order
+// ID doubles as the cursor, pass the last one received to resume an interrupted export
+type ExportRow struct {
+	ID             int            `db:"id" json:"id"`
+	CreatedAt      time.Time      `db:"created_at" json:"created_at"`
+	OrderID        uuid.UUID      `db:"order_id" json:"order_id"`
+	BuyerID        uuid.UUID      `db:"buyer_id" json:"buyer_id"`
+	SellerID       uuid.UUID      `db:"seller_id" json:"seller_id"`
+	EntryType      EntryType      `db:"entry_type" json:"entry_type"`
+	Amount         float64        `db:"amount" json:"amount"`
+	DiscountAmount float64        `db:"discount_amount" json:"discount_amount"`
+	Currency       money.Currency `db:"currency" json:"currency"`
+	WalletID       *uuid.UUID     `db:"wallet_id" json:"wallet_id,omitempty"`
+	ActorID        *uuid.UUID     `db:"actor_id" json:"actor_id,omitempty"`
+	ActorType      *ActorType     `db:"actor_type" json:"actor_type,omitempty"`
+	Notes          *string        `db:"notes" json:"notes,omitempty"`
+}
+
+// GuardError is returned when the ledger refuses an entry.
+// Err is errorutils.ErrInsufficientEscrow or errorutils.ErrDuplicatePayout, so callers can
+// match with errors.Is and read the details with errors.As.
+type GuardError struct {
+	OrderID       uuid.UUID      `json:"order_id"`
+	EntryType     EntryType      `json:"entry_type"`
+	Amount        float64        `json:"amount"`
+	Currency      money.Currency `json:"currency"`
+	EscrowBalance float64        `json:"escrow_balance"`
+	Err           error          `json:"-"`
+}
+
+func newGuardError(entry *LedgerEntry, balance *BalanceCalculation, err error) *GuardError {
+	escrow := balance.EscrowBalance
+	if entry.EntryType == EntryTypeTipPayout {
+		escrow = balance.TipBalance
+	}
+
+	return &GuardError{
+		OrderID:       entry.OrderID,
+		EntryType:     entry.EntryType,
+		Amount:        entry.Amount,
+		Currency:      entry.Currency,
+		EscrowBalance: escrow,
+		Err:           err,
+	}
+}
+
+func (e *GuardError) Error() string {
+	return fmt.Sprintf("%s of %.2f %s refused for order %s (escrow balance %.2f): %v",
+		e.EntryType, e.Amount, e.Currency, e.OrderID, e.EscrowBalance, e.Err)
+}
+
+func (e *GuardError) Unwrap() error {
+	return e.Err
+}
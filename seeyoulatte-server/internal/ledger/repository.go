@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 
+	"github.com/darkphotonKN/seeyoulatte-app/internal/money"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 )
@@ -26,9 +27,9 @@ func NewRepository(db *sqlx.DB) *repository {
 func (r *repository) Create(ctx context.Context, entry *LedgerEntry) error {
 	query := `
 		INSERT INTO ledger_entries (
-			order_id, entry_type, amount, actor_id, actor_type, notes
+			order_id, entry_type, amount, discount_amount, currency, wallet_id, actor_id, actor_type, notes
 		) VALUES (
-			$1, $2, $3, $4, $5, $6
+			$1, $2, $3, $4, $5, $6, $7, $8, $9
 		) RETURNING id, created_at
 	`
 
@@ -38,6 +39,9 @@ func (r *repository) Create(ctx context.Context, entry *LedgerEntry) error {
 		entry.OrderID,
 		entry.EntryType,
 		entry.Amount,
+		entry.DiscountAmount,
+		entry.Currency,
+		entry.WalletID,
 		entry.ActorID,
 		entry.ActorType,
 		entry.Notes,
@@ -50,13 +54,62 @@ func (r *repository) Create(ctx context.Context, entry *LedgerEntry) error {
 	return nil
 }
 
+// CreateTx inserts a new ledger entry as part of a larger transaction,
+// so the entry commits or rolls back together with the order transition
+func (r *repository) CreateTx(ctx context.Context, tx *sqlx.Tx, entry *LedgerEntry) error {
+	query := `
+		INSERT INTO ledger_entries (
+			order_id, entry_type, amount, discount_amount, currency, wallet_id, actor_id, actor_type, notes
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9
+		) RETURNING id, created_at
+	`
+
+	err := tx.QueryRowContext(
+		ctx,
+		query,
+		entry.OrderID,
+		entry.EntryType,
+		entry.Amount,
+		entry.DiscountAmount,
+		entry.Currency,
+		entry.WalletID,
+		entry.ActorID,
+		entry.ActorType,
+		entry.Notes,
+	).Scan(&entry.ID, &entry.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create ledger entry: %w", err)
+	}
+
+	return nil
+}
+
+// LockOrderTx takes the order's row lock for the rest of the transaction and returns its currency
+// Every append locks first, so balance checks cannot race with each other
+func (r *repository) LockOrderTx(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID) (money.Currency, error) {
+	var currency money.Currency
+	query := `SELECT currency FROM orders WHERE id = $1 FOR UPDATE`
+
+	err := tx.GetContext(ctx, &currency, query, orderID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("order %s not found", orderID)
+		}
+		return "", fmt.Errorf("failed to lock order: %w", err)
+	}
+
+	return currency, nil
+}
+
 // GetByID retrieves a single ledger entry by ID
 func (r *repository) GetByID(ctx context.Context, id int) (*LedgerEntry, error) {
 	var entry LedgerEntry
 	query := `
 		SELECT
-			id, order_id, entry_type, amount,
-			actor_id, actor_type, notes, created_at
+			id, order_id, entry_type, amount, discount_amount, currency,
+			wallet_id, actor_id, actor_type, notes, created_at
 		FROM ledger_entries
 		WHERE id = $1
 	`
@@ -78,8 +131,8 @@ func (r *repository) GetByOrderID(ctx context.Context, orderID uuid.UUID) ([]Led
 	var entries []LedgerEntry
 	query := `
 		SELECT
-			id, order_id, entry_type, amount,
-			actor_id, actor_type, notes, created_at
+			id, order_id, entry_type, amount, discount_amount, currency,
+			wallet_id, actor_id, actor_type, notes, created_at
 		FROM ledger_entries
 		WHERE order_id = $1
 		ORDER BY created_at ASC, id ASC
@@ -93,37 +146,64 @@ func (r *repository) GetByOrderID(ctx context.Context, orderID uuid.UUID) ([]Led
 	return entries, nil
 }
 
-// GetOrderBalance calculates the escrow balance for an order
-// Follows the formula from SPECIFICATION.md:
+// orderBalanceQuery follows the formula from SPECIFICATION.md:
 // ESCROW entries add to balance, PAYOUT/REFUND/REVERSAL subtract
+// Tips are tracked as a separate balance: TIP adds, TIP_PAYOUT subtracts
+const orderBalanceQuery = `
+	SELECT
+		o.currency,
+		COALESCE(SUM(CASE WHEN le.entry_type = 'ESCROW' THEN le.amount ELSE 0 END), 0) as total_escrow,
+		COALESCE(SUM(CASE WHEN le.entry_type = 'PAYOUT' THEN le.amount ELSE 0 END), 0) as total_payout,
+		COALESCE(SUM(CASE WHEN le.entry_type = 'REFUND' THEN le.amount ELSE 0 END), 0) as total_refund,
+		COALESCE(SUM(CASE WHEN le.entry_type = 'REVERSAL' THEN le.amount ELSE 0 END), 0) as total_reversal,
+		COALESCE(SUM(
+			CASE
+				WHEN le.entry_type = 'ESCROW' THEN le.amount
+				WHEN le.entry_type IN ('PAYOUT', 'REFUND', 'REVERSAL') THEN -le.amount
+				ELSE 0
+			END
+		), 0) as escrow_balance,
+		COALESCE(SUM(CASE WHEN le.entry_type = 'TIP' THEN le.amount ELSE 0 END), 0) as total_tip,
+		COALESCE(SUM(CASE WHEN le.entry_type = 'TIP_PAYOUT' THEN le.amount ELSE 0 END), 0) as total_tip_payout,
+		COALESCE(SUM(
+			CASE
+				WHEN le.entry_type = 'TIP' THEN le.amount
+				WHEN le.entry_type = 'TIP_PAYOUT' THEN -le.amount
+				ELSE 0
+			END
+		), 0) as tip_balance
+	FROM orders as o
+	LEFT JOIN ledger_entries as le
+	ON le.order_id = o.id
+	WHERE o.id = $1
+	GROUP BY o.currency
+`
+
+// GetOrderBalance calculates the escrow balance for an order
 func (r *repository) GetOrderBalance(ctx context.Context, orderID uuid.UUID) (*BalanceCalculation, error) {
-	query := `
-		SELECT
-			COALESCE(SUM(CASE WHEN entry_type = 'ESCROW' THEN amount ELSE 0 END), 0) as total_escrow,
-			COALESCE(SUM(CASE WHEN entry_type = 'PAYOUT' THEN amount ELSE 0 END), 0) as total_payout,
-			COALESCE(SUM(CASE WHEN entry_type = 'REFUND' THEN amount ELSE 0 END), 0) as total_refund,
-			COALESCE(SUM(CASE WHEN entry_type = 'REVERSAL' THEN amount ELSE 0 END), 0) as total_reversal,
-			COALESCE(SUM(
-				CASE
-					WHEN entry_type = 'ESCROW' THEN amount
-					WHEN entry_type IN ('PAYOUT', 'REFUND', 'REVERSAL') THEN -amount
-					ELSE 0
-				END
-			), 0) as escrow_balance
-		FROM ledger_entries
-		WHERE order_id = $1
-	`
+	return scanBalance(r.db.QueryRowContext(ctx, orderBalanceQuery, orderID), orderID)
+}
+
+// GetOrderBalanceTx calculates the escrow balance inside a transaction,
+// including entries written earlier in that same transaction
+func (r *repository) GetOrderBalanceTx(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID) (*BalanceCalculation, error) {
+	return scanBalance(tx.QueryRowContext(ctx, orderBalanceQuery, orderID), orderID)
+}
 
+func scanBalance(row *sql.Row, orderID uuid.UUID) (*BalanceCalculation, error) {
 	var calc BalanceCalculation
 	calc.OrderID = orderID
 
-	row := r.db.QueryRowContext(ctx, query, orderID)
 	err := row.Scan(
+		&calc.Currency,
 		&calc.TotalEscrow,
 		&calc.TotalPayout,
 		&calc.TotalRefund,
 		&calc.TotalReversal,
 		&calc.EscrowBalance,
+		&calc.TotalTip,
+		&calc.TotalTipPayout,
+		&calc.TipBalance,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to calculate order balance: %w", err)
@@ -137,8 +217,8 @@ func (r *repository) GetEntriesByType(ctx context.Context, orderID uuid.UUID, en
 	var entries []LedgerEntry
 	query := `
 		SELECT
-			id, order_id, entry_type, amount,
-			actor_id, actor_type, notes, created_at
+			id, order_id, entry_type, amount, discount_amount, currency,
+			wallet_id, actor_id, actor_type, notes, created_at
 		FROM ledger_entries
 		WHERE order_id = $1 AND entry_type = $2
 		ORDER BY created_at ASC, id ASC
@@ -167,4 +247,30 @@ func (r *repository) CountEntriesByType(ctx context.Context, orderID uuid.UUID,
 	}
 
 	return count, nil
-}
\ No newline at end of file
+}
+
+// GetExportPage returns up to limit entries after the cursor, joined with the order's parties
+// Ordering by id keeps pages stable while new entries are appended, provided To is settled
+// (see ExportFilter.Settled) so an entry below the cursor is unlikely to still commit.
+func (r *repository) GetExportPage(ctx context.Context, filter ExportFilter, limit int) ([]ExportRow, error) {
+	var rows []ExportRow
+	query := `
+		SELECT
+			le.id, le.created_at, le.order_id, o.buyer_id, o.seller_id,
+			le.entry_type, le.amount, le.discount_amount, le.currency, le.wallet_id, le.actor_id, le.actor_type, le.notes
+		FROM ledger_entries le
+		JOIN orders o ON o.id = le.order_id
+		WHERE le.id > $1
+			AND ($2::timestamp IS NULL OR le.created_at >= $2)
+			AND ($3::timestamp IS NULL OR le.created_at < $3)
+		ORDER BY le.id ASC
+		LIMIT $4
+	`
+
+	err := r.db.SelectContext(ctx, &rows, query, filter.After, filter.From, filter.To, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger export page: %w", err)
+	}
+
+	return rows, nil
+}
@@ -5,124 +5,206 @@ import (
 	"fmt"
 	"log/slog"
 
+	"github.com/darkphotonKN/seeyoulatte-app/internal/money"
+	dbutils "github.com/darkphotonKN/seeyoulatte-app/internal/utils/db"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
 	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
 )
 
 // Repository interface defines what the service needs from the repository
 // Following ISP - the service defines what it needs
 type Repository interface {
 	Create(ctx context.Context, entry *LedgerEntry) error
+	CreateTx(ctx context.Context, tx *sqlx.Tx, entry *LedgerEntry) error
+	LockOrderTx(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID) (money.Currency, error)
 	GetByID(ctx context.Context, id int) (*LedgerEntry, error)
 	GetByOrderID(ctx context.Context, orderID uuid.UUID) ([]LedgerEntry, error)
 	GetOrderBalance(ctx context.Context, orderID uuid.UUID) (*BalanceCalculation, error)
+	GetOrderBalanceTx(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID) (*BalanceCalculation, error)
 	GetEntriesByType(ctx context.Context, orderID uuid.UUID, entryType EntryType) ([]LedgerEntry, error)
 	CountEntriesByType(ctx context.Context, orderID uuid.UUID, entryType EntryType) (int, error)
+	GetExportPage(ctx context.Context, filter ExportFilter, limit int) ([]ExportRow, error)
 }
 
+// exportPageSize bounds memory per round trip, exports of any size are streamed page by page
+const exportPageSize = 1000
+
 // service implements the ledger business logic
 type service struct {
 	repo   Repository
+	db     *sqlx.DB
 	logger *slog.Logger
 }
 
 // NewService creates a new ledger service
-func NewService(repo Repository, logger *slog.Logger) *service {
+func NewService(repo Repository, db *sqlx.DB, logger *slog.Logger) *service {
 	return &service{
 		repo:   repo,
+		db:     db,
 		logger: logger,
 	}
 }
 
 // CreateEscrowEntry creates an ESCROW entry when payment is confirmed
 // This represents money entering the platform's hold
-func (s *service) CreateEscrowEntry(ctx context.Context, orderID uuid.UUID, amount float64, actorID uuid.UUID) error {
-	if amount <= 0 {
-		return fmt.Errorf("escrow amount must be positive, got %f", amount)
-	}
+func (s *service) CreateEscrowEntry(ctx context.Context, orderID uuid.UUID, amount money.Money, discount money.Money, actorID uuid.UUID) error {
+	return dbutils.ExecTx(ctx, s.db, func(tx *sqlx.Tx) error {
+		return s.CreateEscrowEntryTx(ctx, tx, orderID, amount, discount, actorID)
+	})
+}
 
-	actorType := ActorTypeBuyer
-	entry := &LedgerEntry{
-		OrderID:   orderID,
-		EntryType: EntryTypeEscrow,
-		Amount:    amount,
-		ActorID:   &actorID,
-		ActorType: &actorType,
+// CreateEscrowEntryTx creates an ESCROW entry inside the payment transition's transaction
+// amount is what the buyer paid, discount is the promo amount already taken off the subtotal
+func (s *service) CreateEscrowEntryTx(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID, amount money.Money, discount money.Money, actorID uuid.UUID) error {
+	entry, err := newEscrowEntry(orderID, amount, discount, actorID)
+	if err != nil {
+		return err
 	}
+	return s.appendTx(ctx, tx, entry)
+}
 
-	err := s.repo.Create(ctx, entry)
+// CreateWalletEscrowEntryTx creates an ESCROW entry for an order paid from the buyer's wallet
+// The entry is also the wallet debit, the caller must hold the wallet lock and have checked its balance
+func (s *service) CreateWalletEscrowEntryTx(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID, walletID uuid.UUID, amount money.Money, discount money.Money, actorID uuid.UUID) error {
+	entry, err := newEscrowEntry(orderID, amount, discount, actorID)
 	if err != nil {
-		s.logger.Error("failed to create escrow entry",
-			"orderID", orderID,
-			"amount", amount,
-			"error", err)
-		return fmt.Errorf("failed to create escrow entry: %w", err)
+		return err
 	}
+	entry.WalletID = &walletID
+	return s.appendTx(ctx, tx, entry)
+}
 
-	s.logger.Info("escrow entry created",
-		"orderID", orderID,
-		"amount", amount,
-		"entryID", entry.ID)
+func newEscrowEntry(orderID uuid.UUID, amount money.Money, discount money.Money, actorID uuid.UUID) (*LedgerEntry, error) {
+	if !amount.IsPositive() {
+		return nil, fmt.Errorf("escrow amount must be positive, got %s", amount)
+	}
+	if discount.Minor < 0 || discount.Currency != amount.Currency {
+		return nil, fmt.Errorf("invalid escrow discount %s for amount %s", discount, amount)
+	}
 
-	return nil
+	actorType := ActorTypeBuyer
+	return &LedgerEntry{
+		OrderID:        orderID,
+		EntryType:      EntryTypeEscrow,
+		Amount:         amount.Float64(),
+		DiscountAmount: discount.Float64(),
+		Currency:       amount.Currency,
+		ActorID:        &actorID,
+		ActorType:      &actorType,
+	}, nil
 }
 
 // CreatePayoutEntry creates a PAYOUT entry when money is released to the seller
-func (s *service) CreatePayoutEntry(ctx context.Context, orderID uuid.UUID, amount float64) error {
-	if amount <= 0 {
-		return fmt.Errorf("payout amount must be positive, got %f", amount)
-	}
-
-	// Check if there's sufficient escrow balance
-	balance, err := s.repo.GetOrderBalance(ctx, orderID)
-	if err != nil {
-		return fmt.Errorf("failed to check escrow balance: %w", err)
-	}
+func (s *service) CreatePayoutEntry(ctx context.Context, orderID uuid.UUID, amount money.Money) error {
+	return dbutils.ExecTx(ctx, s.db, func(tx *sqlx.Tx) error {
+		_, err := s.CreatePayoutEntryTx(ctx, tx, orderID, amount, "")
+		return err
+	})
+}
 
-	if balance.EscrowBalance < amount {
-		return fmt.Errorf("insufficient escrow balance: have %f, need %f", balance.EscrowBalance, amount)
+// CreatePayoutEntryTx creates a PAYOUT entry inside the completion or cancellation transition's
+// transaction, empty notes mean a regular completion payout.
+// It returns the entry's id, which keys the transfer that sends the money.
+func (s *service) CreatePayoutEntryTx(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID, amount money.Money, notes string) (int, error) {
+	if !amount.IsPositive() {
+		return 0, fmt.Errorf("payout amount must be positive, got %s", amount)
 	}
 
 	actorType := ActorTypeSystem
-	notes := "Order completed - payout to seller"
+	if notes == "" {
+		notes = "Order completed - payout to seller"
+	}
 	entry := &LedgerEntry{
 		OrderID:   orderID,
 		EntryType: EntryTypePayout,
-		Amount:    amount,
+		Amount:    amount.Float64(),
+		Currency:  amount.Currency,
 		ActorType: &actorType,
 		Notes:     &notes,
 	}
+	if err := s.appendTx(ctx, tx, entry); err != nil {
+		return 0, err
+	}
+	return entry.ID, nil
+}
 
-	err = s.repo.Create(ctx, entry)
-	if err != nil {
-		s.logger.Error("failed to create payout entry",
-			"orderID", orderID,
-			"amount", amount,
-			"error", err)
-		return fmt.Errorf("failed to create payout entry: %w", err)
+// CreateTipEntryTx creates a TIP entry, holding the buyer's tip in escrow until payout
+func (s *service) CreateTipEntryTx(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID, amount money.Money, actorID uuid.UUID) error {
+	if !amount.IsPositive() {
+		return fmt.Errorf("tip amount must be positive, got %s", amount)
 	}
 
-	s.logger.Info("payout entry created",
-		"orderID", orderID,
-		"amount", amount,
-		"entryID", entry.ID)
+	actorType := ActorTypeBuyer
+	notes := "Tip for the host"
+	return s.appendTx(ctx, tx, &LedgerEntry{
+		OrderID:   orderID,
+		EntryType: EntryTypeTip,
+		Amount:    amount.Float64(),
+		Currency:  amount.Currency,
+		ActorID:   &actorID,
+		ActorType: &actorType,
+		Notes:     &notes,
+	})
+}
 
-	return nil
+// CreateTipPayoutEntryTx creates a TIP_PAYOUT entry when a held tip is released to the seller
+// and returns its id, like CreatePayoutEntryTx
+func (s *service) CreateTipPayoutEntryTx(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID, amount money.Money) (int, error) {
+	if !amount.IsPositive() {
+		return 0, fmt.Errorf("tip payout amount must be positive, got %s", amount)
+	}
+
+	actorType := ActorTypeSystem
+	notes := "Tip paid out to seller"
+	entry := &LedgerEntry{
+		OrderID:   orderID,
+		EntryType: EntryTypeTipPayout,
+		Amount:    amount.Float64(),
+		Currency:  amount.Currency,
+		ActorType: &actorType,
+		Notes:     &notes,
+	}
+	if err := s.appendTx(ctx, tx, entry); err != nil {
+		return 0, err
+	}
+	return entry.ID, nil
 }
 
 // CreateRefundEntry creates a REFUND entry when money is returned to the buyer
-func (s *service) CreateRefundEntry(ctx context.Context, orderID uuid.UUID, amount float64, notes string) error {
-	if amount <= 0 {
-		return fmt.Errorf("refund amount must be positive, got %f", amount)
+func (s *service) CreateRefundEntry(ctx context.Context, orderID uuid.UUID, amount money.Money, notes string) error {
+	return dbutils.ExecTx(ctx, s.db, func(tx *sqlx.Tx) error {
+		_, err := s.CreateRefundEntryTx(ctx, tx, orderID, amount, notes)
+		return err
+	})
+}
+
+// CreateRefundEntryTx creates a REFUND entry inside the cancellation transition's transaction
+// and returns its id, like CreatePayoutEntryTx
+func (s *service) CreateRefundEntryTx(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID, amount money.Money, notes string) (int, error) {
+	entry, err := newRefundEntry(orderID, amount, notes)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.appendTx(ctx, tx, entry); err != nil {
+		return 0, err
 	}
+	return entry.ID, nil
+}
 
-	// Check if there's sufficient escrow balance
-	balance, err := s.repo.GetOrderBalance(ctx, orderID)
+// CreateWalletRefundEntryTx creates a REFUND entry that credits the buyer's wallet as store credit
+func (s *service) CreateWalletRefundEntryTx(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID, walletID uuid.UUID, amount money.Money, notes string) error {
+	entry, err := newRefundEntry(orderID, amount, notes)
 	if err != nil {
-		return fmt.Errorf("failed to check escrow balance: %w", err)
+		return err
 	}
+	entry.WalletID = &walletID
+	return s.appendTx(ctx, tx, entry)
+}
 
-	if balance.EscrowBalance < amount {
-		return fmt.Errorf("insufficient escrow balance for refund: have %f, need %f", balance.EscrowBalance, amount)
+func newRefundEntry(orderID uuid.UUID, amount money.Money, notes string) (*LedgerEntry, error) {
+	if !amount.IsPositive() {
+		return nil, fmt.Errorf("refund amount must be positive, got %s", amount)
 	}
 
 	actorType := ActorTypeSystem
@@ -130,37 +212,21 @@ func (s *service) CreateRefundEntry(ctx context.Context, orderID uuid.UUID, amou
 		notes = "Order cancelled - refund to buyer"
 	}
 
-	entry := &LedgerEntry{
+	return &LedgerEntry{
 		OrderID:   orderID,
 		EntryType: EntryTypeRefund,
-		Amount:    amount,
+		Amount:    amount.Float64(),
+		Currency:  amount.Currency,
 		ActorType: &actorType,
 		Notes:     &notes,
-	}
-
-	err = s.repo.Create(ctx, entry)
-	if err != nil {
-		s.logger.Error("failed to create refund entry",
-			"orderID", orderID,
-			"amount", amount,
-			"error", err)
-		return fmt.Errorf("failed to create refund entry: %w", err)
-	}
-
-	s.logger.Info("refund entry created",
-		"orderID", orderID,
-		"amount", amount,
-		"notes", notes,
-		"entryID", entry.ID)
-
-	return nil
+	}, nil
 }
 
 // CreateReversalEntry creates a REVERSAL entry to correct a previous erroneous entry
 // Per SPECIFICATION.md: corrections are made via new entries, not updates
-func (s *service) CreateReversalEntry(ctx context.Context, orderID uuid.UUID, amount float64, notes string, actorID uuid.UUID) error {
-	if amount <= 0 {
-		return fmt.Errorf("reversal amount must be positive, got %f", amount)
+func (s *service) CreateReversalEntry(ctx context.Context, orderID uuid.UUID, amount money.Money, notes string, actorID uuid.UUID) error {
+	if !amount.IsPositive() {
+		return fmt.Errorf("reversal amount must be positive, got %s", amount)
 	}
 
 	if notes == "" {
@@ -168,33 +234,99 @@ func (s *service) CreateReversalEntry(ctx context.Context, orderID uuid.UUID, am
 	}
 
 	actorType := ActorTypeAdmin
-	entry := &LedgerEntry{
-		OrderID:   orderID,
-		EntryType: EntryTypeReversal,
-		Amount:    amount,
-		ActorID:   &actorID,
-		ActorType: &actorType,
-		Notes:     &notes,
+	return dbutils.ExecTx(ctx, s.db, func(tx *sqlx.Tx) error {
+		return s.appendTx(ctx, tx, &LedgerEntry{
+			OrderID:   orderID,
+			EntryType: EntryTypeReversal,
+			Amount:    amount.Float64(),
+			Currency:  amount.Currency,
+			ActorID:   &actorID,
+			ActorType: &actorType,
+			Notes:     &notes,
+		})
+	})
+}
+
+// appendTx is the single write path into the ledger.
+// It locks the order so concurrent appends for the same order serialize, then re-reads the
+// balance under that lock and refuses entries in another currency, entries that would overdraw
+// escrow, and a second payout. Refusals are returned as *GuardError.
+func (s *service) appendTx(ctx context.Context, tx *sqlx.Tx, entry *LedgerEntry) error {
+	currency, err := s.repo.LockOrderTx(ctx, tx, entry.OrderID)
+	if err != nil {
+		return fmt.Errorf("failed to lock order for ledger append: %w", err)
 	}
 
-	err := s.repo.Create(ctx, entry)
+	balance, err := s.repo.GetOrderBalanceTx(ctx, tx, entry.OrderID)
 	if err != nil {
-		s.logger.Error("failed to create reversal entry",
-			"orderID", orderID,
-			"amount", amount,
+		return fmt.Errorf("failed to check escrow balance: %w", err)
+	}
+	balance.Currency = currency
+
+	if guardErr := checkGuards(entry, balance); guardErr != nil {
+		s.logger.Warn("ledger entry refused",
+			"orderID", entry.OrderID,
+			"entryType", entry.EntryType,
+			"amount", entry.Amount,
+			"currency", entry.Currency,
+			"escrowBalance", balance.EscrowBalance,
+			"reason", guardErr.Err)
+		return guardErr
+	}
+
+	if err := s.repo.CreateTx(ctx, tx, entry); err != nil {
+		s.logger.Error("failed to create ledger entry",
+			"orderID", entry.OrderID,
+			"entryType", entry.EntryType,
+			"amount", entry.Amount,
 			"error", err)
-		return fmt.Errorf("failed to create reversal entry: %w", err)
+		return fmt.Errorf("failed to create %s entry: %w", entry.EntryType, err)
 	}
 
-	s.logger.Info("reversal entry created",
-		"orderID", orderID,
-		"amount", amount,
-		"notes", notes,
+	s.logger.Info("ledger entry created",
+		"orderID", entry.OrderID,
+		"entryType", entry.EntryType,
+		"amount", entry.Amount,
+		"currency", entry.Currency,
 		"entryID", entry.ID)
 
 	return nil
 }
 
+// checkGuards applies the ledger invariants to a proposed entry
+func checkGuards(entry *LedgerEntry, balance *BalanceCalculation) *GuardError {
+	// one escrow never mixes currencies, every entry is in the order's currency
+	if entry.Currency != balance.Currency {
+		return newGuardError(entry, balance, errorutils.ErrCurrencyMismatch)
+	}
+
+	switch entry.EntryType {
+	case EntryTypePayout:
+		// PAYOUT is terminal, a second one means a retry or a worker race
+		if balance.TotalPayout > 0 {
+			return newGuardError(entry, balance, errorutils.ErrDuplicatePayout)
+		}
+		if exceedsBalance(entry, balance) {
+			return newGuardError(entry, balance, errorutils.ErrInsufficientEscrow)
+		}
+	case EntryTypeRefund, EntryTypeReversal:
+		if exceedsBalance(entry, balance) {
+			return newGuardError(entry, balance, errorutils.ErrInsufficientEscrow)
+		}
+	case EntryTypeTipPayout:
+		// tips have their own escrow, so they never trip the PAYOUT duplicate check
+		if entry.Money().Minor > balance.Tips().Minor {
+			return newGuardError(entry, balance, errorutils.ErrInsufficientEscrow)
+		}
+	}
+	return nil
+}
+
+// exceedsBalance compares in the currency's minor units, matching what DECIMAL(10,2) stores
+func exceedsBalance(entry *LedgerEntry, balance *BalanceCalculation) bool {
+	return entry.Money().Minor > balance.Escrow().Minor
+}
+
 // CalculateOrderBalance calculates the current escrow balance for an order
 // Balance > 0: funds still held, Balance = 0: fully disbursed
 func (s *service) CalculateOrderBalance(ctx context.Context, orderID uuid.UUID) (*BalanceCalculation, error) {
@@ -256,4 +388,29 @@ func (s *service) HasRefundEntry(ctx context.Context, orderID uuid.UUID) (bool,
 		return false, fmt.Errorf("failed to check for refund entries: %w", err)
 	}
 	return count > 0, nil
-}
\ No newline at end of file
+}
+
+// Export streams every entry matching the filter to emit in id order.
+// Pages are fetched with a keyset cursor, so the export never holds one long-running query
+// and a failure part way leaves the caller with a valid prefix it can resume from.
+// Callers cap To with ExportFilter.Settled first, so entries that may not have committed yet
+// are left for a later export.
+func (s *service) Export(ctx context.Context, filter ExportFilter, emit func(*ExportRow) error) error {
+	for {
+		rows, err := s.repo.GetExportPage(ctx, filter, exportPageSize)
+		if err != nil {
+			return err
+		}
+
+		for i := range rows {
+			if err := emit(&rows[i]); err != nil {
+				return err
+			}
+		}
+
+		if len(rows) < exportPageSize {
+			return nil
+		}
+		filter.After = rows[len(rows)-1].ID
+	}
+}
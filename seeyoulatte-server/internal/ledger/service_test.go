@@ -0,0 +1,179 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/money"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// fakeRepository serves one order's balance to appendTx and records what it writes.
+// Methods appendTx does not use panic through the nil embedded interface.
+type fakeRepository struct {
+	Repository
+	currency money.Currency
+	balance  BalanceCalculation
+	created  []*LedgerEntry
+}
+
+func (r *fakeRepository) LockOrderTx(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID) (money.Currency, error) {
+	return r.currency, nil
+}
+
+func (r *fakeRepository) GetOrderBalanceTx(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID) (*BalanceCalculation, error) {
+	balance := r.balance
+	return &balance, nil
+}
+
+func (r *fakeRepository) CreateTx(ctx context.Context, tx *sqlx.Tx, entry *LedgerEntry) error {
+	r.created = append(r.created, entry)
+	return nil
+}
+
+func TestAppendTxGuards(t *testing.T) {
+	funded := BalanceCalculation{EscrowBalance: 50, TotalEscrow: 50}
+
+	tests := []struct {
+		name     string
+		currency money.Currency
+		balance  BalanceCalculation
+		entry    LedgerEntry
+		wantErr  error
+	}{
+		{
+			name:     "escrow is always accepted",
+			currency: "USD",
+			entry:    LedgerEntry{EntryType: EntryTypeEscrow, Amount: 50, Currency: "USD"},
+		},
+		{
+			name:     "refund within escrow",
+			currency: "USD",
+			balance:  funded,
+			entry:    LedgerEntry{EntryType: EntryTypeRefund, Amount: 20, Currency: "USD"},
+		},
+		{
+			name:     "refund of the whole escrow",
+			currency: "USD",
+			balance:  funded,
+			entry:    LedgerEntry{EntryType: EntryTypeRefund, Amount: 50, Currency: "USD"},
+		},
+		{
+			name:     "refund one cent over escrow",
+			currency: "USD",
+			balance:  funded,
+			entry:    LedgerEntry{EntryType: EntryTypeRefund, Amount: 50.01, Currency: "USD"},
+			wantErr:  errorutils.ErrInsufficientEscrow,
+		},
+		{
+			name:     "second refund over what is left",
+			currency: "USD",
+			balance:  BalanceCalculation{EscrowBalance: 10, TotalEscrow: 50, TotalRefund: 40},
+			entry:    LedgerEntry{EntryType: EntryTypeRefund, Amount: 20, Currency: "USD"},
+			wantErr:  errorutils.ErrInsufficientEscrow,
+		},
+		{
+			name:     "refund of a balance with float noise",
+			currency: "USD",
+			balance:  BalanceCalculation{EscrowBalance: 0.1 + 0.2, TotalEscrow: 0.3},
+			entry:    LedgerEntry{EntryType: EntryTypeRefund, Amount: 0.3, Currency: "USD"},
+		},
+		{
+			name:     "reversal over escrow",
+			currency: "USD",
+			balance:  funded,
+			entry:    LedgerEntry{EntryType: EntryTypeReversal, Amount: 60, Currency: "USD"},
+			wantErr:  errorutils.ErrInsufficientEscrow,
+		},
+		{
+			name:     "payout of the whole escrow",
+			currency: "USD",
+			balance:  funded,
+			entry:    LedgerEntry{EntryType: EntryTypePayout, Amount: 50, Currency: "USD"},
+		},
+		{
+			name:     "payout of the remainder after a partial refund",
+			currency: "USD",
+			balance:  BalanceCalculation{EscrowBalance: 30, TotalEscrow: 50, TotalRefund: 20},
+			entry:    LedgerEntry{EntryType: EntryTypePayout, Amount: 30, Currency: "USD"},
+		},
+		{
+			name:     "double payout",
+			currency: "USD",
+			balance:  BalanceCalculation{EscrowBalance: 25, TotalEscrow: 50, TotalPayout: 25},
+			entry:    LedgerEntry{EntryType: EntryTypePayout, Amount: 25, Currency: "USD"},
+			wantErr:  errorutils.ErrDuplicatePayout,
+		},
+		{
+			name:     "payout over escrow",
+			currency: "USD",
+			balance:  funded,
+			entry:    LedgerEntry{EntryType: EntryTypePayout, Amount: 75, Currency: "USD"},
+			wantErr:  errorutils.ErrInsufficientEscrow,
+		},
+		{
+			name:     "tip payout after the order payout",
+			currency: "USD",
+			balance:  BalanceCalculation{TotalEscrow: 50, TotalPayout: 50, TipBalance: 5, TotalTip: 5},
+			entry:    LedgerEntry{EntryType: EntryTypeTipPayout, Amount: 5, Currency: "USD"},
+		},
+		{
+			name:     "tip payout over the tip escrow",
+			currency: "USD",
+			balance:  BalanceCalculation{EscrowBalance: 50, TotalEscrow: 50, TipBalance: 5, TotalTip: 5},
+			entry:    LedgerEntry{EntryType: EntryTypeTipPayout, Amount: 6, Currency: "USD"},
+			wantErr:  errorutils.ErrInsufficientEscrow,
+		},
+		{
+			name:     "entry in another currency",
+			currency: "USD",
+			balance:  funded,
+			entry:    LedgerEntry{EntryType: EntryTypeRefund, Amount: 10, Currency: "EUR"},
+			wantErr:  errorutils.ErrCurrencyMismatch,
+		},
+		{
+			name:     "refund in a currency without minor units",
+			currency: "JPY",
+			balance:  BalanceCalculation{EscrowBalance: 1500, TotalEscrow: 1500},
+			entry:    LedgerEntry{EntryType: EntryTypeRefund, Amount: 1501, Currency: "JPY"},
+			wantErr:  errorutils.ErrInsufficientEscrow,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepository{currency: tt.currency, balance: tt.balance}
+			s := NewService(repo, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+			entry := tt.entry
+			entry.OrderID = uuid.New()
+			err := s.appendTx(context.Background(), nil, &entry)
+
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("appendTx() error = %v, want nil", err)
+				}
+				if len(repo.created) != 1 {
+					t.Fatalf("appendTx() wrote %d entries, want 1", len(repo.created))
+				}
+				return
+			}
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("appendTx() error = %v, want %v", err, tt.wantErr)
+			}
+			var guardErr *GuardError
+			if !errors.As(err, &guardErr) {
+				t.Fatalf("appendTx() error = %T, want *GuardError", err)
+			}
+			if len(repo.created) != 0 {
+				t.Fatalf("appendTx() wrote %d entries after refusing, want 0", len(repo.created))
+			}
+		})
+	}
+}
@@ -2,21 +2,24 @@ package listing
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"net/http"
 
+	"github.com/darkphotonKN/seeyoulatte-app/internal/principal"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
 // Service interface defines what the handler needs from the service
 type Service interface {
-	Create(ctx context.Context, sellerID uuid.UUID, req *CreateListingRequest) (*Listing, error)
+	Create(ctx context.Context, p *principal.Principal, req *CreateListingRequest) (*Listing, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*Listing, error)
 	GetAllPublic(ctx context.Context) ([]Listing, error)
 	GetMyListings(ctx context.Context, sellerID uuid.UUID) ([]Listing, error)
-	Update(ctx context.Context, id uuid.UUID, sellerID uuid.UUID, req *UpdateListingRequest) (*Listing, error)
-	Delete(ctx context.Context, id uuid.UUID, sellerID uuid.UUID) error
+	Update(ctx context.Context, id uuid.UUID, p *principal.Principal, req *UpdateListingRequest) (*Listing, error)
+	Delete(ctx context.Context, id uuid.UUID, p *principal.Principal) error
 }
 
 type Handler struct {
@@ -33,29 +36,30 @@ func NewHandler(service Service, logger *slog.Logger) *Handler {
 
 // CreateListing - POST /api/listings (requires auth)
 func (h *Handler) CreateListing(c *gin.Context) {
-	userIDStr, exists := c.Get("user_id")
-	if !exists {
+	p, ok := principal.FromContext(c)
+	if !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
 		return
 	}
 
-	userID, err := uuid.Parse(userIDStr.(string))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
-		return
-	}
-
 	var req CreateListingRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	listing, err := h.service.Create(c.Request.Context(), userID, &req)
+	listing, err := h.service.Create(c.Request.Context(), p, &req)
 	if err != nil {
+		if h.sellerRefused(c, err) {
+			return
+		}
+		if errors.Is(err, errorutils.ErrUnsupportedCurrency) || errors.Is(err, errorutils.ErrInvalidAmount) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		h.logger.Error("failed to create listing",
 			slog.String("error", err.Error()),
-			slog.String("user_id", userID.String()))
+			slog.String("user_id", p.UserID.String()))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create listing"})
 		return
 	}
@@ -106,23 +110,17 @@ func (h *Handler) GetAllListings(c *gin.Context) {
 
 // GetMyListings - GET /api/listings/my (requires auth)
 func (h *Handler) GetMyListings(c *gin.Context) {
-	userIDStr, exists := c.Get("user_id")
-	if !exists {
+	p, ok := principal.FromContext(c)
+	if !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
 		return
 	}
 
-	userID, err := uuid.Parse(userIDStr.(string))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
-		return
-	}
-
-	listings, err := h.service.GetMyListings(c.Request.Context(), userID)
+	listings, err := h.service.GetMyListings(c.Request.Context(), p.UserID)
 	if err != nil {
 		h.logger.Error("failed to get user listings",
 			slog.String("error", err.Error()),
-			slog.String("user_id", userID.String()))
+			slog.String("user_id", p.UserID.String()))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get your listings"})
 		return
 	}
@@ -135,18 +133,12 @@ func (h *Handler) GetMyListings(c *gin.Context) {
 
 // UpdateListing - PUT /api/listings/:id (requires auth & ownership)
 func (h *Handler) UpdateListing(c *gin.Context) {
-	userIDStr, exists := c.Get("user_id")
-	if !exists {
+	p, ok := principal.FromContext(c)
+	if !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
 		return
 	}
 
-	userID, err := uuid.Parse(userIDStr.(string))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
-		return
-	}
-
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
@@ -160,20 +152,27 @@ func (h *Handler) UpdateListing(c *gin.Context) {
 		return
 	}
 
-	listing, err := h.service.Update(c.Request.Context(), id, userID, &req)
+	listing, err := h.service.Update(c.Request.Context(), id, p, &req)
 	if err != nil {
+		if h.sellerRefused(c, err) {
+			return
+		}
 		if err.Error() == "listing not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Listing not found"})
 			return
 		}
-		if err.Error() == "unauthorized: you can only update your own listings" {
+		if errors.Is(err, errorutils.ErrForbidden) {
 			c.JSON(http.StatusForbidden, gin.H{"error": "You can only update your own listings"})
 			return
 		}
+		if errors.Is(err, errorutils.ErrUnsupportedCurrency) || errors.Is(err, errorutils.ErrInvalidAmount) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		h.logger.Error("failed to update listing",
 			slog.String("error", err.Error()),
 			slog.String("listing_id", id.String()),
-			slog.String("user_id", userID.String()))
+			slog.String("user_id", p.UserID.String()))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update listing"})
 		return
 	}
@@ -183,18 +182,12 @@ func (h *Handler) UpdateListing(c *gin.Context) {
 
 // DeleteListing - DELETE /api/listings/:id (requires auth & ownership)
 func (h *Handler) DeleteListing(c *gin.Context) {
-	userIDStr, exists := c.Get("user_id")
-	if !exists {
+	p, ok := principal.FromContext(c)
+	if !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
 		return
 	}
 
-	userID, err := uuid.Parse(userIDStr.(string))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
-		return
-	}
-
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
@@ -202,23 +195,36 @@ func (h *Handler) DeleteListing(c *gin.Context) {
 		return
 	}
 
-	err = h.service.Delete(c.Request.Context(), id, userID)
+	err = h.service.Delete(c.Request.Context(), id, p)
 	if err != nil {
 		if err.Error() == "listing not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Listing not found"})
 			return
 		}
-		if err.Error() == "unauthorized: you can only delete your own listings" {
+		if errors.Is(err, errorutils.ErrForbidden) {
 			c.JSON(http.StatusForbidden, gin.H{"error": "You can only delete your own listings"})
 			return
 		}
 		h.logger.Error("failed to delete listing",
 			slog.String("error", err.Error()),
 			slog.String("listing_id", id.String()),
-			slog.String("user_id", userID.String()))
+			slog.String("user_id", p.UserID.String()))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete listing"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Listing deleted successfully"})
-}
\ No newline at end of file
+}
+
+// sellerRefused answers requests from users who may not sell, reporting whether it did
+func (h *Handler) sellerRefused(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, errorutils.ErrSellerNotVerified):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Seller onboarding required. Complete it at POST /api/seller/onboard"})
+	case errors.Is(err, errorutils.ErrUserIsFrozen):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Your account has been suspended"})
+	default:
+		return false
+	}
+	return true
+}
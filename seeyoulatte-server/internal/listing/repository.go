@@ -20,10 +20,10 @@ func NewRepository(db *sqlx.DB) *repository {
 func (r *repository) Create(ctx context.Context, listing *Listing) error {
 	query := `
 		INSERT INTO listings (
-			seller_id, title, description, category, price,
-			quantity, pickup_instructions, expires_at, is_active
+			seller_id, title, description, category, price, currency,
+			quantity, pickup_instructions, expires_at, starts_at, cancellation_policy, is_active
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
 		) RETURNING id, created_at
 	`
 
@@ -35,9 +35,12 @@ func (r *repository) Create(ctx context.Context, listing *Listing) error {
 		listing.Description,
 		listing.Category,
 		listing.Price,
+		listing.Currency,
 		listing.Quantity,
 		listing.PickupInstructions,
 		listing.ExpiresAt,
+		listing.StartsAt,
+		listing.CancellationPolicy,
 		listing.IsActive,
 	).Scan(&listing.ID, &listing.CreatedAt)
 
@@ -52,8 +55,8 @@ func (r *repository) GetByID(ctx context.Context, id uuid.UUID) (*Listing, error
 	var listing Listing
 	query := `
 		SELECT
-			id, seller_id, title, description, category, price,
-			quantity, pickup_instructions, expires_at, is_active, created_at
+			id, seller_id, title, description, category, price, currency,
+			quantity, pickup_instructions, expires_at, starts_at, cancellation_policy, is_active, created_at
 		FROM listings
 		WHERE id = $1
 	`
@@ -74,8 +77,8 @@ func (r *repository) GetByIDWithSellerForUpdateTx(ctx context.Context, tx *sqlx.
 	var listing ListingWithSeller
 	query := `
 		SELECT
-			l.id as listing_id, seller_id, u.is_frozen as is_frozen, title, description, category, price,
-			quantity, pickup_instructions, expires_at, is_active, l.created_at as listing_created_at
+			l.id as listing_id, seller_id, u.is_frozen as user_is_frozen, title, description, category, price, currency,
+			quantity, pickup_instructions, expires_at, starts_at, cancellation_policy, is_active, l.created_at as listing_created_at
 		FROM listings as l
 		JOIN users as u
 		ON u.id = l.seller_id
@@ -99,13 +102,16 @@ func (r *repository) GetAllPublic(ctx context.Context) ([]Listing, error) {
 	var listings []Listing
 	query := `
 		SELECT
-			id, seller_id, title, description, category, price,
-			quantity, pickup_instructions, expires_at, is_active, created_at
-		FROM listings
+			l.id, seller_id, title, description, category, price, currency,
+			quantity, pickup_instructions, expires_at, starts_at, cancellation_policy, is_active, l.created_at
+		FROM listings as l
+		JOIN users as u
+		ON u.id = l.seller_id
 		WHERE is_active = true
 			AND quantity > 0
 			AND (expires_at IS NULL OR expires_at > NOW())
-		ORDER BY created_at DESC
+			AND u.is_frozen = false -- frozen sellers' listings come back when they are unfrozen
+		ORDER BY l.created_at DESC
 	`
 
 	err := r.db.SelectContext(ctx, &listings, query)
@@ -116,12 +122,38 @@ func (r *repository) GetAllPublic(ctx context.Context) ([]Listing, error) {
 	return listings, nil
 }
 
+// GetPublicBySellerID applies the same visibility rules as GetAllPublic to one seller
+func (r *repository) GetPublicBySellerID(ctx context.Context, sellerID uuid.UUID) ([]Listing, error) {
+	var listings []Listing
+	query := `
+		SELECT
+			l.id, seller_id, title, description, category, price, currency,
+			quantity, pickup_instructions, expires_at, starts_at, cancellation_policy, is_active, l.created_at
+		FROM listings as l
+		JOIN users as u
+		ON u.id = l.seller_id
+		WHERE l.seller_id = $1
+			AND is_active = true
+			AND quantity > 0
+			AND (expires_at IS NULL OR expires_at > NOW())
+			AND u.is_frozen = false
+		ORDER BY l.created_at DESC
+	`
+
+	err := r.db.SelectContext(ctx, &listings, query, sellerID)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return listings, nil
+}
+
 func (r *repository) GetBySellerID(ctx context.Context, sellerID uuid.UUID) ([]Listing, error) {
 	var listings []Listing
 	query := `
 		SELECT
-			id, seller_id, title, description, category, price,
-			quantity, pickup_instructions, expires_at, is_active, created_at
+			id, seller_id, title, description, category, price, currency,
+			quantity, pickup_instructions, expires_at, starts_at, cancellation_policy, is_active, created_at
 		FROM listings
 		WHERE seller_id = $1
 		ORDER BY created_at DESC
@@ -141,10 +173,13 @@ func (r *repository) Update(ctx context.Context, listing *Listing) error {
 			title = $2,
 			description = $3,
 			price = $4,
-			quantity = $5,
-			pickup_instructions = $6,
-			is_active = $7,
-			expires_at = $8
+			currency = $5,
+			quantity = $6,
+			pickup_instructions = $7,
+			is_active = $8,
+			expires_at = $9,
+			starts_at = $10,
+			cancellation_policy = $11
 		WHERE id = $1
 	`
 
@@ -155,10 +190,13 @@ func (r *repository) Update(ctx context.Context, listing *Listing) error {
 		listing.Title,
 		listing.Description,
 		listing.Price,
+		listing.Currency,
 		listing.Quantity,
 		listing.PickupInstructions,
 		listing.IsActive,
 		listing.ExpiresAt,
+		listing.StartsAt,
+		listing.CancellationPolicy,
 	)
 
 	if err != nil {
@@ -183,10 +221,13 @@ func (r *repository) UpdateTx(ctx context.Context, tx *sqlx.Tx, listing *Listing
 			title = $2,
 			description = $3,
 			price = $4,
-			quantity = $5,
-			pickup_instructions = $6,
-			is_active = $7,
-			expires_at = $8
+			currency = $5,
+			quantity = $6,
+			pickup_instructions = $7,
+			is_active = $8,
+			expires_at = $9,
+			starts_at = $10,
+			cancellation_policy = $11
 		WHERE id = $1
 	`
 
@@ -197,10 +238,13 @@ func (r *repository) UpdateTx(ctx context.Context, tx *sqlx.Tx, listing *Listing
 		listing.Title,
 		listing.Description,
 		listing.Price,
+		listing.Currency,
 		listing.Quantity,
 		listing.PickupInstructions,
 		listing.IsActive,
 		listing.ExpiresAt,
+		listing.StartsAt,
+		listing.CancellationPolicy,
 	)
 
 	if err != nil {
@@ -219,6 +263,41 @@ func (r *repository) UpdateTx(ctx context.Context, tx *sqlx.Tx, listing *Listing
 	return nil
 }
 
+// IncrementQuantityTx adds quantity back to a listing atomically,
+// used when an order is cancelled and its reserved units are released
+func (r *repository) IncrementQuantityTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, quantity int) error {
+	query := `UPDATE listings SET quantity = quantity + $1 WHERE id = $2`
+
+	result, err := tx.ExecContext(ctx, query, quantity, id)
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return errorutils.ErrNotFound
+	}
+
+	return nil
+}
+
+// DeactivateBySellerTx takes every listing of a seller off the catalogue and clears the pickup
+// instructions, which often hold an address. Listings stay because orders reference them.
+func (r *repository) DeactivateBySellerTx(ctx context.Context, tx *sqlx.Tx, sellerID uuid.UUID) error {
+	query := `UPDATE listings SET is_active = false, pickup_instructions = NULL WHERE seller_id = $1`
+
+	_, err := tx.ExecContext(ctx, query, sellerID)
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
+
 func (r *repository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM listings WHERE id = $1`
 
@@ -6,6 +6,10 @@ import (
 	"fmt"
 	"log/slog"
 
+	"github.com/darkphotonKN/seeyoulatte-app/internal/cancellation"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/money"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/principal"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 )
@@ -16,8 +20,11 @@ type Repository interface {
 	GetByIDWithSellerForUpdateTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (*ListingWithSeller, error)
 	GetAllPublic(ctx context.Context) ([]Listing, error)
 	GetBySellerID(ctx context.Context, sellerID uuid.UUID) ([]Listing, error)
+	GetPublicBySellerID(ctx context.Context, sellerID uuid.UUID) ([]Listing, error)
 	Update(ctx context.Context, listing *Listing) error
 	UpdateTx(ctx context.Context, tx *sqlx.Tx, listing *Listing) error
+	IncrementQuantityTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, quantity int) error
+	DeactivateBySellerTx(ctx context.Context, tx *sqlx.Tx, sellerID uuid.UUID) error
 	Delete(ctx context.Context, id uuid.UUID) error
 }
 
@@ -33,16 +40,36 @@ func NewService(repo Repository, logger *slog.Logger) *service {
 	}
 }
 
-func (s *service) Create(ctx context.Context, sellerID uuid.UUID, req *CreateListingRequest) (*Listing, error) {
+// Create lists an item for a seller who completed onboarding and is not frozen
+func (s *service) Create(ctx context.Context, p *principal.Principal, req *CreateListingRequest) (*Listing, error) {
+	if err := p.RequireSeller(); err != nil {
+		return nil, err
+	}
+
+	currency, err := money.ParseCurrency(req.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if err := validatePrice(req.Price, currency); err != nil {
+		return nil, err
+	}
+	policy, err := cancellation.ParsePolicy(req.CancellationPolicy)
+	if err != nil {
+		return nil, err
+	}
+
 	listing := &Listing{
-		SellerID:           sellerID,
+		SellerID:           p.UserID,
 		Title:              req.Title,
 		Description:        req.Description,
 		Category:           req.Category,
 		Price:              req.Price,
+		Currency:           currency,
 		Quantity:           req.Quantity,
 		PickupInstructions: req.PickupInstructions,
 		ExpiresAt:          req.ExpiresAt,
+		StartsAt:           req.StartsAt,
+		CancellationPolicy: policy,
 		IsActive:           true,
 	}
 
@@ -52,7 +79,7 @@ func (s *service) Create(ctx context.Context, sellerID uuid.UUID, req *CreateLis
 
 	s.logger.Info("listing created",
 		slog.String("listing_id", listing.ID.String()),
-		slog.String("seller_id", sellerID.String()),
+		slog.String("seller_id", p.UserID.String()),
 		slog.String("title", listing.Title))
 
 	return listing, nil
@@ -88,6 +115,15 @@ func (s *service) GetAllPublic(ctx context.Context) ([]Listing, error) {
 	return listings, nil
 }
 
+// GetPublicBySeller returns the seller's listings that are visible in the public catalogue
+func (s *service) GetPublicBySeller(ctx context.Context, sellerID uuid.UUID) ([]Listing, error) {
+	listings, err := s.repo.GetPublicBySellerID(ctx, sellerID)
+	if err != nil {
+		return nil, fmt.Errorf("getting seller listings: %w", err)
+	}
+	return listings, nil
+}
+
 func (s *service) GetMyListings(ctx context.Context, sellerID uuid.UUID) ([]Listing, error) {
 	listings, err := s.repo.GetBySellerID(ctx, sellerID)
 	if err != nil {
@@ -96,7 +132,17 @@ func (s *service) GetMyListings(ctx context.Context, sellerID uuid.UUID) ([]List
 	return listings, nil
 }
 
-func (s *service) Update(ctx context.Context, id uuid.UUID, sellerID uuid.UUID, req *UpdateListingRequest) (*Listing, error) {
+// DeactivateBySellerTx retires a deleted seller's listings
+func (s *service) DeactivateBySellerTx(ctx context.Context, tx *sqlx.Tx, sellerID uuid.UUID) error {
+	return s.repo.DeactivateBySellerTx(ctx, tx, sellerID)
+}
+
+// Update changes the seller's own listing, the seller must still be allowed to sell
+func (s *service) Update(ctx context.Context, id uuid.UUID, p *principal.Principal, req *UpdateListingRequest) (*Listing, error) {
+	if err := p.RequireSeller(); err != nil {
+		return nil, err
+	}
+
 	// Get existing listing
 	listing, err := s.repo.GetByID(ctx, id)
 	if err != nil {
@@ -107,8 +153,8 @@ func (s *service) Update(ctx context.Context, id uuid.UUID, sellerID uuid.UUID,
 	}
 
 	// Check ownership
-	if listing.SellerID != sellerID {
-		return nil, errors.New("unauthorized: you can only update your own listings")
+	if listing.SellerID != p.UserID {
+		return nil, fmt.Errorf("%w: you can only update your own listings", errorutils.ErrForbidden)
 	}
 
 	// Update fields if provided
@@ -118,12 +164,21 @@ func (s *service) Update(ctx context.Context, id uuid.UUID, sellerID uuid.UUID,
 	if req.Description != nil {
 		listing.Description = req.Description
 	}
-	if req.Price != nil {
-		if *req.Price < 0.01 {
-			return nil, errors.New("price must be at least 0.01")
+	if req.Currency != nil {
+		currency, err := money.ParseCurrency(*req.Currency)
+		if err != nil {
+			return nil, err
 		}
+		listing.Currency = currency
+	}
+	if req.Price != nil {
 		listing.Price = *req.Price
 	}
+	if req.Price != nil || req.Currency != nil {
+		if err := validatePrice(listing.Price, listing.Currency); err != nil {
+			return nil, err
+		}
+	}
 	if req.Quantity != nil {
 		if *req.Quantity < 0 {
 			return nil, errors.New("quantity cannot be negative")
@@ -139,6 +194,16 @@ func (s *service) Update(ctx context.Context, id uuid.UUID, sellerID uuid.UUID,
 	if req.ExpiresAt != nil {
 		listing.ExpiresAt = req.ExpiresAt
 	}
+	if req.StartsAt != nil {
+		listing.StartsAt = req.StartsAt
+	}
+	if req.CancellationPolicy != nil {
+		policy, err := cancellation.ParsePolicy(*req.CancellationPolicy)
+		if err != nil {
+			return nil, err
+		}
+		listing.CancellationPolicy = policy
+	}
 
 	// Save updates
 	if err := s.repo.Update(ctx, listing); err != nil {
@@ -147,7 +212,7 @@ func (s *service) Update(ctx context.Context, id uuid.UUID, sellerID uuid.UUID,
 
 	s.logger.Info("listing updated",
 		slog.String("listing_id", id.String()),
-		slog.String("seller_id", sellerID.String()))
+		slog.String("seller_id", p.UserID.String()))
 
 	return listing, nil
 }
@@ -164,7 +229,7 @@ func (s *service) UpdateTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, selle
 
 	// Check ownership
 	if listing.SellerID != sellerID {
-		return nil, errors.New("unauthorized: you can only update your own listings")
+		return nil, fmt.Errorf("%w: you can only update your own listings", errorutils.ErrForbidden)
 	}
 
 	// Update fields if provided
@@ -174,12 +239,21 @@ func (s *service) UpdateTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, selle
 	if req.Description != nil {
 		listing.Description = req.Description
 	}
-	if req.Price != nil {
-		if *req.Price < 0.01 {
-			return nil, errors.New("price must be at least 0.01")
+	if req.Currency != nil {
+		currency, err := money.ParseCurrency(*req.Currency)
+		if err != nil {
+			return nil, err
 		}
+		listing.Currency = currency
+	}
+	if req.Price != nil {
 		listing.Price = *req.Price
 	}
+	if req.Price != nil || req.Currency != nil {
+		if err := validatePrice(listing.Price, listing.Currency); err != nil {
+			return nil, err
+		}
+	}
 	if req.Quantity != nil {
 		if *req.Quantity < 0 {
 			return nil, errors.New("quantity cannot be negative")
@@ -195,6 +269,16 @@ func (s *service) UpdateTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, selle
 	if req.ExpiresAt != nil {
 		listing.ExpiresAt = req.ExpiresAt
 	}
+	if req.StartsAt != nil {
+		listing.StartsAt = req.StartsAt
+	}
+	if req.CancellationPolicy != nil {
+		policy, err := cancellation.ParsePolicy(*req.CancellationPolicy)
+		if err != nil {
+			return nil, err
+		}
+		listing.CancellationPolicy = policy
+	}
 
 	// Save updates
 	if err := s.repo.UpdateTx(ctx, tx, listing); err != nil {
@@ -208,7 +292,21 @@ func (s *service) UpdateTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, selle
 	return listing, nil
 }
 
-func (s *service) Delete(ctx context.Context, id uuid.UUID, sellerID uuid.UUID) error {
+func (s *service) RestoreQuantityTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, quantity int) error {
+	if err := s.repo.IncrementQuantityTx(ctx, tx, id, quantity); err != nil {
+		return fmt.Errorf("restoring listing quantity: %w", err)
+	}
+
+	s.logger.Info("listing quantity restored",
+		slog.String("listing_id", id.String()),
+		slog.Int("quantity", quantity))
+
+	return nil
+}
+
+// Delete removes the user's own listing. It needs no seller check, so a seller who lost
+// access can still take listings down.
+func (s *service) Delete(ctx context.Context, id uuid.UUID, p *principal.Principal) error {
 	// Get listing to check ownership
 	listing, err := s.repo.GetByID(ctx, id)
 	if err != nil {
@@ -219,8 +317,8 @@ func (s *service) Delete(ctx context.Context, id uuid.UUID, sellerID uuid.UUID)
 	}
 
 	// Check ownership
-	if listing.SellerID != sellerID {
-		return errors.New("unauthorized: you can only delete your own listings")
+	if listing.SellerID != p.UserID {
+		return fmt.Errorf("%w: you can only delete your own listings", errorutils.ErrForbidden)
 	}
 
 	// Delete listing
@@ -230,7 +328,19 @@ func (s *service) Delete(ctx context.Context, id uuid.UUID, sellerID uuid.UUID)
 
 	s.logger.Info("listing deleted",
 		slog.String("listing_id", id.String()),
-		slog.String("seller_id", sellerID.String()))
+		slog.String("seller_id", p.UserID.String()))
 
 	return nil
 }
+
+// validatePrice checks the price is positive and no finer than the currency's minor unit
+func validatePrice(price float64, currency money.Currency) error {
+	m, err := money.New(price, currency)
+	if err != nil {
+		return err
+	}
+	if !m.IsPositive() {
+		return fmt.Errorf("%w: price must be at least one %s minor unit", errorutils.ErrInvalidAmount, currency)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package mailer
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// NewMailerFromEnv picks the mailer named by MAILER:
+// "log" (default) writes messages to the application log, "file" appends them
+// as JSON lines to MAILER_FILE (default "mail.log").
+func NewMailerFromEnv(logger *slog.Logger) (Mailer, error) {
+	kind := os.Getenv("MAILER")
+	if kind == "" {
+		kind = "log"
+	}
+
+	switch kind {
+	case "log":
+		logger.Info("using log mailer")
+		return NewLogMailer(logger), nil
+
+	case "file":
+		path := os.Getenv("MAILER_FILE")
+		if path == "" {
+			path = "mail.log"
+		}
+
+		logger.Info("using file mailer", slog.String("path", path))
+
+		return NewFileMailer(path), nil
+
+	default:
+		return nil, fmt.Errorf("unknown MAILER %q, use log or file", kind)
+	}
+}
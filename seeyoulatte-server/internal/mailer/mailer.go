@@ -0,0 +1,20 @@
+package mailer
+
+import (
+	"context"
+	"time"
+)
+
+// Message is a plain-text email
+type Message struct {
+	To      string    `json:"to"`
+	Subject string    `json:"subject"`
+	Body    string    `json:"body"`
+	SentAt  time.Time `json:"sent_at"`
+}
+
+// Mailer delivers transactional email. The log and file mailers keep every flow
+// usable offline, a provider-backed implementation can replace them without touching callers.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/principal"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAdmin must run after AuthRequired. The principal's admin flag is read from the
+// database on every request instead of being trusted from the token, so a revoked admin
+// loses access immediately.
+func RequireAdmin(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, ok := principal.FromContext(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Authentication required",
+			})
+			c.Abort()
+			return
+		}
+
+		if err := p.RequireAdmin(); err != nil {
+			switch {
+			case errors.Is(err, errorutils.ErrForbidden):
+				c.JSON(http.StatusForbidden, gin.H{
+					"error": "Admin access required",
+				})
+			case errors.Is(err, errorutils.ErrTwoFactorRequired):
+				c.JSON(http.StatusForbidden, gin.H{
+					"error": "Admins must enable two-factor authentication",
+					"code":  "two_factor_required",
+				})
+			default:
+				logger.Error("failed to check admin access",
+					slog.String("error", err.Error()),
+					slog.String("user_id", p.UserID.String()))
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": "Failed to verify admin access",
+				})
+			}
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/principal"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// AdminReasonHeader carries the admin's justification for the request
+const AdminReasonHeader = "X-Admin-Reason"
+
+// AuditRecorder writes admin actions to the audit log
+type AuditRecorder interface {
+	StartAdminAction(ctx context.Context, adminID uuid.UUID, action string, target map[string]string, reason string, requestID string) (int64, error)
+	FinishAdminAction(ctx context.Context, id int64, statusCode int) error
+}
+
+// AuditAdmin must run after RequireAdmin. Every admin request needs a reason and is written
+// to the audit log before its handler runs; if the entry cannot be written the request is refused.
+func AuditAdmin(recorder AuditRecorder, logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, ok := principal.FromContext(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Authentication required",
+			})
+			c.Abort()
+			return
+		}
+
+		adminID := p.UserID
+
+		target := make(map[string]string, len(c.Params))
+		for _, param := range c.Params {
+			target[param.Key] = param.Value
+		}
+		for key, values := range c.Request.URL.Query() {
+			if _, exists := target[key]; !exists && len(values) > 0 {
+				target[key] = values[0]
+			}
+		}
+
+		requestID := c.GetString("request_id")
+		action := c.Request.Method + " " + c.FullPath()
+
+		entryID, err := recorder.StartAdminAction(c.Request.Context(), adminID, action, target, c.GetHeader(AdminReasonHeader), requestID)
+		if err != nil {
+			if errors.Is(err, errorutils.ErrInvalidInput) {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": err.Error() + ". Send it in the " + AdminReasonHeader + " header",
+				})
+				c.Abort()
+				return
+			}
+			logger.Error("failed to write admin audit entry",
+				slog.String("error", err.Error()),
+				slog.String("admin_id", adminID.String()),
+				slog.String("action", action))
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to record admin action",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+
+		// the request has already been handled, a failure here only loses the status code
+		if err := recorder.FinishAdminAction(context.WithoutCancel(c.Request.Context()), entryID, c.Writer.Status()); err != nil {
+			logger.Error("failed to complete admin audit entry",
+				slog.String("error", err.Error()),
+				slog.Int64("audit_id", entryID))
+		}
+	}
+}
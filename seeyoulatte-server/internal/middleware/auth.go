@@ -1,15 +1,36 @@
 package middleware
 
 import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
 	"net/http"
-	"os"
 	"strings"
+	"time"
 
+	"github.com/darkphotonKN/seeyoulatte-app/internal/jwtauth"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/principal"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-func AuthRequired() gin.HandlerFunc {
+// TokenVerifier checks a JWT's signature and expiry and returns its claims
+type TokenVerifier interface {
+	Parse(tokenString string) (jwt.MapClaims, error)
+}
+
+// TokenAuthenticator loads the principal for an access token issued at the given time, unless
+// the token is no longer honoured: logout-all and freezing revoke every token issued before them
+type TokenAuthenticator interface {
+	AuthenticateToken(ctx context.Context, userID uuid.UUID, issuedAt time.Time) (*principal.Principal, error)
+}
+
+// AuthRequired places the user's principal on the context, see principal.FromContext. It is
+// loaded from the database on every request, so the flags are never older than the request.
+func AuthRequired(verifier TokenVerifier, authenticator TokenAuthenticator, logger *slog.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get token from Authorization header
 		authHeader := c.GetHeader("Authorization")
@@ -33,21 +54,9 @@ func AuthRequired() gin.HandlerFunc {
 
 		tokenString := parts[1]
 
-		// Parse and validate token
-		jwtSecret := os.Getenv("JWT_SECRET")
-		if jwtSecret == "" {
-			jwtSecret = "your-secret-key-change-in-production"
-		}
-
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// Verify signing method
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrInvalidKey
-			}
-			return []byte(jwtSecret), nil
-		})
-
-		if err != nil || !token.Valid {
+		// Parse and validate token, two-factor challenges and other token kinds are not access tokens
+		claims, err := verifier.Parse(tokenString)
+		if err != nil || !isAccessToken(claims) {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error": "Invalid or expired token",
 			})
@@ -55,58 +64,55 @@ func AuthRequired() gin.HandlerFunc {
 			return
 		}
 
-		// Extract claims
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			// Set user info in context
-			c.Set("user_id", claims["user_id"])
-			c.Set("email", claims["email"])
-			c.Set("name", claims["name"])
-		}
-
-		c.Next()
-	}
-}
-
-func OptionalAuth() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// Get token from Authorization header
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.Next()
+		userIDStr, _ := claims["user_id"].(string)
+		userID, err := uuid.Parse(userIDStr)
+		issuedAt, ok := tokenIssuedAt(claims)
+		if err != nil || !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid or expired token",
+			})
+			c.Abort()
 			return
 		}
 
-		// Check if it's a Bearer token
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.Next()
+		// Reject tokens issued before the user's sessions were revoked
+		p, err := authenticator.AuthenticateToken(c.Request.Context(), userID, issuedAt)
+		if err != nil {
+			switch {
+			case errors.Is(err, errorutils.ErrTokenRevoked), errors.Is(err, errorutils.ErrNotFound):
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"error": "Invalid or expired token",
+				})
+			default:
+				logger.Error("failed to check token revocation",
+					slog.String("error", err.Error()),
+					slog.String("user_id", userID.String()))
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": "Failed to verify token",
+				})
+			}
+			c.Abort()
 			return
 		}
 
-		tokenString := parts[1]
-
-		// Parse and validate token
-		jwtSecret := os.Getenv("JWT_SECRET")
-		if jwtSecret == "" {
-			jwtSecret = "your-secret-key-change-in-production"
-		}
+		principal.Set(c, p)
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrInvalidKey
-			}
-			return []byte(jwtSecret), nil
-		})
+		c.Next()
+	}
+}
 
-		// If valid, set user info in context
-		if err == nil && token.Valid {
-			if claims, ok := token.Claims.(jwt.MapClaims); ok {
-				c.Set("user_id", claims["user_id"])
-				c.Set("email", claims["email"])
-				c.Set("name", claims["name"])
-			}
-		}
+func isAccessToken(claims jwt.MapClaims) bool {
+	use, _ := claims[jwtauth.ClaimTokenUse].(string)
+	return use == jwtauth.UseAccess
+}
 
-		c.Next()
+// tokenIssuedAt reads iat with its fractional part. jwt.MapClaims.GetIssuedAt truncates to whole
+// seconds, which would reject tokens issued in the same second as a logout-all.
+func tokenIssuedAt(claims jwt.MapClaims) (time.Time, bool) {
+	iat, ok := claims["iat"].(float64)
+	if !ok {
+		return time.Time{}, false
 	}
-}
\ No newline at end of file
+	sec, frac := math.Modf(iat)
+	return time.Unix(int64(sec), int64(math.Round(frac*1e6))*int64(time.Microsecond)), true
+}
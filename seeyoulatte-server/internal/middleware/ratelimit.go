@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/principal"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimiter takes a token from the bucket for a key
+type RateLimiter interface {
+	Allow(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error)
+}
+
+// KeyFunc picks the bucket a request draws from, an empty key skips limiting
+type KeyFunc func(c *gin.Context) string
+
+// ByClientIP limits per client address. Behind a proxy, gin only trusts
+// X-Forwarded-For from the proxies listed in TRUSTED_PROXIES.
+func ByClientIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// ByUser limits per signed-in user, it must run after AuthRequired
+func ByUser(c *gin.Context) string {
+	p, ok := principal.FromContext(c)
+	if !ok {
+		return ""
+	}
+	return "user:" + p.UserID.String()
+}
+
+// RateLimit answers 429 with Retry-After once the scope's budget for the key is spent.
+// If the limiter itself fails the request goes through, so an outage of the bucket store
+// cannot lock everyone out.
+func RateLimit(limiter RateLimiter, scope string, limit ratelimit.Limit, key KeyFunc, logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		k := key(c)
+		if k == "" {
+			c.Next()
+			return
+		}
+
+		result, err := limiter.Allow(c.Request.Context(), scope+":"+k, limit)
+		if err != nil {
+			logger.Error("rate limiter failed, allowing request",
+				slog.String("scope", scope),
+				slog.String("error", err.Error()))
+			c.Next()
+			return
+		}
+
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(ratelimit.RetryAfterSeconds(result.RetryAfter)))
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error": "Too many requests. Try again later",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
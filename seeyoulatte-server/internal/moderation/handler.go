@@ -0,0 +1,97 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/principal"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/user"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Service interface {
+	Freeze(ctx context.Context, id uuid.UUID, p *principal.Principal) (*FreezeResult, error)
+	Unfreeze(ctx context.Context, id uuid.UUID, p *principal.Principal) (*user.User, error)
+}
+
+type Handler struct {
+	service Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service Service, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// FreezeUser - POST /api/admin/users/:id/freeze (requires admin)
+// Also cancels the user's paid orders and holds their accepted and fulfilled ones for review.
+func (h *Handler) FreezeUser(c *gin.Context) {
+	p, id, ok := h.ids(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.Freeze(c.Request.Context(), id, p)
+	if err != nil {
+		h.writeError(c, err, id, "freeze")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// UnfreezeUser - POST /api/admin/users/:id/unfreeze (requires admin)
+func (h *Handler) UnfreezeUser(c *gin.Context) {
+	p, id, ok := h.ids(c)
+	if !ok {
+		return
+	}
+
+	u, err := h.service.Unfreeze(c.Request.Context(), id, p)
+	if err != nil {
+		h.writeError(c, err, id, "unfreeze")
+		return
+	}
+
+	c.JSON(http.StatusOK, u)
+}
+
+// ids reads the authenticated admin and the target user, writing the error response if either is invalid
+func (h *Handler) ids(c *gin.Context) (*principal.Principal, uuid.UUID, bool) {
+	p, ok := principal.FromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return nil, uuid.Nil, false
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return nil, uuid.Nil, false
+	}
+
+	return p, id, true
+}
+
+func (h *Handler) writeError(c *gin.Context, err error, id uuid.UUID, action string) {
+	switch {
+	case errors.Is(err, errorutils.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	case errors.Is(err, errorutils.ErrForbidden), errors.Is(err, errorutils.ErrTwoFactorRequired):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+	case errors.Is(err, errorutils.ErrInvalidInput):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error("failed to "+action+" user",
+			slog.String("user_id", id.String()),
+			slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to " + action + " user"})
+	}
+}
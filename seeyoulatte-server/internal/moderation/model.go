@@ -0,0 +1,12 @@
+package moderation
+
+import (
+	"github.com/darkphotonKN/seeyoulatte-app/internal/order"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/user"
+)
+
+// FreezeResult is the frozen user and what happened to the orders they were selling
+type FreezeResult struct {
+	User   *user.User           `json:"user"`
+	Orders *order.FreezeCascade `json:"orders"`
+}
@@ -0,0 +1,66 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/order"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/principal"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/user"
+	"github.com/google/uuid"
+)
+
+type UserService interface {
+	Freeze(ctx context.Context, id uuid.UUID, adminID uuid.UUID) (*user.User, error)
+	Unfreeze(ctx context.Context, id uuid.UUID, adminID uuid.UUID) (*user.User, error)
+}
+
+type OrderService interface {
+	CascadeSellerFreeze(ctx context.Context, sellerID uuid.UUID) (*order.FreezeCascade, error)
+}
+
+type service struct {
+	userService  UserService
+	orderService OrderService
+	logger       *slog.Logger
+}
+
+func NewService(userService UserService, orderService OrderService, logger *slog.Logger) *service {
+	return &service{
+		userService:  userService,
+		orderService: orderService,
+		logger:       logger,
+	}
+}
+
+// Freeze freezes the user first, which hides their listings and blocks new orders right away,
+// then settles the orders they are selling. The freeze stands even if some orders fail, and
+// freezing again retries only the orders that are still open.
+func (s *service) Freeze(ctx context.Context, id uuid.UUID, p *principal.Principal) (*FreezeResult, error) {
+	if err := p.RequireAdmin(); err != nil {
+		return nil, err
+	}
+
+	u, err := s.userService.Freeze(ctx, id, p.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	cascade, err := s.orderService.CascadeSellerFreeze(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("user frozen, but their orders could not be loaded: %w", err)
+	}
+
+	return &FreezeResult{User: u, Orders: cascade}, nil
+}
+
+// Unfreeze lifts the freeze, the user's listings are public again. Orders that were cancelled
+// stay cancelled and held orders stay in the review queue for an admin to decide.
+func (s *service) Unfreeze(ctx context.Context, id uuid.UUID, p *principal.Principal) (*user.User, error) {
+	if err := p.RequireAdmin(); err != nil {
+		return nil, err
+	}
+
+	return s.userService.Unfreeze(ctx, id, p.UserID)
+}
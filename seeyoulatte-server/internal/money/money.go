@@ -0,0 +1,142 @@
+package money
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+)
+
+// Currency is an ISO 4217 alphabetic code
+type Currency string
+
+// DefaultCurrency is used when a listing does not specify one, and for rows created
+// before currencies were tracked (see migration 000012)
+const DefaultCurrency Currency = "USD"
+
+// minorUnits lists the supported currencies and their ISO 4217 decimal places.
+// Amount columns are DECIMAL(10,2), so currencies with three minor units are not supported.
+var minorUnits = map[Currency]int{
+	"AUD": 2,
+	"CAD": 2,
+	"CNY": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"HKD": 2,
+	"JPY": 0,
+	"KRW": 0,
+	"SGD": 2,
+	"THB": 2,
+	"TWD": 2,
+	"USD": 2,
+}
+
+// ParseCurrency normalizes a code from user input, an empty code means DefaultCurrency
+func ParseCurrency(code string) (Currency, error) {
+	if code == "" {
+		return DefaultCurrency, nil
+	}
+
+	c := Currency(strings.ToUpper(strings.TrimSpace(code)))
+	if !c.IsValid() {
+		return "", fmt.Errorf("%w: %q", errorutils.ErrUnsupportedCurrency, code)
+	}
+	return c, nil
+}
+
+func (c Currency) IsValid() bool {
+	_, ok := minorUnits[c]
+	return ok
+}
+
+// MinorUnits is the number of decimal places, 2 for USD (cents) and 0 for JPY
+func (c Currency) MinorUnits() int {
+	return minorUnits[c]
+}
+
+func (c Currency) scale() float64 {
+	return math.Pow10(c.MinorUnits())
+}
+
+// Money is an amount held as an integer count of the currency's minor unit,
+// so arithmetic never accumulates floating point error
+type Money struct {
+	Minor    int64
+	Currency Currency
+}
+
+// New validates an amount from user input.
+// It fails if the currency is unsupported or the amount is finer than the currency allows
+// (e.g. 100.5 JPY).
+func New(amount float64, currency Currency) (Money, error) {
+	if !currency.IsValid() {
+		return Money{}, fmt.Errorf("%w: %q", errorutils.ErrUnsupportedCurrency, currency)
+	}
+
+	scaled := amount * currency.scale()
+	minor := math.Round(scaled)
+	if math.Abs(scaled-minor) > 1e-6 {
+		return Money{}, fmt.Errorf("%w: %s allows %d decimal places, got %v",
+			errorutils.ErrInvalidAmount, currency, currency.MinorUnits(), amount)
+	}
+
+	return Money{Minor: int64(minor), Currency: currency}, nil
+}
+
+// FromDecimal converts a stored amount, rounding to the currency's minor unit.
+// Use it for values read back from DECIMAL columns, which are already valid.
+func FromDecimal(amount float64, currency Currency) Money {
+	return Money{Minor: int64(math.Round(amount * currency.scale())), Currency: currency}
+}
+
+// Float64 converts back to the decimal representation stored in the database
+func (m Money) Float64() float64 {
+	return float64(m.Minor) / m.Currency.scale()
+}
+
+func (m Money) Mul(n int) Money {
+	return Money{Minor: m.Minor * int64(n), Currency: m.Currency}
+}
+
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, mismatch(m, other)
+	}
+	return Money{Minor: m.Minor + other.Minor, Currency: m.Currency}, nil
+}
+
+func (m Money) Sub(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, mismatch(m, other)
+	}
+	return Money{Minor: m.Minor - other.Minor, Currency: m.Currency}, nil
+}
+
+// Cmp returns -1, 0 or 1 like strings.Compare, and an error for different currencies
+func (m Money) Cmp(other Money) (int, error) {
+	if m.Currency != other.Currency {
+		return 0, mismatch(m, other)
+	}
+	switch {
+	case m.Minor < other.Minor:
+		return -1, nil
+	case m.Minor > other.Minor:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+func (m Money) IsPositive() bool {
+	return m.Minor > 0
+}
+
+// String formats with the currency's own precision, e.g. "USD 12.50" or "JPY 1500"
+func (m Money) String() string {
+	return fmt.Sprintf("%s %.*f", m.Currency, m.Currency.MinorUnits(), m.Float64())
+}
+
+func mismatch(a, b Money) error {
+	return fmt.Errorf("%w: %s and %s", errorutils.ErrCurrencyMismatch, a.Currency, b.Currency)
+}
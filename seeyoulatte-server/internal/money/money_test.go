@@ -0,0 +1,74 @@
+package money
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name      string
+		amount    float64
+		currency  Currency
+		wantMinor int64
+		wantErr   error
+	}{
+		{name: "dollars and cents", amount: 12.5, currency: "USD", wantMinor: 1250},
+		{name: "float noise is rounded away", amount: 0.1 + 0.2, currency: "USD", wantMinor: 30},
+		{name: "whole yen", amount: 1500, currency: "JPY", wantMinor: 1500},
+		{name: "fractional yen", amount: 100.5, currency: "JPY", wantErr: errorutils.ErrInvalidAmount},
+		{name: "fraction of a cent", amount: 1.005, currency: "USD", wantErr: errorutils.ErrInvalidAmount},
+		{name: "unsupported currency", amount: 1, currency: "XYZ", wantErr: errorutils.ErrUnsupportedCurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := New(tt.amount, tt.currency)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("New() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if m.Minor != tt.wantMinor {
+				t.Fatalf("New() = %d minor units, want %d", m.Minor, tt.wantMinor)
+			}
+		})
+	}
+}
+
+func TestArithmeticRefusesMixedCurrencies(t *testing.T) {
+	usd := Money{Minor: 100, Currency: "USD"}
+	eur := Money{Minor: 100, Currency: "EUR"}
+
+	if _, err := usd.Add(eur); !errors.Is(err, errorutils.ErrCurrencyMismatch) {
+		t.Errorf("Add() error = %v, want %v", err, errorutils.ErrCurrencyMismatch)
+	}
+	if _, err := usd.Sub(eur); !errors.Is(err, errorutils.ErrCurrencyMismatch) {
+		t.Errorf("Sub() error = %v, want %v", err, errorutils.ErrCurrencyMismatch)
+	}
+	if _, err := usd.Cmp(eur); !errors.Is(err, errorutils.ErrCurrencyMismatch) {
+		t.Errorf("Cmp() error = %v, want %v", err, errorutils.ErrCurrencyMismatch)
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		m    Money
+		want string
+	}{
+		{m: Money{Minor: 1250, Currency: "USD"}, want: "USD 12.50"},
+		{m: Money{Minor: 1500, Currency: "JPY"}, want: "JPY 1500"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.m.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/money"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// ApplyDisputeEventTx applies a dispute transition inside the caller's transaction,
+// so the dispute record and the order's ledger entries commit together
+func (s *service) ApplyDisputeEventTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, event Event, actor Actor, resolution DisputeResolution) (*Order, error) {
+	o, err := s.repo.GetByIDForUpdateTx(ctx, tx, id)
+	if err != nil {
+		return nil, fmt.Errorf("getting order: %w", err)
+	}
+	if o == nil {
+		return nil, errorutils.ErrNotFound
+	}
+
+	o.dispute = resolution
+
+	if err := s.applyTransitionTx(ctx, tx, o, event, actor); err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+// partialRefund is the admin's refund amount, validated against the order currency's precision
+func (o *Order) partialRefund() (money.Money, error) {
+	return money.New(o.dispute.RefundAmount, o.Currency)
+}
+
+// guardPartialRefund requires a refund strictly between zero and the order amount,
+// anything else is a full refund or a rejection and has its own transition
+func guardPartialRefund(ctx context.Context, o *Order, now time.Time) error {
+	refund, err := o.partialRefund()
+	if err != nil {
+		return err
+	}
+	if !refund.IsPositive() || refund.Minor >= o.Total().Minor {
+		return fmt.Errorf("%w: partial refund must be more than zero and less than %s", errorutils.ErrInvalidAmount, o.Total())
+	}
+	return nil
+}
+
+// resolveRefundTx returns the whole order amount to the buyer. A tip is not part of the
+// dispute, so it still goes to the host.
+func (s *service) resolveRefundTx(ctx context.Context, tx *sqlx.Tx, o *Order, actor Actor, now time.Time) error {
+	if err := s.refundBuyerTx(ctx, tx, o, o.Total(), "Dispute resolved for buyer - refund to buyer", now); err != nil {
+		return err
+	}
+
+	if o.Tip().IsPositive() {
+		return s.payoutTipTx(ctx, tx, o)
+	}
+
+	return nil
+}
+
+// resolvePartialTx refunds the amount the admin decided on and pays the seller the remainder.
+// Both entries and their transfers are written in the order's transaction, so the escrow is
+// emptied in one step and the provider is only called once it commits.
+func (s *service) resolvePartialTx(ctx context.Context, tx *sqlx.Tx, o *Order, actor Actor, now time.Time) error {
+	refund, err := o.partialRefund()
+	if err != nil {
+		return err
+	}
+
+	payout, err := o.Total().Sub(refund)
+	if err != nil {
+		return fmt.Errorf("splitting disputed amount: %w", err)
+	}
+
+	if err := s.refundBuyerTx(ctx, tx, o, refund, "Dispute partially resolved - partial refund to buyer", now); err != nil {
+		return err
+	}
+
+	entryID, err := s.ledgerService.CreatePayoutEntryTx(ctx, tx, o.ID, payout, "Dispute partially resolved - remainder paid to seller")
+	if err != nil {
+		return fmt.Errorf("recording payout: %w", err)
+	}
+
+	if err := s.transferService.QueuePayoutTx(ctx, tx, o.ID, entryID, o.SellerID, payout); err != nil {
+		return fmt.Errorf("queueing payout: %w", err)
+	}
+
+	if o.Tip().IsPositive() {
+		return s.payoutTipTx(ctx, tx, o)
+	}
+
+	return nil
+}
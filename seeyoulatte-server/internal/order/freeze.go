@@ -0,0 +1,125 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/principal"
+	dbutils "github.com/darkphotonKN/seeyoulatte-app/internal/utils/db"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// CascadeSellerFreeze deals with the open orders of a seller who was just frozen.
+// Paid orders are cancelled and refunded, accepted and fulfilled orders go to the admin review
+// queue so nobody is paid out or left waiting on a seller who cannot act. Each order runs in its
+// own transaction, one failure does not undo the others and running it again only picks up the rest.
+func (s *service) CascadeSellerFreeze(ctx context.Context, sellerID uuid.UUID) (*FreezeCascade, error) {
+	ids, err := s.repo.GetIDsBySellerInStates(ctx, sellerID, []State{StatePaid, StateAccepted, StateFulfilled})
+	if err != nil {
+		return nil, fmt.Errorf("getting seller's open orders: %w", err)
+	}
+
+	result := &FreezeCascade{
+		Cancelled: []uuid.UUID{},
+		Held:      []uuid.UUID{},
+		Failed:    []uuid.UUID{},
+	}
+
+	for _, id := range ids {
+		event, err := s.applySellerFreeze(ctx, id)
+		switch {
+		case err != nil:
+			s.logger.Error("failed to apply seller freeze to order",
+				slog.String("order_id", id.String()),
+				slog.String("seller_id", sellerID.String()),
+				slog.String("error", err.Error()))
+			result.Failed = append(result.Failed, id)
+		case event == EventSellerFrozen:
+			result.Cancelled = append(result.Cancelled, id)
+		case event == EventHoldForReview:
+			result.Held = append(result.Held, id)
+		}
+	}
+
+	s.logger.Info("seller freeze cascaded",
+		slog.String("seller_id", sellerID.String()),
+		slog.Int("cancelled", len(result.Cancelled)),
+		slog.Int("held", len(result.Held)),
+		slog.Int("failed", len(result.Failed)))
+
+	return result, nil
+}
+
+// applySellerFreeze moves one order under its lock. The state and the freeze are re-read,
+// so an order that moved on or a seller unfrozen in the meantime is left alone.
+// Returns the event applied, empty when nothing was done.
+func (s *service) applySellerFreeze(ctx context.Context, id uuid.UUID) (Event, error) {
+	var applied Event
+
+	err := dbutils.ExecTx(ctx, s.db, func(tx *sqlx.Tx) error {
+		o, err := s.repo.GetByIDForUpdateTx(ctx, tx, id)
+		if err != nil {
+			return fmt.Errorf("locking order: %w", err)
+		}
+		if o == nil {
+			return nil
+		}
+
+		if err := s.userService.VerifyUserNotFrozen(ctx, o.SellerID); err == nil {
+			return nil
+		} else if !errors.Is(err, errorutils.ErrUserIsFrozen) {
+			return err
+		}
+
+		var event Event
+		switch State(o.State) {
+		case StatePaid:
+			event = EventSellerFrozen
+		case StateAccepted, StateFulfilled:
+			event = EventHoldForReview
+		default:
+			return nil
+		}
+
+		if err := s.applyTransitionTx(ctx, tx, o, event, SystemActor); err != nil {
+			return err
+		}
+
+		applied = event
+		return nil
+	})
+
+	return applied, err
+}
+
+// GetReviewQueue returns orders held for an admin decision
+func (s *service) GetReviewQueue(ctx context.Context) ([]Order, error) {
+	orders, err := s.repo.GetUnderReview(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting review queue: %w", err)
+	}
+	return orders, nil
+}
+
+// ResolveReview applies an admin's decision to a held order
+func (s *service) ResolveReview(ctx context.Context, id uuid.UUID, p *principal.Principal, resolution ReviewResolution) (*Order, error) {
+	if err := p.RequireAdmin(); err != nil {
+		return nil, err
+	}
+
+	var event Event
+	switch resolution {
+	case ReviewRefund:
+		event = EventReviewRefund
+	case ReviewRelease:
+		event = EventReviewRelease
+	default:
+		return nil, fmt.Errorf("%w: unknown resolution %q", errorutils.ErrInvalidInput, resolution)
+	}
+
+	return s.Transition(ctx, id, event, AdminActor(p.UserID))
+}
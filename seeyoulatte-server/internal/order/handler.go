@@ -6,16 +6,21 @@ import (
 	"log/slog"
 	"net/http"
 
+	"github.com/darkphotonKN/seeyoulatte-app/internal/principal"
 	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
 type Service interface {
-	Create(ctx context.Context, buyerID uuid.UUID, req *CreateOrderRequest) (*Order, error)
-	GetAll(ctx context.Context) ([]Order, error)
-	Update(ctx context.Context, id uuid.UUID, userID uuid.UUID, req *UpdateOrderRequest) (*Order, error)
-	Delete(ctx context.Context, id uuid.UUID, userID uuid.UUID) error
+	Create(ctx context.Context, p *principal.Principal, req *CreateOrderRequest) (*Order, error)
+	GetByUser(ctx context.Context, p *principal.Principal, role Role) ([]Order, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*Order, error)
+	Delete(ctx context.Context, id uuid.UUID, p *principal.Principal) error
+	Transition(ctx context.Context, id uuid.UUID, event Event, actor Actor) (*Order, error)
+	Tip(ctx context.Context, id uuid.UUID, p *principal.Principal, req *TipRequest) (*Order, error)
+	GetReviewQueue(ctx context.Context) ([]Order, error)
+	ResolveReview(ctx context.Context, id uuid.UUID, p *principal.Principal, resolution ReviewResolution) (*Order, error)
 }
 
 type Handler struct {
@@ -31,47 +36,37 @@ func NewHandler(service Service, logger *slog.Logger) *Handler {
 }
 
 func (h *Handler) CreateOrder(c *gin.Context) {
-	// Extract authenticated user ID from context
-	userIDValue, exists := c.Get("user_id")
-	if !exists {
+	p, ok := principal.FromContext(c)
+	if !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
 		return
 	}
 
-	// Convert user_id to UUID
-	var buyerID uuid.UUID
-	switch v := userIDValue.(type) {
-	case string:
-		parsedID, err := uuid.Parse(v)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
-			return
-		}
-		buyerID = parsedID
-	case uuid.UUID:
-		buyerID = v
-	default:
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID type"})
-		return
-	}
-
 	var req CreateOrderRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	order, err := h.service.Create(c.Request.Context(), buyerID, &req)
+	order, err := h.service.Create(c.Request.Context(), p, &req)
 	if err != nil {
 
 		if errors.Is(err, errorutils.ErrBuyerIsFrozen) {
 			h.logger.Error("Buyer is frozen but attempted purchase",
 				slog.String("error", err.Error()),
-				slog.String("buyer_id", buyerID.String()))
+				slog.String("buyer_id", p.UserID.String()))
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Attempted to buy when user, the buyer, is frozen."})
 			return
 		}
 
+		if errors.Is(err, errorutils.ErrEmailNotVerified) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Verify your email address before placing orders",
+				"code":  "email_not_verified",
+			})
+			return
+		}
+
 		if errors.Is(err, errorutils.ErrSellerIsFrozen) {
 			h.logger.Error("Seller is frozen but attempted purchase",
 				slog.String("error", err.Error()))
@@ -79,9 +74,16 @@ func (h *Handler) CreateOrder(c *gin.Context) {
 			return
 		}
 
+		if errors.Is(err, errorutils.ErrPromoCodeInvalid) ||
+			errors.Is(err, errorutils.ErrPromoCodeNotApplicable) ||
+			errors.Is(err, errorutils.ErrPromoCodeExhausted) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+
 		h.logger.Error("failed to create order",
 			slog.String("error", err.Error()),
-			slog.String("buyer_id", buyerID.String()))
+			slog.String("buyer_id", p.UserID.String()))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create order"})
 		return
 	}
@@ -89,8 +91,21 @@ func (h *Handler) CreateOrder(c *gin.Context) {
 	c.JSON(http.StatusCreated, order)
 }
 
-func (h *Handler) GetAllOrders(c *gin.Context) {
-	orders, err := h.service.GetAll(c.Request.Context())
+// GetMyOrders - GET /api/orders?role=buyer|seller (requires auth), without role both sides are listed
+func (h *Handler) GetMyOrders(c *gin.Context) {
+	p, ok := principal.FromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+
+	role := Role(c.Query("role"))
+	if role != "" && role != RoleBuyer && role != RoleSeller {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "role must be buyer or seller"})
+		return
+	}
+
+	orders, err := h.service.GetByUser(c.Request.Context(), p, role)
 	if err != nil {
 		h.logger.Error("failed to get orders",
 			slog.String("error", err.Error()))
@@ -104,28 +119,34 @@ func (h *Handler) GetAllOrders(c *gin.Context) {
 	})
 }
 
-func (h *Handler) UpdateOrder(c *gin.Context) {
-	// Extract authenticated user ID from context
-	userIDValue, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+// GetOrder - GET /api/admin/orders/:id (requires admin)
+func (h *Handler) GetOrder(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
 		return
 	}
 
-	// Convert user_id to UUID
-	var userID uuid.UUID
-	switch v := userIDValue.(type) {
-	case string:
-		parsedID, err := uuid.Parse(v)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+	order, err := h.service.GetByID(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, errorutils.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
 			return
 		}
-		userID = parsedID
-	case uuid.UUID:
-		userID = v
-	default:
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID type"})
+		h.logger.Error("failed to get order",
+			slog.String("error", err.Error()),
+			slog.String("order_id", id.String()))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get order"})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+func (h *Handler) DeleteOrder(c *gin.Context) {
+	p, ok := principal.FromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
 		return
 	}
 
@@ -136,66 +157,221 @@ func (h *Handler) UpdateOrder(c *gin.Context) {
 		return
 	}
 
-	var req UpdateOrderRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	err = h.service.Delete(c.Request.Context(), id, p)
+	if err != nil {
+		switch {
+		case errors.Is(err, errorutils.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		case errors.Is(err, errorutils.ErrForbidden):
+			c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to delete this order"})
+		case errors.Is(err, errorutils.ErrInvalidStateTransition):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			h.logger.Error("failed to delete order",
+				slog.String("error", err.Error()),
+				slog.String("order_id", id.String()),
+				slog.String("user_id", p.UserID.String()))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete order"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Order deleted successfully"})
+}
+
+// PayOrder - POST /api/orders/:id/pay (buyer)
+func (h *Handler) PayOrder(c *gin.Context) {
+	h.transition(c, EventPay)
+}
+
+// PayOrderWithWallet - POST /api/orders/:id/pay-wallet (buyer, pays from store credit)
+func (h *Handler) PayOrderWithWallet(c *gin.Context) {
+	h.transition(c, EventPayWallet)
+}
+
+// AcceptOrder - POST /api/orders/:id/accept (seller)
+func (h *Handler) AcceptOrder(c *gin.Context) {
+	h.transition(c, EventAccept)
+}
+
+// DeclineOrder - POST /api/orders/:id/decline (seller)
+func (h *Handler) DeclineOrder(c *gin.Context) {
+	h.transition(c, EventDecline)
+}
+
+// CancelOrder - POST /api/orders/:id/cancel (buyer, refunded per the cancellation policy)
+func (h *Handler) CancelOrder(c *gin.Context) {
+	h.transition(c, EventCancel)
+}
+
+// FulfillOrder - POST /api/orders/:id/fulfill (seller)
+func (h *Handler) FulfillOrder(c *gin.Context) {
+	h.transition(c, EventFulfill)
+}
+
+func (h *Handler) transition(c *gin.Context, event Event) {
+	p, ok := principal.FromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
 		return
 	}
 
-	order, err := h.service.Update(c.Request.Context(), id, userID, &req)
+	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		h.logger.Error("failed to update order",
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	order, err := h.service.Transition(c.Request.Context(), id, event, PrincipalActor(p))
+	if err != nil {
+		h.logger.Error("order transition failed",
 			slog.String("error", err.Error()),
+			slog.String("event", string(event)),
 			slog.String("order_id", id.String()),
-			slog.String("user_id", userID.String()))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order"})
+			slog.String("user_id", p.UserID.String()))
+
+		switch {
+		case errors.Is(err, errorutils.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		case errors.Is(err, errorutils.ErrForbidden):
+			c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to perform this action on this order"})
+		case errors.Is(err, errorutils.ErrInvalidStateTransition):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, errorutils.ErrSellerIsFrozen):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Seller account is frozen"})
+		case errors.Is(err, errorutils.ErrInsufficientEscrow), errors.Is(err, errorutils.ErrDuplicatePayout):
+			c.JSON(http.StatusConflict, gin.H{"error": "Order funds have already been released"})
+		case errors.Is(err, errorutils.ErrInsufficientWalletBalance):
+			c.JSON(http.StatusPaymentRequired, gin.H{"error": "Wallet balance is too low for this order"})
+		case errors.Is(err, errorutils.ErrPaymentDeclined):
+			c.JSON(http.StatusPaymentRequired, gin.H{"error": "Payment was declined"})
+		case errors.Is(err, errorutils.ErrPaymentGatewayUnavailable):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Payment provider unavailable, please try again"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order"})
+		}
 		return
 	}
 
 	c.JSON(http.StatusOK, order)
 }
 
-func (h *Handler) DeleteOrder(c *gin.Context) {
-	// Extract authenticated user ID from context
-	userIDValue, exists := c.Get("user_id")
-	if !exists {
+// TipOrder - POST /api/orders/:id/tip (buyer, fulfilled or completed experience orders)
+func (h *Handler) TipOrder(c *gin.Context) {
+	p, ok := principal.FromContext(c)
+	if !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
 		return
 	}
 
-	// Convert user_id to UUID
-	var userID uuid.UUID
-	switch v := userIDValue.(type) {
-	case string:
-		parsedID, err := uuid.Parse(v)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
-			return
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	var req TipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	order, err := h.service.Tip(c.Request.Context(), id, p, &req)
+	if err != nil {
+		h.logger.Error("failed to add tip",
+			slog.String("error", err.Error()),
+			slog.String("order_id", id.String()),
+			slog.String("buyer_id", p.UserID.String()))
+
+		switch {
+		case errors.Is(err, errorutils.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		case errors.Is(err, errorutils.ErrUserIsFrozen):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Your account has been suspended"})
+		case errors.Is(err, errorutils.ErrForbidden):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only the buyer can tip on this order"})
+		case errors.Is(err, errorutils.ErrInvalidAmount):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, errorutils.ErrTipNotAllowed):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		case errors.Is(err, errorutils.ErrTipAlreadyAdded):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, errorutils.ErrPaymentDeclined):
+			c.JSON(http.StatusPaymentRequired, gin.H{"error": "Payment was declined"})
+		case errors.Is(err, errorutils.ErrPaymentGatewayUnavailable):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Payment provider unavailable, please try again"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add tip"})
 		}
-		userID = parsedID
-	case uuid.UUID:
-		userID = v
-	default:
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID type"})
 		return
 	}
 
-	idStr := c.Param("id")
-	id, err := uuid.Parse(idStr)
+	c.JSON(http.StatusOK, order)
+}
+
+// GetReviewQueue - GET /api/admin/review-queue (requires admin)
+// Orders held because their seller was frozen after accepting them
+func (h *Handler) GetReviewQueue(c *gin.Context) {
+	orders, err := h.service.GetReviewQueue(c.Request.Context())
+	if err != nil {
+		h.logger.Error("failed to get review queue",
+			slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get review queue"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"orders": orders,
+		"count":  len(orders),
+	})
+}
+
+// ResolveReview - POST /api/admin/review-queue/:id/resolve (requires admin)
+func (h *Handler) ResolveReview(c *gin.Context) {
+	p, ok := principal.FromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
 		return
 	}
 
-	err = h.service.Delete(c.Request.Context(), id, userID)
+	var req ResolveReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	order, err := h.service.ResolveReview(c.Request.Context(), id, p, req.Resolution)
 	if err != nil {
-		h.logger.Error("failed to delete order",
-			slog.String("error", err.Error()),
-			slog.String("order_id", id.String()),
-			slog.String("user_id", userID.String()))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete order"})
+		switch {
+		case errors.Is(err, errorutils.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		case errors.Is(err, errorutils.ErrForbidden), errors.Is(err, errorutils.ErrTwoFactorRequired):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+		case errors.Is(err, errorutils.ErrInvalidInput):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, errorutils.ErrInvalidStateTransition),
+			errors.Is(err, errorutils.ErrInsufficientEscrow),
+			errors.Is(err, errorutils.ErrDuplicatePayout):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, errorutils.ErrPaymentDeclined):
+			c.JSON(http.StatusPaymentRequired, gin.H{"error": err.Error()})
+		case errors.Is(err, errorutils.ErrPaymentGatewayUnavailable):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		default:
+			h.logger.Error("failed to resolve review",
+				slog.String("error", err.Error()),
+				slog.String("order_id", id.String()),
+				slog.String("admin_id", p.UserID.String()))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve review"})
+		}
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Order deleted successfully"})
+	c.JSON(http.StatusOK, order)
 }
@@ -3,29 +3,103 @@ package order
 import (
 	"time"
 
+	"github.com/darkphotonKN/seeyoulatte-app/internal/cancellation"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/money"
 	"github.com/google/uuid"
 )
 
 type Order struct {
-	ID              uuid.UUID  `db:"id" json:"id"`
-	ListingID       uuid.UUID  `db:"listing_id" json:"listing_id"`
-	BuyerID         uuid.UUID  `db:"buyer_id" json:"buyer_id"`
-	SellerID        uuid.UUID  `db:"seller_id" json:"seller_id"`
-	Quantity        int        `db:"quantity" json:"quantity"`
-	Amount          float64    `db:"amount" json:"amount"`
-	State           string     `db:"state" json:"state"`
-	SellerRespondBy *time.Time `db:"seller_respond_by" json:"seller_respond_by,omitempty"`
-	ReviewEndsAt    *time.Time `db:"review_ends_at" json:"review_ends_at,omitempty"`
-	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+	ID              uuid.UUID      `db:"id" json:"id"`
+	ListingID       uuid.UUID      `db:"listing_id" json:"listing_id"`
+	BuyerID         uuid.UUID      `db:"buyer_id" json:"buyer_id"`
+	SellerID        uuid.UUID      `db:"seller_id" json:"seller_id"`
+	Quantity        int            `db:"quantity" json:"quantity"`
+	Subtotal        float64        `db:"subtotal" json:"subtotal"`
+	DiscountAmount  float64        `db:"discount_amount" json:"discount_amount"`
+	Amount          float64        `db:"amount" json:"amount"` // Subtotal less the discount, what the buyer pays
+	Currency        money.Currency `db:"currency" json:"currency"`
+	TipAmount       float64        `db:"tip_amount" json:"tip_amount"` // Kept out of Amount, never refunded or disputed
+	State           string         `db:"state" json:"state"`
+	SellerRespondBy *time.Time     `db:"seller_respond_by" json:"seller_respond_by,omitempty"`
+	ReviewEndsAt    *time.Time     `db:"review_ends_at" json:"review_ends_at,omitempty"`
+	PromotionID     *uuid.UUID     `db:"promotion_id" json:"promotion_id,omitempty"`
+	RefundTo        RefundTo       `db:"refund_to" json:"refund_to"`
+	// Copied from the listing at creation, so later listing edits do not change the terms
+	CancellationPolicy cancellation.Policy `db:"cancellation_policy" json:"cancellation_policy"`
+	StartsAt           *time.Time          `db:"starts_at" json:"starts_at,omitempty"`
+	CancellationTerms  *cancellation.Terms `db:"-" json:"cancellation_terms,omitempty"` // Shown when the order is created
+	PaymentReference   *string             `db:"payment_reference" json:"payment_reference,omitempty"`
+	RefundSettledAt    *time.Time          `db:"refund_settled_at" json:"refund_settled_at,omitempty"`
+	HeldAt             *time.Time          `db:"held_at" json:"held_at,omitempty"` // Set when the order entered the admin review queue
+	HeldReason         *string             `db:"held_reason" json:"held_reason,omitempty"`
+	CreatedAt          time.Time           `db:"created_at" json:"created_at"`
+
+	// set by ApplyDisputeEventTx for the resolution being applied, never stored
+	dispute DisputeResolution
+}
+
+// Total is the order amount in its currency's minor units
+func (o *Order) Total() money.Money {
+	return money.FromDecimal(o.Amount, o.Currency)
 }
 
+// Discount is the promo code amount taken off the subtotal, zero without a code
+func (o *Order) Discount() money.Money {
+	return money.FromDecimal(o.DiscountAmount, o.Currency)
+}
+
+// Tip is the buyer's tip for the host in minor units, zero when none was added
+func (o *Order) Tip() money.Money {
+	return money.FromDecimal(o.TipAmount, o.Currency)
+}
+
+// RefundTo is where a buyer's refund is sent
+type RefundTo string
+
+const (
+	RefundToOriginal RefundTo = "original" // Back to the payment method through the gateway
+	RefundToWallet   RefundTo = "wallet"   // Store credit in the buyer's wallet, always used for wallet payments
+)
+
 type CreateOrderRequest struct {
 	ListingID uuid.UUID `json:"listing_id" binding:"required"`
 	Quantity  int       `json:"quantity" binding:"required,min=1"`
+	PromoCode string    `json:"promo_code,omitempty" binding:"omitempty,max=50"`
+	RefundTo  RefundTo  `json:"refund_to,omitempty" binding:"omitempty,oneof=original wallet"`
+}
+
+// TipRequest adds a tip for the host of a fulfilled experience, in the order's currency
+type TipRequest struct {
+	Amount float64 `json:"amount" binding:"required,gt=0"`
+}
+
+// DisputeResolution carries an admin's decision into the dispute transitions
+type DisputeResolution struct {
+	RefundAmount float64 // In the order's currency. Only read by partial resolutions, full refunds return the order amount
 }
 
-type UpdateOrderRequest struct {
-	State           *string    `json:"state,omitempty"`
-	SellerRespondBy *time.Time `json:"seller_respond_by,omitempty"`
-	ReviewEndsAt    *time.Time `json:"review_ends_at,omitempty"`
-}
\ No newline at end of file
+// ReviewResolution is an admin's decision on an order in the review queue
+type ReviewResolution string
+
+const (
+	ReviewRefund  ReviewResolution = "refund"  // Buyer gets the order amount back
+	ReviewRelease ReviewResolution = "release" // Seller delivered, pay them out
+)
+
+type ResolveReviewRequest struct {
+	Resolution ReviewResolution `json:"resolution" binding:"required,oneof=refund release"`
+}
+
+// FreezeCascade reports what freezing a seller did to their open orders
+type FreezeCascade struct {
+	Cancelled []uuid.UUID `json:"cancelled"` // Paid orders, refunded in full
+	Held      []uuid.UUID `json:"held"`      // Accepted and fulfilled orders moved to the review queue
+	Failed    []uuid.UUID `json:"failed"`    // Left as they were, see the logs
+}
+
+// PaymentOutcome is an asynchronous result reported by the payment provider
+type PaymentOutcome struct {
+	Reference string
+	Amount    float64
+	Currency  money.Currency // Empty when the provider did not report one
+}
@@ -3,10 +3,12 @@ package order
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type repository struct {
@@ -20,10 +22,10 @@ func NewRepository(db *sqlx.DB) *repository {
 func (r *repository) Create(ctx context.Context, order *Order) error {
 	query := `
 		INSERT INTO orders (
-			listing_id, buyer_id, seller_id, quantity, amount,
-			state, seller_respond_by, review_ends_at
+			listing_id, buyer_id, seller_id, quantity, subtotal, discount_amount, amount, currency,
+			promotion_id, refund_to, cancellation_policy, starts_at, state, seller_respond_by, review_ends_at
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15
 		) RETURNING id, created_at
 	`
 
@@ -34,7 +36,14 @@ func (r *repository) Create(ctx context.Context, order *Order) error {
 		order.BuyerID,
 		order.SellerID,
 		order.Quantity,
+		order.Subtotal,
+		order.DiscountAmount,
 		order.Amount,
+		order.Currency,
+		order.PromotionID,
+		order.RefundTo,
+		order.CancellationPolicy,
+		order.StartsAt,
 		order.State,
 		order.SellerRespondBy,
 		order.ReviewEndsAt,
@@ -47,14 +56,143 @@ func (r *repository) Create(ctx context.Context, order *Order) error {
 	return nil
 }
 
-func (r *repository) GetAll(ctx context.Context) ([]Order, error) {
+// CreateTx inserts the order inside the transaction that holds the listing lock
+func (r *repository) CreateTx(ctx context.Context, tx *sqlx.Tx, order *Order) error {
+	query := `
+		INSERT INTO orders (
+			listing_id, buyer_id, seller_id, quantity, subtotal, discount_amount, amount, currency,
+			promotion_id, refund_to, cancellation_policy, starts_at, state, seller_respond_by, review_ends_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15
+		) RETURNING id, created_at
+	`
+
+	err := tx.QueryRowContext(
+		ctx,
+		query,
+		order.ListingID,
+		order.BuyerID,
+		order.SellerID,
+		order.Quantity,
+		order.Subtotal,
+		order.DiscountAmount,
+		order.Amount,
+		order.Currency,
+		order.PromotionID,
+		order.RefundTo,
+		order.CancellationPolicy,
+		order.StartsAt,
+		order.State,
+		order.SellerRespondBy,
+		order.ReviewEndsAt,
+	).Scan(&order.ID, &order.CreatedAt)
+
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
+
+func (r *repository) GetByIDForUpdateTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (*Order, error) {
+	var order Order
+	query := `
+		SELECT
+			id, listing_id, buyer_id, seller_id, quantity, subtotal, discount_amount, amount, currency,
+			tip_amount, promotion_id, refund_to, cancellation_policy, starts_at, state, seller_respond_by, review_ends_at, payment_reference, refund_settled_at, held_at, held_reason, created_at
+		FROM orders
+		WHERE id = $1
+		FOR UPDATE
+	`
+
+	err := tx.GetContext(ctx, &order, query, id)
+	if err != nil {
+		dbErr := errorutils.AnalyzeDBErr(err)
+		if dbErr == errorutils.ErrNotFound {
+			return nil, nil
+		}
+		return nil, dbErr
+	}
+
+	return &order, nil
+}
+
+// GetByIDForUpdateSkipLockedTx locks the order unless another transaction already holds it,
+// in which case nil is returned so background workers can move on
+func (r *repository) GetByIDForUpdateSkipLockedTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (*Order, error) {
+	var order Order
+	query := `
+		SELECT
+			id, listing_id, buyer_id, seller_id, quantity, subtotal, discount_amount, amount, currency,
+			tip_amount, promotion_id, refund_to, cancellation_policy, starts_at, state, seller_respond_by, review_ends_at, payment_reference, refund_settled_at, held_at, held_reason, created_at
+		FROM orders
+		WHERE id = $1
+		FOR UPDATE SKIP LOCKED
+	`
+
+	err := tx.GetContext(ctx, &order, query, id)
+	if err != nil {
+		dbErr := errorutils.AnalyzeDBErr(err)
+		if dbErr == errorutils.ErrNotFound {
+			return nil, nil
+		}
+		return nil, dbErr
+	}
+
+	return &order, nil
+}
+
+// GetIDsPastRespondBy returns paid orders the seller did not respond to in time
+func (r *repository) GetIDsPastRespondBy(ctx context.Context, now time.Time) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	query := `
+		SELECT id
+		FROM orders
+		WHERE state = 'paid' AND seller_respond_by < $1
+		ORDER BY seller_respond_by ASC
+	`
+
+	err := r.db.SelectContext(ctx, &ids, query, now)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return ids, nil
+}
+
+// GetIDsBySellerInStates returns a seller's orders currently in any of the given states
+func (r *repository) GetIDsBySellerInStates(ctx context.Context, sellerID uuid.UUID, states []State) ([]uuid.UUID, error) {
+	names := make([]string, len(states))
+	for i, state := range states {
+		names[i] = string(state)
+	}
+
+	var ids []uuid.UUID
+	query := `
+		SELECT id
+		FROM orders
+		WHERE seller_id = $1 AND state = ANY($2)
+		ORDER BY created_at ASC
+	`
+
+	err := r.db.SelectContext(ctx, &ids, query, sellerID, pq.Array(names))
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return ids, nil
+}
+
+// GetUnderReview returns the admin review queue, oldest hold first
+func (r *repository) GetUnderReview(ctx context.Context) ([]Order, error) {
 	var orders []Order
 	query := `
 		SELECT
-			id, listing_id, buyer_id, seller_id, quantity, amount,
-			state, seller_respond_by, review_ends_at, created_at
+			id, listing_id, buyer_id, seller_id, quantity, subtotal, discount_amount, amount, currency,
+			tip_amount, promotion_id, refund_to, cancellation_policy, starts_at, state, seller_respond_by, review_ends_at, payment_reference, refund_settled_at, held_at, held_reason, created_at
 		FROM orders
-		ORDER BY created_at DESC
+		WHERE state = 'under_review'
+		ORDER BY held_at ASC
 	`
 
 	err := r.db.SelectContext(ctx, &orders, query)
@@ -65,22 +203,95 @@ func (r *repository) GetAll(ctx context.Context) ([]Order, error) {
 	return orders, nil
 }
 
-func (r *repository) Update(ctx context.Context, order *Order) error {
+// GetIDsPastReviewPeriod returns fulfilled orders whose review period ended without a dispute
+func (r *repository) GetIDsPastReviewPeriod(ctx context.Context, now time.Time) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	query := `
+		SELECT id
+		FROM orders
+		WHERE state = 'fulfilled' AND review_ends_at < $1
+		ORDER BY review_ends_at ASC
+	`
+
+	err := r.db.SelectContext(ctx, &ids, query, now)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return ids, nil
+}
+
+func (r *repository) GetByID(ctx context.Context, id uuid.UUID) (*Order, error) {
+	var order Order
+	query := `
+		SELECT
+			id, listing_id, buyer_id, seller_id, quantity, subtotal, discount_amount, amount, currency,
+			tip_amount, promotion_id, refund_to, cancellation_policy, starts_at, state, seller_respond_by, review_ends_at, payment_reference, refund_settled_at, held_at, held_reason, created_at
+		FROM orders
+		WHERE id = $1
+	`
+
+	err := r.db.GetContext(ctx, &order, query, id)
+	if err != nil {
+		dbErr := errorutils.AnalyzeDBErr(err)
+		if dbErr == errorutils.ErrNotFound {
+			return nil, nil
+		}
+		return nil, dbErr
+	}
+
+	return &order, nil
+}
+
+// GetByUser returns the user's orders newest first, as buyer, as seller, or both when role is empty
+func (r *repository) GetByUser(ctx context.Context, userID uuid.UUID, role Role) ([]Order, error) {
+	var orders []Order
+	query := `
+		SELECT
+			id, listing_id, buyer_id, seller_id, quantity, subtotal, discount_amount, amount, currency,
+			tip_amount, promotion_id, refund_to, cancellation_policy, starts_at, state, seller_respond_by, review_ends_at, payment_reference, refund_settled_at, held_at, held_reason, created_at
+		FROM orders
+		WHERE ($2 IN ('', 'buyer') AND buyer_id = $1)
+			OR ($2 IN ('', 'seller') AND seller_id = $1)
+		ORDER BY created_at DESC
+	`
+
+	err := r.db.SelectContext(ctx, &orders, query, userID, string(role))
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return orders, nil
+}
+
+func (r *repository) UpdateTx(ctx context.Context, tx *sqlx.Tx, order *Order) error {
 	query := `
 		UPDATE orders SET
 			state = $2,
 			seller_respond_by = $3,
-			review_ends_at = $4
+			review_ends_at = $4,
+			payment_reference = $5,
+			refund_settled_at = $6,
+			refund_to = $7,
+			tip_amount = $8,
+			held_at = $9,
+			held_reason = $10
 		WHERE id = $1
 	`
 
-	result, err := r.db.ExecContext(
+	result, err := tx.ExecContext(
 		ctx,
 		query,
 		order.ID,
 		order.State,
 		order.SellerRespondBy,
 		order.ReviewEndsAt,
+		order.PaymentReference,
+		order.RefundSettledAt,
+		order.RefundTo,
+		order.TipAmount,
+		order.HeldAt,
+		order.HeldReason,
 	)
 
 	if err != nil {
@@ -99,10 +310,10 @@ func (r *repository) Update(ctx context.Context, order *Order) error {
 	return nil
 }
 
-func (r *repository) Delete(ctx context.Context, id uuid.UUID) error {
+func (r *repository) DeleteTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) error {
 	query := `DELETE FROM orders WHERE id = $1`
 
-	result, err := r.db.ExecContext(ctx, query, id)
+	result, err := tx.ExecContext(ctx, query, id)
 	if err != nil {
 		return errorutils.AnalyzeDBErr(err)
 	}
@@ -5,8 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 
+	"github.com/darkphotonKN/seeyoulatte-app/internal/ledger"
 	"github.com/darkphotonKN/seeyoulatte-app/internal/listing"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/money"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/payment"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/principal"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/promotion"
 	dbutils "github.com/darkphotonKN/seeyoulatte-app/internal/utils/db"
 	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
 	"github.com/google/uuid"
@@ -15,51 +21,96 @@ import (
 
 type Repository interface {
 	Create(ctx context.Context, order *Order) error
-	GetAll(ctx context.Context) ([]Order, error)
-	Update(ctx context.Context, order *Order) error
-	Delete(ctx context.Context, id uuid.UUID) error
+	CreateTx(ctx context.Context, tx *sqlx.Tx, order *Order) error
+	GetByID(ctx context.Context, id uuid.UUID) (*Order, error)
+	GetByIDForUpdateTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (*Order, error)
+	GetByIDForUpdateSkipLockedTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (*Order, error)
+	GetIDsPastRespondBy(ctx context.Context, now time.Time) ([]uuid.UUID, error)
+	GetIDsPastReviewPeriod(ctx context.Context, now time.Time) ([]uuid.UUID, error)
+	GetIDsBySellerInStates(ctx context.Context, sellerID uuid.UUID, states []State) ([]uuid.UUID, error)
+	GetUnderReview(ctx context.Context) ([]Order, error)
+	GetByUser(ctx context.Context, userID uuid.UUID, role Role) ([]Order, error)
+	UpdateTx(ctx context.Context, tx *sqlx.Tx, order *Order) error
+	DeleteTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) error
 }
 
 type ListingService interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*listing.Listing, error)
 	GetByIDWithSellerForUpdateTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (*listing.ListingWithSeller, error)
 	UpdateTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, sellerID uuid.UUID, req *listing.UpdateListingRequest) (*listing.Listing, error)
+	RestoreQuantityTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, quantity int) error
 }
 
 type UserService interface {
 	VerifyUserNotFrozen(ctx context.Context, id uuid.UUID) error
 }
 
+type LedgerService interface {
+	CreateEscrowEntryTx(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID, amount money.Money, discount money.Money, actorID uuid.UUID) error
+	CreatePayoutEntryTx(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID, amount money.Money, notes string) (int, error)
+	CreateRefundEntryTx(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID, amount money.Money, notes string) (int, error)
+	CreateTipEntryTx(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID, amount money.Money, actorID uuid.UUID) error
+	CreateTipPayoutEntryTx(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID, amount money.Money) (int, error)
+}
+
+type PromotionService interface {
+	ApplyTx(ctx context.Context, tx *sqlx.Tx, code string, target promotion.Target, now time.Time) (*promotion.Discount, error)
+	RecordRedemptionTx(ctx context.Context, tx *sqlx.Tx, discount *promotion.Discount, orderID uuid.UUID, userID uuid.UUID) error
+	ReleaseTx(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID) error
+}
+
+type WalletService interface {
+	SpendTx(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, orderID uuid.UUID, amount money.Money, discount money.Money) error
+	CreditTx(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, orderID uuid.UUID, amount money.Money, notes string) error
+}
+
+type TransferService interface {
+	QueueRefundTx(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID, ledgerEntryID int, chargeID string, amount money.Money) error
+	QueueChargeRefundTx(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID, chargeID string, amount money.Money) error
+	QueuePayoutTx(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID, ledgerEntryID int, sellerID uuid.UUID, amount money.Money) error
+}
+
 type service struct {
-	repo           Repository
-	db             *sqlx.DB
-	listingService ListingService
-	userService    UserService
-	logger         *slog.Logger
+	repo             Repository
+	db               *sqlx.DB
+	listingService   ListingService
+	userService      UserService
+	ledgerService    LedgerService
+	promotionService PromotionService
+	walletService    WalletService
+	transferService  TransferService
+	gateway          payment.PaymentGateway
+	transitions      []transition
+	logger           *slog.Logger
 }
 
-func NewService(repo Repository, db *sqlx.DB, logger *slog.Logger, listingService ListingService, userService UserService) *service {
-	return &service{
-		repo:           repo,
-		db:             db,
-		listingService: listingService,
-		userService:    userService,
-		logger:         logger,
+func NewService(repo Repository, db *sqlx.DB, logger *slog.Logger, listingService ListingService, userService UserService, ledgerService LedgerService, promotionService PromotionService, walletService WalletService, transferService TransferService, gateway payment.PaymentGateway) *service {
+	s := &service{
+		repo:             repo,
+		db:               db,
+		listingService:   listingService,
+		userService:      userService,
+		ledgerService:    ledgerService,
+		promotionService: promotionService,
+		walletService:    walletService,
+		transferService:  transferService,
+		gateway:          gateway,
+		logger:           logger,
 	}
+	s.transitions = s.transitionTable()
+	return s
 }
 
-func (s *service) Create(ctx context.Context, userID uuid.UUID, req *CreateOrderRequest) (*Order, error) {
+func (s *service) Create(ctx context.Context, p *principal.Principal, req *CreateOrderRequest) (*Order, error) {
 	var order *Order
+	userID := p.UserID
 
-	// 1. buyer already validated through JWT token, but check that they are not frozen here
-	err := s.userService.VerifyUserNotFrozen(ctx, userID)
-	if err != nil {
-		if errors.Is(err, errorutils.ErrUserIsFrozen) {
-			return nil, errorutils.ErrBuyerIsFrozen
-		}
+	// 1. buyer must not be frozen and needs a confirmed email address
+	if err := p.RequireBuyer(); err != nil {
 		return nil, err
 	}
 
-	err = dbutils.ExecTx(ctx, s.db, func(tx *sqlx.Tx) error {
+	err := dbutils.ExecTx(ctx, s.db, func(tx *sqlx.Tx) error {
 
 		// 2. validate the listing exists, quantity sufficient and is not expired and if SELLER is frozen
 		// locks both table rows to prevent race condition collision
@@ -99,24 +150,68 @@ func (s *service) Create(ctx context.Context, userID uuid.UUID, req *CreateOrder
 			return err
 		}
 
-		// 5. calculate total amount
-		amount := l.Price * float64(req.Quantity)
+		// 5. calculate total amount in the listing's currency, in minor units to avoid float drift
+		subtotal := money.FromDecimal(l.Price, l.Currency).Mul(req.Quantity)
+		discount := money.Money{Currency: subtotal.Currency}
+
+		// 6. apply the promo code while the listing is still locked, counting the redemption
+		// in this transaction so a failed order does not use up the code
+		var applied *promotion.Discount
+		if req.PromoCode != "" {
+			applied, err = s.promotionService.ApplyTx(ctx, tx, req.PromoCode, promotion.Target{
+				BuyerID:  userID,
+				SellerID: l.SellerID,
+				Category: l.Category,
+				Subtotal: subtotal,
+			}, time.Now())
+			if err != nil {
+				return err
+			}
+			discount = applied.Amount
+		}
 
-		// 4. create the order
+		total, err := subtotal.Sub(discount)
+		if err != nil {
+			return fmt.Errorf("applying discount: %w", err)
+		}
+
+		// 7. create the order
 		order = &Order{
-			ListingID: req.ListingID,
-			BuyerID:   userID,
-			SellerID:  l.SellerID,
-			Quantity:  req.Quantity,
-			Amount:    amount,
-			State:     "pending_payment",
+			ListingID:      req.ListingID,
+			BuyerID:        userID,
+			SellerID:       l.SellerID,
+			Quantity:       req.Quantity,
+			Subtotal:       subtotal.Float64(),
+			DiscountAmount: discount.Float64(),
+			Amount:         total.Float64(),
+			Currency:       total.Currency,
+			RefundTo:       RefundToOriginal,
+			State:          string(StatePendingPayment),
+			// the policy is fixed at purchase, the buyer agreed to these terms
+			CancellationPolicy: l.CancellationPolicy,
+			StartsAt:           l.StartsAt,
+		}
+		if req.RefundTo != "" {
+			order.RefundTo = req.RefundTo
+		}
+		if applied != nil {
+			order.PromotionID = &applied.PromotionID
 		}
 
-		if err := s.repo.Create(ctx, order); err != nil {
+		if err := s.repo.CreateTx(ctx, tx, order); err != nil {
 			return fmt.Errorf("creating order: %w", err)
 		}
 
-		// TODO: 6. insert ESCROW ledger entry
+		if applied != nil {
+			if err := s.promotionService.RecordRedemptionTx(ctx, tx, applied, order.ID, userID); err != nil {
+				return err
+			}
+		}
+
+		terms := order.CancellationPolicy.Terms()
+		order.CancellationTerms = &terms
+
+		// ESCROW is recorded by the pay transition, once money has actually been captured
 
 		s.logger.Info("order created",
 			slog.String("order_id", order.ID.String()),
@@ -134,51 +229,143 @@ func (s *service) Create(ctx context.Context, userID uuid.UUID, req *CreateOrder
 	return order, nil
 }
 
-func (s *service) GetAll(ctx context.Context) ([]Order, error) {
-	orders, err := s.repo.GetAll(ctx)
+func (s *service) GetByID(ctx context.Context, id uuid.UUID) (*Order, error) {
+	order, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("getting order: %w", err)
+	}
+	if order == nil {
+		return nil, errorutils.ErrNotFound
+	}
+	return order, nil
+}
+
+// GetByUser lists the orders the user bought or sold, role narrows it to one side
+func (s *service) GetByUser(ctx context.Context, p *principal.Principal, role Role) ([]Order, error) {
+	orders, err := s.repo.GetByUser(ctx, p.UserID, role)
 	if err != nil {
 		return nil, fmt.Errorf("getting orders: %w", err)
 	}
 	return orders, nil
 }
 
-func (s *service) Update(ctx context.Context, id uuid.UUID, userID uuid.UUID, req *UpdateOrderRequest) (*Order, error) {
-	// TODO: Add validation to ensure user is either buyer or seller of this order
-	// For now, just update as before
-	order := &Order{
-		ID: id,
-	}
+// Delete removes an order that was never paid, for its buyer and seller and admins. Paid orders
+// have ledger entries and only leave through the state machine. The reserved quantity and any
+// promo code redemption are given back.
+func (s *service) Delete(ctx context.Context, id uuid.UUID, p *principal.Principal) error {
+	err := dbutils.ExecTx(ctx, s.db, func(tx *sqlx.Tx) error {
+		o, err := s.repo.GetByIDForUpdateTx(ctx, tx, id)
+		if err != nil {
+			return fmt.Errorf("getting order: %w", err)
+		}
+		if o == nil {
+			return errorutils.ErrNotFound
+		}
 
-	if req.State != nil {
-		order.State = *req.State
-	}
-	if req.SellerRespondBy != nil {
-		order.SellerRespondBy = req.SellerRespondBy
-	}
-	if req.ReviewEndsAt != nil {
-		order.ReviewEndsAt = req.ReviewEndsAt
-	}
+		if o.BuyerID != p.UserID && o.SellerID != p.UserID && p.RequireAdmin() != nil {
+			return errorutils.ErrForbidden
+		}
 
-	if err := s.repo.Update(ctx, order); err != nil {
-		return nil, fmt.Errorf("updating order: %w", err)
-	}
+		if State(o.State) != StatePendingPayment {
+			return fmt.Errorf("%w: only unpaid orders can be deleted, order is %s", errorutils.ErrInvalidStateTransition, o.State)
+		}
 
-	s.logger.Info("order updated",
-		slog.String("order_id", id.String()),
-		slog.String("user_id", userID.String()))
+		if err := s.releaseReservationTx(ctx, tx, o); err != nil {
+			return err
+		}
 
-	return order, nil
-}
+		return s.repo.DeleteTx(ctx, tx, id)
+	})
 
-func (s *service) Delete(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
-	// TODO: Add validation to ensure user has permission to delete this order
-	if err := s.repo.Delete(ctx, id); err != nil {
+	if err != nil {
 		return fmt.Errorf("deleting order: %w", err)
 	}
 
 	s.logger.Info("order deleted",
 		slog.String("order_id", id.String()),
-		slog.String("user_id", userID.String()))
+		slog.String("user_id", p.UserID.String()))
 
 	return nil
 }
+
+// ProcessTimeouts runs the system transitions whose deadlines have passed:
+// unanswered paid orders are cancelled and undisputed fulfilled orders are completed.
+// Returns how many orders were transitioned.
+func (s *service) ProcessTimeouts(ctx context.Context) (int, error) {
+	now := time.Now()
+
+	expired, err := s.repo.GetIDsPastRespondBy(ctx, now)
+	if err != nil {
+		return 0, fmt.Errorf("getting orders past respond by: %w", err)
+	}
+
+	completable, err := s.repo.GetIDsPastReviewPeriod(ctx, now)
+	if err != nil {
+		return 0, fmt.Errorf("getting orders past review period: %w", err)
+	}
+
+	processed := 0
+	for _, id := range expired {
+		if s.processTimeout(ctx, id, EventExpire) {
+			processed++
+		}
+	}
+	for _, id := range completable {
+		if s.processTimeout(ctx, id, EventComplete) {
+			processed++
+		}
+	}
+
+	return processed, nil
+}
+
+// processTimeout transitions a single order in its own transaction.
+// The row is locked with SKIP LOCKED and the guard re-verifies state and deadline,
+// so an order handled elsewhere since the batch query is skipped rather than double-processed.
+func (s *service) processTimeout(ctx context.Context, id uuid.UUID, event Event) bool {
+	transitioned := false
+
+	err := dbutils.ExecTx(ctx, s.db, func(tx *sqlx.Tx) error {
+		o, err := s.repo.GetByIDForUpdateSkipLockedTx(ctx, tx, id)
+		if err != nil {
+			return fmt.Errorf("locking order: %w", err)
+		}
+
+		// locked by another worker or request
+		if o == nil {
+			return nil
+		}
+
+		if err := s.applyTransitionTx(ctx, tx, o, event, SystemActor); err != nil {
+			return err
+		}
+
+		transitioned = true
+		return nil
+	})
+
+	if err != nil {
+		if errors.Is(err, errorutils.ErrInvalidStateTransition) {
+			// state changed since the batch query, nothing to do
+			return false
+		}
+		var guardErr *ledger.GuardError
+		if errors.As(err, &guardErr) {
+			// the ledger disagrees with the order state, retrying will not help
+			s.logger.Error("ledger refused order timeout",
+				slog.String("order_id", id.String()),
+				slog.String("event", string(event)),
+				slog.String("entry_type", string(guardErr.EntryType)),
+				slog.Float64("escrow_balance", guardErr.EscrowBalance),
+				slog.String("error", err.Error()))
+			return false
+		}
+		s.logger.Error("failed to process order timeout",
+			slog.String("order_id", id.String()),
+			slog.String("event", string(event)),
+			slog.String("error", err.Error()))
+		return false
+	}
+
+	return transitioned
+}
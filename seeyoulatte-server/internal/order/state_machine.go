@@ -0,0 +1,732 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/cancellation"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/money"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/payment"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/principal"
+	dbutils "github.com/darkphotonKN/seeyoulatte-app/internal/utils/db"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	SellerResponseTimeout = 24 * time.Hour // How long seller has to accept/decline
+	ReviewPeriod          = 48 * time.Hour // How long buyer has to dispute after fulfillment
+)
+
+// State is an order lifecycle state, see "Order State Machine" in SPECIFICATION.md
+type State string
+
+const (
+	StatePendingPayment State = "pending_payment"
+	StatePaid           State = "paid"
+	StateAccepted       State = "accepted"
+	StateFulfilled      State = "fulfilled"
+	StateCompleted      State = "completed"
+	StateCancelled      State = "cancelled"
+	StateDisputed       State = "disputed"
+	StateRefunded       State = "refunded"
+	// dispute settled with part of the amount refunded and the remainder paid out
+	StatePartiallyRefunded State = "partially_refunded"
+	// seller was frozen after accepting, an admin decides what happens
+	StateUnderReview State = "under_review"
+)
+
+// Event names a requested transition
+type Event string
+
+const (
+	EventPay       Event = "pay"
+	EventPayWallet Event = "pay_wallet"
+	EventAccept    Event = "accept"
+	EventDecline   Event = "decline"
+	EventCancel    Event = "cancel"
+	EventExpire    Event = "expire"
+	EventFulfill   Event = "fulfill"
+	EventComplete  Event = "complete"
+	EventDispute   Event = "dispute"
+
+	// admin dispute resolutions
+	EventResolveRefund  Event = "resolve_refund"
+	EventResolveReject  Event = "resolve_reject"
+	EventResolvePartial Event = "resolve_partial"
+
+	// seller freeze cascade and the admin review queue it feeds
+	EventSellerFrozen  Event = "seller_frozen"
+	EventHoldForReview Event = "hold_for_review"
+	EventReviewRefund  Event = "review_refund"
+	EventReviewRelease Event = "review_release"
+
+	// asynchronous outcomes reported by the payment provider
+	EventPaymentSucceeded Event = "payment_succeeded"
+	EventPaymentFailed    Event = "payment_failed"
+	EventRefundSettled    Event = "refund_settled"
+)
+
+// Role is the party allowed to trigger a transition
+type Role string
+
+const (
+	RoleBuyer  Role = "buyer"
+	RoleSeller Role = "seller"
+	RoleSystem Role = "system"
+	RoleAdmin  Role = "admin"
+)
+
+// Actor is whoever requests a transition. The zero value is the system (background jobs).
+type Actor struct {
+	UserID  uuid.UUID
+	IsAdmin bool // Set only by callers that already checked the admin flag
+}
+
+var SystemActor = Actor{}
+
+func UserActor(userID uuid.UUID) Actor {
+	return Actor{UserID: userID}
+}
+
+func AdminActor(userID uuid.UUID) Actor {
+	return Actor{UserID: userID, IsAdmin: true}
+}
+
+// PrincipalActor is the actor for an authenticated request, it acts as an admin only when
+// the principal passes the admin check
+func PrincipalActor(p *principal.Principal) Actor {
+	return Actor{UserID: p.UserID, IsAdmin: p.RequireAdmin() == nil}
+}
+
+func (a Actor) IsSystem() bool {
+	return a.UserID == uuid.Nil
+}
+
+// transition is one row of the centralized transition table.
+// guard runs before anything is written, action runs inside the order's transaction.
+type transition struct {
+	from   State
+	event  Event
+	to     State
+	role   Role
+	guard  func(ctx context.Context, o *Order, now time.Time) error
+	action func(ctx context.Context, tx *sqlx.Tx, o *Order, actor Actor, now time.Time) error
+}
+
+// transitionTable is the single source of truth for allowed state changes
+func (s *service) transitionTable() []transition {
+	return []transition{
+		// 1. buyer pays (mock) -> escrow
+		{from: StatePendingPayment, event: EventPay, to: StatePaid, role: RoleBuyer,
+			action: s.payTx},
+		// 1b. buyer pays from wallet store credit -> escrow
+		{from: StatePendingPayment, event: EventPayWallet, to: StatePaid, role: RoleBuyer,
+			action: s.payFromWalletTx},
+		// 2. seller accepts within the response window
+		{from: StatePaid, event: EventAccept, to: StateAccepted, role: RoleSeller,
+			guard: s.guardSellerCanAccept},
+		// 3. seller declines within the response window
+		{from: StatePaid, event: EventDecline, to: StateCancelled, role: RoleSeller,
+			guard: guardWithinRespondBy, action: s.cancelTx},
+		// 4. seller never responded
+		{from: StatePaid, event: EventExpire, to: StateCancelled, role: RoleSystem,
+			guard: guardRespondByPassed, action: s.cancelTx},
+		// 4b. buyer cancels, refunded according to the listing's cancellation policy
+		{from: StatePendingPayment, event: EventCancel, to: StateCancelled, role: RoleBuyer,
+			action: s.releaseUnpaidTx},
+		{from: StatePaid, event: EventCancel, to: StateCancelled, role: RoleBuyer,
+			action: s.cancelTx},
+		{from: StateAccepted, event: EventCancel, to: StateCancelled, role: RoleBuyer,
+			action: s.cancelTx},
+		// 5. seller marks fulfilled, review period starts
+		{from: StateAccepted, event: EventFulfill, to: StateFulfilled, role: RoleSeller,
+			action: startReviewPeriod},
+		// 6. buyer disputes during the review period, payout is frozen
+		{from: StateFulfilled, event: EventDispute, to: StateDisputed, role: RoleBuyer,
+			guard: guardWithinReviewPeriod},
+		// 7. review period passed without a dispute
+		{from: StateFulfilled, event: EventComplete, to: StateCompleted, role: RoleSystem,
+			guard: guardReviewPeriodPassed, action: s.payoutTx},
+		// 8. admin resolves the dispute for the buyer
+		{from: StateDisputed, event: EventResolveRefund, to: StateRefunded, role: RoleAdmin,
+			action: s.resolveRefundTx},
+		// 9. admin rejects the dispute
+		{from: StateDisputed, event: EventResolveReject, to: StateCompleted, role: RoleAdmin,
+			action: s.payoutTx},
+		// 10. admin refunds part of the order, the seller is paid the remainder
+		{from: StateDisputed, event: EventResolvePartial, to: StatePartiallyRefunded, role: RoleAdmin,
+			guard: guardPartialRefund, action: s.resolvePartialTx},
+
+		// seller frozen: paid orders are cancelled with a full refund,
+		// accepted and fulfilled ones wait in the admin review queue
+		{from: StatePaid, event: EventSellerFrozen, to: StateCancelled, role: RoleSystem,
+			action: s.sellerFrozenCancelTx},
+		{from: StateAccepted, event: EventHoldForReview, to: StateUnderReview, role: RoleSystem,
+			action: holdForReview},
+		{from: StateFulfilled, event: EventHoldForReview, to: StateUnderReview, role: RoleSystem,
+			action: holdForReview},
+		{from: StateUnderReview, event: EventReviewRefund, to: StateRefunded, role: RoleAdmin,
+			action: s.resolveRefundTx},
+		{from: StateUnderReview, event: EventReviewRelease, to: StateCompleted, role: RoleAdmin,
+			action: s.payoutTx},
+
+		// provider webhooks: payment confirmed out of band
+		{from: StatePendingPayment, event: EventPaymentSucceeded, to: StatePaid, role: RoleSystem,
+			guard: guardHasPaymentReference, action: s.confirmPaymentTx},
+		// provider webhooks: payment failed, release the reserved quantity and promo code
+		{from: StatePendingPayment, event: EventPaymentFailed, to: StateCancelled, role: RoleSystem,
+			action: s.releaseUnpaidTx},
+		// provider webhooks: a refund reached the buyer, state does not change
+		{from: StateCancelled, event: EventRefundSettled, to: StateCancelled, role: RoleSystem,
+			guard: guardRefundNotSettled, action: markRefundSettled},
+		{from: StateRefunded, event: EventRefundSettled, to: StateRefunded, role: RoleSystem,
+			guard: guardRefundNotSettled, action: markRefundSettled},
+		{from: StatePartiallyRefunded, event: EventRefundSettled, to: StatePartiallyRefunded, role: RoleSystem,
+			guard: guardRefundNotSettled, action: markRefundSettled},
+	}
+}
+
+func (s *service) findTransition(from State, event Event) (transition, bool) {
+	for _, t := range s.transitions {
+		if t.from == from && t.event == event {
+			return t, true
+		}
+	}
+	return transition{}, false
+}
+
+// Transition locks the order and applies the event to it
+func (s *service) Transition(ctx context.Context, id uuid.UUID, event Event, actor Actor) (*Order, error) {
+	if event == EventPay {
+		return s.pay(ctx, id, actor)
+	}
+
+	var order *Order
+
+	err := dbutils.ExecTx(ctx, s.db, func(tx *sqlx.Tx) error {
+		o, err := s.repo.GetByIDForUpdateTx(ctx, tx, id)
+		if err != nil {
+			return fmt.Errorf("getting order: %w", err)
+		}
+		if o == nil {
+			return errorutils.ErrNotFound
+		}
+
+		if err := s.applyTransitionTx(ctx, tx, o, event, actor); err != nil {
+			return err
+		}
+
+		order = o
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// ApplyPaymentOutcomeTx applies a provider-reported outcome inside the caller's transaction,
+// letting the webhook record its deduplication row atomically with the transition.
+// A payment that does not match the order, in amount or currency or because the order was
+// settled without it, fails with errorutils.ErrPaymentMismatch: the money was captured but the
+// order will never hold it, so the caller has to refund it rather than drop the event.
+func (s *service) ApplyPaymentOutcomeTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, event Event, outcome PaymentOutcome) (*Order, error) {
+	o, err := s.repo.GetByIDForUpdateTx(ctx, tx, id)
+	if err != nil {
+		return nil, fmt.Errorf("getting order: %w", err)
+	}
+	if o == nil {
+		return nil, errorutils.ErrNotFound
+	}
+
+	if event == EventPaymentSucceeded {
+		if err := checkReportedPayment(o, outcome); err != nil {
+			return nil, err
+		}
+		if State(o.State) == StatePendingPayment {
+			o.PaymentReference = &outcome.Reference
+		}
+	}
+
+	if err := s.applyTransitionTx(ctx, tx, o, event, SystemActor); err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+// checkReportedPayment matches a payment_succeeded outcome against the order. Once the order
+// left pending_payment, only a repeat of the charge it was paid with is stale.
+func checkReportedPayment(o *Order, outcome PaymentOutcome) error {
+	if State(o.State) != StatePendingPayment {
+		if o.PaymentReference != nil && *o.PaymentReference == outcome.Reference {
+			return nil
+		}
+		return fmt.Errorf("%w: order is %s and was not paid with charge %q", errorutils.ErrPaymentMismatch, o.State, outcome.Reference)
+	}
+
+	if outcome.Currency != "" && outcome.Currency != o.Currency {
+		return fmt.Errorf("%w: %w: provider captured %s, order is in %s", errorutils.ErrPaymentMismatch, errorutils.ErrCurrencyMismatch, outcome.Currency, o.Currency)
+	}
+	captured := money.FromDecimal(outcome.Amount, o.Currency)
+	if captured != o.Total() {
+		return fmt.Errorf("%w: provider captured %s, order amount is %s", errorutils.ErrPaymentMismatch, captured, o.Total())
+	}
+
+	return nil
+}
+
+// RefundUnmatchedPaymentTx queues a refund of a reported payment that ApplyPaymentOutcomeTx
+// refused with errorutils.ErrPaymentMismatch. The amount is what the provider reported capturing.
+func (s *service) RefundUnmatchedPaymentTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, outcome PaymentOutcome) error {
+	o, err := s.repo.GetByIDForUpdateTx(ctx, tx, id)
+	if err != nil {
+		return fmt.Errorf("getting order: %w", err)
+	}
+	if o == nil {
+		return errorutils.ErrNotFound
+	}
+
+	currency := outcome.Currency
+	if currency == "" {
+		currency = o.Currency
+	}
+	amount := money.FromDecimal(outcome.Amount, currency)
+
+	if outcome.Reference == "" || !amount.IsPositive() {
+		return fmt.Errorf("%w: payment has no charge reference or amount to refund", errorutils.ErrInvalidInput)
+	}
+
+	return s.transferService.QueueChargeRefundTx(ctx, tx, o.ID, outcome.Reference, amount)
+}
+
+// applyTransitionTx runs the guard, the action and the state update for an already locked order.
+// Any error leaves the order untouched once the caller's transaction rolls back.
+func (s *service) applyTransitionTx(ctx context.Context, tx *sqlx.Tx, o *Order, event Event, actor Actor) error {
+	from := State(o.State)
+
+	t, ok := s.findTransition(from, event)
+	if !ok {
+		return fmt.Errorf("%w: cannot %s an order that is %s", errorutils.ErrInvalidStateTransition, event, from)
+	}
+
+	if !canTrigger(t.role, o, actor) {
+		return errorutils.ErrForbidden
+	}
+
+	now := time.Now()
+
+	if t.guard != nil {
+		if err := t.guard(ctx, o, now); err != nil {
+			return err
+		}
+	}
+
+	if t.action != nil {
+		if err := t.action(ctx, tx, o, actor, now); err != nil {
+			return err
+		}
+	}
+
+	o.State = string(t.to)
+	if err := s.repo.UpdateTx(ctx, tx, o); err != nil {
+		return fmt.Errorf("updating order state: %w", err)
+	}
+
+	s.logger.Info("order transitioned",
+		slog.String("order_id", o.ID.String()),
+		slog.String("from", string(from)),
+		slog.String("to", string(t.to)),
+		slog.String("event", string(event)),
+		slog.String("actor_id", actor.UserID.String()))
+
+	return nil
+}
+
+func canTrigger(role Role, o *Order, actor Actor) bool {
+	switch role {
+	case RoleBuyer:
+		return !actor.IsSystem() && actor.UserID == o.BuyerID
+	case RoleSeller:
+		return !actor.IsSystem() && actor.UserID == o.SellerID
+	case RoleSystem:
+		return actor.IsSystem()
+	case RoleAdmin:
+		return !actor.IsSystem() && actor.IsAdmin
+	default:
+		return false
+	}
+}
+
+// --- guards ---
+
+func guardWithinRespondBy(ctx context.Context, o *Order, now time.Time) error {
+	if o.SellerRespondBy != nil && now.After(*o.SellerRespondBy) {
+		return fmt.Errorf("%w: seller response window has passed", errorutils.ErrInvalidStateTransition)
+	}
+	return nil
+}
+
+func guardRespondByPassed(ctx context.Context, o *Order, now time.Time) error {
+	if o.SellerRespondBy == nil || !now.After(*o.SellerRespondBy) {
+		return fmt.Errorf("%w: seller response window has not passed", errorutils.ErrInvalidStateTransition)
+	}
+	return nil
+}
+
+func guardWithinReviewPeriod(ctx context.Context, o *Order, now time.Time) error {
+	if o.ReviewEndsAt == nil || now.After(*o.ReviewEndsAt) {
+		return fmt.Errorf("%w: review period has ended", errorutils.ErrInvalidStateTransition)
+	}
+	return nil
+}
+
+func guardReviewPeriodPassed(ctx context.Context, o *Order, now time.Time) error {
+	if o.ReviewEndsAt == nil || !now.After(*o.ReviewEndsAt) {
+		return fmt.Errorf("%w: review period has not ended", errorutils.ErrInvalidStateTransition)
+	}
+	return nil
+}
+
+func guardHasPaymentReference(ctx context.Context, o *Order, now time.Time) error {
+	if o.PaymentReference == nil || *o.PaymentReference == "" {
+		return fmt.Errorf("%w: payment confirmation has no provider reference", errorutils.ErrInvalidStateTransition)
+	}
+	return nil
+}
+
+func guardRefundNotSettled(ctx context.Context, o *Order, now time.Time) error {
+	if o.PaymentReference == nil {
+		return fmt.Errorf("%w: order was never paid", errorutils.ErrInvalidStateTransition)
+	}
+	if o.RefundSettledAt != nil {
+		return fmt.Errorf("%w: refund already settled", errorutils.ErrInvalidStateTransition)
+	}
+	return nil
+}
+
+func (s *service) guardSellerCanAccept(ctx context.Context, o *Order, now time.Time) error {
+	if err := guardWithinRespondBy(ctx, o, now); err != nil {
+		return err
+	}
+
+	if err := s.userService.VerifyUserNotFrozen(ctx, o.SellerID); err != nil {
+		if errors.Is(err, errorutils.ErrUserIsFrozen) {
+			return errorutils.ErrSellerIsFrozen
+		}
+		return err
+	}
+
+	return nil
+}
+
+// --- actions ---
+// Actions only write to the database. Refunds and payouts are queued as transfers next to
+// their ledger entries and the transfer worker sends them after commit, one idempotent
+// gateway call each, so a rolled back transition never leaves money moved behind it.
+// Charging is the exception: pay and Tip charge the buyer last in their transaction and
+// refund the charge themselves if it does not commit.
+
+// payTx records the order total in escrow and starts the seller's response window.
+// The buyer is charged by pay once the transition has been written.
+func (s *service) payTx(ctx context.Context, tx *sqlx.Tx, o *Order, actor Actor, now time.Time) error {
+	respondBy := now.Add(SellerResponseTimeout)
+	o.SellerRespondBy = &respondBy
+
+	if err := s.ledgerService.CreateEscrowEntryTx(ctx, tx, o.ID, o.Total(), o.Discount(), o.BuyerID); err != nil {
+		return fmt.Errorf("recording escrow: %w", err)
+	}
+
+	return nil
+}
+
+// pay applies EventPay and then charges the order total, the way Tip charges a tip: the escrow
+// entry and the new state are written first, so only storing the charge reference and the commit
+// can fail after the buyer was charged. A charge that does not commit is refunded.
+func (s *service) pay(ctx context.Context, id uuid.UUID, actor Actor) (*Order, error) {
+	var order *Order
+	var charge *payment.Transaction // set once the order total is captured
+	var charged money.Money
+	attempt := uuid.New()
+
+	err := dbutils.ExecTx(ctx, s.db, func(tx *sqlx.Tx) error {
+		o, err := s.repo.GetByIDForUpdateTx(ctx, tx, id)
+		if err != nil {
+			return fmt.Errorf("getting order: %w", err)
+		}
+		if o == nil {
+			return errorutils.ErrNotFound
+		}
+
+		if err := s.applyTransitionTx(ctx, tx, o, EventPay, actor); err != nil {
+			return err
+		}
+
+		charge, err = s.charge(ctx, o.ID, attempt, o.Total())
+		if err != nil {
+			return fmt.Errorf("charging order: %w", err)
+		}
+		charged = o.Total()
+
+		o.PaymentReference = &charge.ID
+		if err := s.repo.UpdateTx(ctx, tx, o); err != nil {
+			return fmt.Errorf("recording payment reference: %w", err)
+		}
+
+		order = o
+		return nil
+	})
+
+	if err != nil {
+		if charge != nil {
+			s.refundUncommittedCharge(ctx, id, charge.ID, charged, chargeKey(id, attempt, "ESCROW_REFUND"))
+		}
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// charge authorizes and captures amount for an order. A failed capture voids the authorization
+// so no hold is left on the buyer's card.
+// The idempotency keys are derived from the order and scoped to one attempt, so a retried call
+// replays the attempt's transaction while a new attempt, after an earlier charge was refunded,
+// charges again instead of replaying the refunded one.
+func (s *service) charge(ctx context.Context, orderID uuid.UUID, attempt uuid.UUID, amount money.Money) (*payment.Transaction, error) {
+	auth, err := s.gateway.Authorize(ctx, orderID, amount, chargeKey(orderID, attempt, "AUTHORIZE"))
+	if err != nil {
+		return nil, fmt.Errorf("authorizing payment: %w", err)
+	}
+
+	captured, err := s.gateway.Capture(ctx, auth.ID, amount, chargeKey(orderID, attempt, "CAPTURE"))
+	if err != nil {
+		s.voidAuthorization(ctx, orderID, auth.ID, amount)
+		return nil, fmt.Errorf("capturing payment: %w", err)
+	}
+
+	return captured, nil
+}
+
+func chargeKey(orderID uuid.UUID, attempt uuid.UUID, step string) string {
+	return fmt.Sprintf("%s:%s:%s", orderID, attempt, step)
+}
+
+// voidAuthorization releases the hold of a capture that failed. A failed void is only logged,
+// the capture error is what the caller reports and the provider expires holds on its own.
+func (s *service) voidAuthorization(ctx context.Context, orderID uuid.UUID, authorizationID string, amount money.Money) {
+	if _, err := s.gateway.Void(ctx, authorizationID, amount); err != nil {
+		s.logger.Error("failed to void authorization",
+			slog.String("order_id", orderID.String()),
+			slog.String("authorization_id", authorizationID),
+			slog.String("error", err.Error()))
+	}
+}
+
+// refundUncommittedCharge gives back a charge whose transaction did not commit, so the buyer is
+// never charged for money the ledger does not know about. A failed refund is logged for an
+// operator, the key lets them retry it safely.
+func (s *service) refundUncommittedCharge(ctx context.Context, orderID uuid.UUID, chargeID string, amount money.Money, key string) {
+	if _, err := s.gateway.Refund(ctx, chargeID, amount, key); err != nil {
+		s.logger.Error("failed to refund uncommitted charge",
+			slog.String("order_id", orderID.String()),
+			slog.String("charge_id", chargeID),
+			slog.String("idempotency_key", key),
+			slog.String("error", err.Error()))
+	}
+}
+
+// payFromWalletTx moves the order total from the buyer's wallet into escrow, no gateway is involved.
+// Money that came from the wallet can only go back to it, so the refund destination is pinned.
+func (s *service) payFromWalletTx(ctx context.Context, tx *sqlx.Tx, o *Order, actor Actor, now time.Time) error {
+	respondBy := now.Add(SellerResponseTimeout)
+	o.SellerRespondBy = &respondBy
+	o.RefundTo = RefundToWallet
+
+	if err := s.walletService.SpendTx(ctx, tx, o.BuyerID, o.ID, o.Total(), o.Discount()); err != nil {
+		return fmt.Errorf("paying from wallet: %w", err)
+	}
+
+	return nil
+}
+
+// cancelTx works out who cancelled from the actor, for the decline, expire and cancel transitions
+func (s *service) cancelTx(ctx context.Context, tx *sqlx.Tx, o *Order, actor Actor, now time.Time) error {
+	by := cancellation.ByBuyer
+	reason := "Buyer cancelled"
+	switch {
+	case actor.IsSystem():
+		by = cancellation.BySystem
+		reason = "Seller response timed out"
+	case actor.UserID == o.SellerID:
+		by = cancellation.BySeller
+		reason = "Seller declined"
+	}
+
+	return s.settleCancellationTx(ctx, tx, o, by, reason, now)
+}
+
+// sellerFrozenCancelTx cancels a paid order of a frozen seller, the buyer is refunded in full
+func (s *service) sellerFrozenCancelTx(ctx context.Context, tx *sqlx.Tx, o *Order, actor Actor, now time.Time) error {
+	return s.settleCancellationTx(ctx, tx, o, cancellation.BySystem, "Seller account frozen", now)
+}
+
+// settleCancellationTx settles a cancelled order against its cancellation policy. The buyer is
+// refunded the percentage the policy allows at this moment and the seller is paid the remainder,
+// so a late buyer cancellation is recorded as a REFUND and a PAYOUT that together empty the escrow.
+// Cancellations by the seller or the system always refund in full.
+func (s *service) settleCancellationTx(ctx context.Context, tx *sqlx.Tx, o *Order, by cancellation.CancelledBy, reason string, now time.Time) error {
+	// o.State is still the state being left
+	percent := cancellation.RefundPercent(cancellation.Cancellation{
+		Policy:   o.CancellationPolicy,
+		By:       by,
+		StartsAt: o.StartsAt,
+		Accepted: State(o.State) == StateAccepted,
+		At:       now,
+	})
+	refund, payout := cancellation.Split(o.Total(), percent)
+
+	if err := s.releaseReservationTx(ctx, tx, o); err != nil {
+		return err
+	}
+
+	if refund.IsPositive() {
+		notes := reason + " - refund to buyer"
+		if percent < 100 {
+			notes = fmt.Sprintf("%s - %d%% refund to buyer under the %s policy", reason, percent, o.CancellationPolicy)
+		}
+		if err := s.refundBuyerTx(ctx, tx, o, refund, notes, now); err != nil {
+			return err
+		}
+	}
+
+	if payout.IsPositive() {
+		notes := fmt.Sprintf("%s - %d%% payout to seller under the %s policy", reason, 100-percent, o.CancellationPolicy)
+		entryID, err := s.ledgerService.CreatePayoutEntryTx(ctx, tx, o.ID, payout, notes)
+		if err != nil {
+			return fmt.Errorf("recording cancellation payout: %w", err)
+		}
+
+		if err := s.transferService.QueuePayoutTx(ctx, tx, o.ID, entryID, o.SellerID, payout); err != nil {
+			return fmt.Errorf("queueing cancellation payout: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// refundBuyerTx returns escrowed money to the buyer, wherever the order says refunds go.
+// Wallet credits are final as soon as the transaction commits, so they are marked settled
+// right away instead of waiting for a provider webhook.
+func (s *service) refundBuyerTx(ctx context.Context, tx *sqlx.Tx, o *Order, amount money.Money, notes string, now time.Time) error {
+	if o.RefundTo == RefundToWallet {
+		if err := s.walletService.CreditTx(ctx, tx, o.BuyerID, o.ID, amount, notes+" (store credit)"); err != nil {
+			return fmt.Errorf("crediting wallet: %w", err)
+		}
+		o.RefundSettledAt = &now
+		return nil
+	}
+
+	entryID, err := s.ledgerService.CreateRefundEntryTx(ctx, tx, o.ID, amount, notes)
+	if err != nil {
+		return fmt.Errorf("recording refund: %w", err)
+	}
+
+	if o.PaymentReference == nil {
+		return fmt.Errorf("order %s has no payment reference to refund", o.ID)
+	}
+
+	if err := s.transferService.QueueRefundTx(ctx, tx, o.ID, entryID, *o.PaymentReference, amount); err != nil {
+		return fmt.Errorf("queueing refund: %w", err)
+	}
+
+	return nil
+}
+
+// confirmPaymentTx records escrow for money the provider already captured, so the gateway is not called
+func (s *service) confirmPaymentTx(ctx context.Context, tx *sqlx.Tx, o *Order, actor Actor, now time.Time) error {
+	respondBy := now.Add(SellerResponseTimeout)
+	o.SellerRespondBy = &respondBy
+
+	if err := s.ledgerService.CreateEscrowEntryTx(ctx, tx, o.ID, o.Total(), o.Discount(), o.BuyerID); err != nil {
+		return fmt.Errorf("recording escrow: %w", err)
+	}
+
+	return nil
+}
+
+// releaseUnpaidTx ends an order that was never paid, nothing is in escrow
+func (s *service) releaseUnpaidTx(ctx context.Context, tx *sqlx.Tx, o *Order, actor Actor, now time.Time) error {
+	return s.releaseReservationTx(ctx, tx, o)
+}
+
+// releaseReservationTx gives back what creating the order took: the listing quantity and,
+// if a promo code was used, its redemption
+func (s *service) releaseReservationTx(ctx context.Context, tx *sqlx.Tx, o *Order) error {
+	if err := s.listingService.RestoreQuantityTx(ctx, tx, o.ListingID, o.Quantity); err != nil {
+		return err
+	}
+
+	if o.PromotionID != nil {
+		if err := s.promotionService.ReleaseTx(ctx, tx, o.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func markRefundSettled(ctx context.Context, tx *sqlx.Tx, o *Order, actor Actor, now time.Time) error {
+	o.RefundSettledAt = &now
+	return nil
+}
+
+func holdForReview(ctx context.Context, tx *sqlx.Tx, o *Order, actor Actor, now time.Time) error {
+	reason := "Seller account frozen"
+	o.HeldAt = &now
+	o.HeldReason = &reason
+	return nil
+}
+
+func startReviewPeriod(ctx context.Context, tx *sqlx.Tx, o *Order, actor Actor, now time.Time) error {
+	reviewEndsAt := now.Add(ReviewPeriod)
+	o.ReviewEndsAt = &reviewEndsAt
+	return nil
+}
+
+func (s *service) payoutTx(ctx context.Context, tx *sqlx.Tx, o *Order, actor Actor, now time.Time) error {
+	entryID, err := s.ledgerService.CreatePayoutEntryTx(ctx, tx, o.ID, o.Total(), "")
+	if err != nil {
+		return fmt.Errorf("recording payout: %w", err)
+	}
+
+	if err := s.transferService.QueuePayoutTx(ctx, tx, o.ID, entryID, o.SellerID, o.Total()); err != nil {
+		return fmt.Errorf("queueing payout: %w", err)
+	}
+
+	if o.Tip().IsPositive() {
+		return s.payoutTipTx(ctx, tx, o)
+	}
+
+	return nil
+}
+
+// payoutTipTx releases a held tip to the seller, on the same path as the order payout
+func (s *service) payoutTipTx(ctx context.Context, tx *sqlx.Tx, o *Order) error {
+	entryID, err := s.ledgerService.CreateTipPayoutEntryTx(ctx, tx, o.ID, o.Tip())
+	if err != nil {
+		return fmt.Errorf("recording tip payout: %w", err)
+	}
+
+	if err := s.transferService.QueuePayoutTx(ctx, tx, o.ID, entryID, o.SellerID, o.Tip()); err != nil {
+		return fmt.Errorf("queueing tip payout: %w", err)
+	}
+
+	return nil
+}
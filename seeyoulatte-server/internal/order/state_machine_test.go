@@ -0,0 +1,59 @@
+package order
+
+import "testing"
+
+func TestTransitionTable(t *testing.T) {
+	s := &service{}
+	s.transitions = s.transitionTable()
+
+	seen := make(map[State]map[Event]bool)
+	for _, tr := range s.transitions {
+		if seen[tr.from][tr.event] {
+			t.Errorf("transition from %s on %s is defined twice", tr.from, tr.event)
+		}
+		if seen[tr.from] == nil {
+			seen[tr.from] = make(map[Event]bool)
+		}
+		seen[tr.from][tr.event] = true
+	}
+
+	tests := []struct {
+		from   State
+		event  Event
+		wantOK bool
+		wantTo State
+		role   Role
+	}{
+		{from: StatePendingPayment, event: EventPay, wantOK: true, wantTo: StatePaid, role: RoleBuyer},
+		{from: StatePaid, event: EventAccept, wantOK: true, wantTo: StateAccepted, role: RoleSeller},
+		{from: StatePaid, event: EventExpire, wantOK: true, wantTo: StateCancelled, role: RoleSystem},
+		{from: StateAccepted, event: EventCancel, wantOK: true, wantTo: StateCancelled, role: RoleBuyer},
+		{from: StateFulfilled, event: EventComplete, wantOK: true, wantTo: StateCompleted, role: RoleSystem},
+		{from: StateDisputed, event: EventResolvePartial, wantOK: true, wantTo: StatePartiallyRefunded, role: RoleAdmin},
+		{from: StateUnderReview, event: EventReviewRelease, wantOK: true, wantTo: StateCompleted, role: RoleAdmin},
+		// money has left escrow, nothing may pay or refund it again
+		{from: StateCompleted, event: EventCancel},
+		{from: StateCompleted, event: EventResolveRefund},
+		{from: StateCancelled, event: EventPay},
+		{from: StateRefunded, event: EventComplete},
+		// the seller cannot skip acceptance or the review period
+		{from: StatePaid, event: EventFulfill},
+		{from: StateAccepted, event: EventComplete},
+		{from: StateFulfilled, event: EventCancel},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"/"+string(tt.event), func(t *testing.T) {
+			tr, ok := s.findTransition(tt.from, tt.event)
+			if ok != tt.wantOK {
+				t.Fatalf("findTransition() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if tr.to != tt.wantTo || tr.role != tt.role {
+				t.Fatalf("findTransition() = %s by %s, want %s by %s", tr.to, tr.role, tt.wantTo, tt.role)
+			}
+		})
+	}
+}
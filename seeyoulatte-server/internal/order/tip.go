@@ -0,0 +1,109 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/money"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/payment"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/principal"
+	dbutils "github.com/darkphotonKN/seeyoulatte-app/internal/utils/db"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Tip charges the buyer a tip for the host of a fulfilled experience.
+// The tip is escrowed under its own entry type and paid out with the order at completion, or
+// queued for payout right away if the order already completed. It is never part of the order
+// amount, so refunds and disputes leave it alone. The order state does not change.
+// The tip is charged after everything else is written, and refunded if the commit then fails.
+func (s *service) Tip(ctx context.Context, id uuid.UUID, p *principal.Principal, req *TipRequest) (*Order, error) {
+	var order *Order
+	var charge *payment.Transaction // set once the tip is captured
+	var charged money.Money
+	attempt := uuid.New()
+	buyerID := p.UserID
+
+	if err := p.RequireActive(); err != nil {
+		return nil, err
+	}
+
+	err := dbutils.ExecTx(ctx, s.db, func(tx *sqlx.Tx) error {
+		o, err := s.repo.GetByIDForUpdateTx(ctx, tx, id)
+		if err != nil {
+			return fmt.Errorf("getting order: %w", err)
+		}
+		if o == nil {
+			return errorutils.ErrNotFound
+		}
+
+		if o.BuyerID != buyerID {
+			return errorutils.ErrForbidden
+		}
+
+		state := State(o.State)
+		if state != StateFulfilled && state != StateCompleted {
+			return fmt.Errorf("%w: order is %s", errorutils.ErrTipNotAllowed, state)
+		}
+
+		// checked under the order lock, so two tips cannot race past it
+		if o.Tip().IsPositive() {
+			return errorutils.ErrTipAlreadyAdded
+		}
+
+		l, err := s.listingService.GetByID(ctx, o.ListingID)
+		if err != nil {
+			return err
+		}
+		if l.Category != "experience" {
+			return fmt.Errorf("%w: %s listings cannot be tipped", errorutils.ErrTipNotAllowed, l.Category)
+		}
+
+		tip, err := money.New(req.Amount, o.Currency)
+		if err != nil {
+			return err
+		}
+
+		if err := s.ledgerService.CreateTipEntryTx(ctx, tx, o.ID, tip, buyerID); err != nil {
+			return fmt.Errorf("recording tip: %w", err)
+		}
+
+		o.TipAmount = tip.Float64()
+		if err := s.repo.UpdateTx(ctx, tx, o); err != nil {
+			return fmt.Errorf("updating order tip: %w", err)
+		}
+
+		// the order payout already happened, release the tip on its own
+		if state == StateCompleted {
+			if err := s.payoutTipTx(ctx, tx, o); err != nil {
+				return err
+			}
+		}
+
+		// the charge comes last, nothing after it can fail but the commit
+		charge, err = s.charge(ctx, o.ID, attempt, tip)
+		if err != nil {
+			return fmt.Errorf("charging tip: %w", err)
+		}
+		charged = tip
+
+		order = o
+		return nil
+	})
+
+	if err != nil {
+		if charge != nil {
+			s.refundUncommittedCharge(ctx, id, charge.ID, charged, chargeKey(id, attempt, "TIP_REFUND"))
+		}
+		return nil, err
+	}
+
+	s.logger.Info("tip added",
+		slog.String("order_id", id.String()),
+		slog.String("buyer_id", buyerID.String()),
+		slog.String("tip", order.Tip().String()))
+
+	return order, nil
+}
@@ -0,0 +1,56 @@
+package order
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+type WorkerService interface {
+	ProcessTimeouts(ctx context.Context) (int, error)
+}
+
+// Worker polls for orders whose response or review window has expired
+type Worker struct {
+	service  WorkerService
+	interval time.Duration
+	logger   *slog.Logger
+}
+
+func NewWorker(service WorkerService, interval time.Duration, logger *slog.Logger) *Worker {
+	return &Worker{
+		service:  service,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Start blocks until the context is cancelled, processing timeouts every interval
+func (w *Worker) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	w.logger.Info("order timeout worker started", slog.Duration("interval", w.interval))
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.logger.Info("order timeout worker stopped")
+			return
+		case <-ticker.C:
+			w.run(ctx)
+		}
+	}
+}
+
+func (w *Worker) run(ctx context.Context) {
+	processed, err := w.service.ProcessTimeouts(ctx)
+	if err != nil {
+		w.logger.Error("order timeout run failed", slog.String("error", err.Error()))
+		return
+	}
+
+	if processed > 0 {
+		w.logger.Info("order timeout run complete", slog.Int("orders_transitioned", processed))
+	}
+}
@@ -0,0 +1,86 @@
+package payment
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MockConfigFromEnv reads the simulated failure settings shared by the in-process
+// mock and the stub server:
+//
+//	PAYMENT_MOCK_LATENCY          e.g. "250ms"
+//	PAYMENT_MOCK_FAILURE_RATE     e.g. "0.1" for 10% unavailable errors
+//	PAYMENT_MOCK_FAIL_OPERATIONS  e.g. "capture,payout" to always decline those
+func MockConfigFromEnv() (MockConfig, error) {
+	config := MockConfig{FailOperations: map[Operation]bool{}}
+
+	if v := os.Getenv("PAYMENT_MOCK_LATENCY"); v != "" {
+		latency, err := time.ParseDuration(v)
+		if err != nil {
+			return config, fmt.Errorf("parsing PAYMENT_MOCK_LATENCY: %w", err)
+		}
+		config.Latency = latency
+	}
+
+	if v := os.Getenv("PAYMENT_MOCK_FAILURE_RATE"); v != "" {
+		rate, err := strconv.ParseFloat(v, 64)
+		if err != nil || rate < 0 || rate > 1 {
+			return config, fmt.Errorf("PAYMENT_MOCK_FAILURE_RATE must be between 0 and 1, got %q", v)
+		}
+		config.FailureRate = rate
+	}
+
+	if v := os.Getenv("PAYMENT_MOCK_FAIL_OPERATIONS"); v != "" {
+		for _, op := range strings.Split(v, ",") {
+			op = strings.TrimSpace(op)
+			switch Operation(op) {
+			case OperationAuthorize, OperationCapture, OperationVoid, OperationRefund, OperationPayout:
+				config.FailOperations[Operation(op)] = true
+			default:
+				return config, fmt.Errorf("unknown operation %q in PAYMENT_MOCK_FAIL_OPERATIONS", op)
+			}
+		}
+	}
+
+	return config, nil
+}
+
+// NewGatewayFromEnv picks the provider named by PAYMENT_PROVIDER:
+// "mock" (default) runs in-process, "http" calls PAYMENT_HTTP_URL.
+func NewGatewayFromEnv(logger *slog.Logger) (PaymentGateway, error) {
+	provider := os.Getenv("PAYMENT_PROVIDER")
+	if provider == "" {
+		provider = "mock"
+	}
+
+	switch provider {
+	case "mock":
+		config, err := MockConfigFromEnv()
+		if err != nil {
+			return nil, err
+		}
+
+		logger.Info("using mock payment gateway",
+			slog.Duration("latency", config.Latency),
+			slog.Float64("failure_rate", config.FailureRate))
+
+		return NewMockGateway(config), nil
+
+	case "http":
+		baseURL := os.Getenv("PAYMENT_HTTP_URL")
+		if baseURL == "" {
+			return nil, fmt.Errorf("PAYMENT_HTTP_URL environment variable is required for the http payment provider")
+		}
+
+		logger.Info("using http payment gateway", slog.String("url", baseURL))
+
+		return NewHTTPGateway(baseURL, 10*time.Second), nil
+
+	default:
+		return nil, fmt.Errorf("unknown PAYMENT_PROVIDER %q", provider)
+	}
+}
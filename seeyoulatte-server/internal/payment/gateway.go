@@ -0,0 +1,48 @@
+package payment
+
+import (
+	"context"
+	"time"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/money"
+	"github.com/google/uuid"
+)
+
+// Operation identifies a single money movement performed by a gateway
+type Operation string
+
+const (
+	OperationAuthorize Operation = "authorize"
+	OperationCapture   Operation = "capture"
+	OperationVoid      Operation = "void"
+	OperationRefund    Operation = "refund"
+	OperationPayout    Operation = "payout"
+)
+
+// Transaction is the provider's record of an operation
+// ID is the provider reference used by follow-up operations (capture, refund)
+type Transaction struct {
+	ID        string         `json:"id"`
+	Operation Operation      `json:"operation"`
+	Amount    float64        `json:"amount"`
+	Currency  money.Currency `json:"currency"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// PaymentGateway is the boundary between the order state machine and a payment provider.
+// Implementations return errorutils.ErrPaymentDeclined when the provider refuses an
+// operation and errorutils.ErrPaymentGatewayUnavailable when it cannot be reached.
+// Every operation that moves money carries an idempotency key: repeating a call with the same
+// key returns the first call's transaction instead of moving the money again.
+type PaymentGateway interface {
+	// Authorize places a hold on the buyer's payment method for an order
+	Authorize(ctx context.Context, orderID uuid.UUID, amount money.Money, idempotencyKey string) (*Transaction, error)
+	// Capture takes the money held by a previous authorization
+	Capture(ctx context.Context, authorizationID string, amount money.Money, idempotencyKey string) (*Transaction, error)
+	// Void releases an authorization that will not be captured
+	Void(ctx context.Context, authorizationID string, amount money.Money) (*Transaction, error)
+	// Refund returns captured money to the buyer
+	Refund(ctx context.Context, chargeID string, amount money.Money, idempotencyKey string) (*Transaction, error)
+	// Payout releases money to the seller
+	Payout(ctx context.Context, sellerID uuid.UUID, amount money.Money, idempotencyKey string) (*Transaction, error)
+}
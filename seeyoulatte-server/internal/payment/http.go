@@ -0,0 +1,199 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/money"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/google/uuid"
+)
+
+// operationRequest is the wire format shared by the HTTP provider and the stub server
+type operationRequest struct {
+	OrderID        uuid.UUID `json:"order_id,omitempty"`
+	SellerID       uuid.UUID `json:"seller_id,omitempty"`
+	Reference      string    `json:"reference,omitempty"`
+	IdempotencyKey string    `json:"idempotency_key,omitempty"` // Everything but voids, makes retries safe
+	Amount         float64   `json:"amount"`
+	Currency       string    `json:"currency"`
+}
+
+func newOperationRequest(amount money.Money) operationRequest {
+	return operationRequest{Amount: amount.Float64(), Currency: string(amount.Currency)}
+}
+
+// money converts the wire amount back, rejecting amounts finer than the currency allows
+func (r operationRequest) money() (money.Money, error) {
+	return money.New(r.Amount, money.Currency(r.Currency))
+}
+
+// httpGateway talks to a payment provider over HTTP, e.g. the local stub from cmd/paystub
+type httpGateway struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewHTTPGateway(baseURL string, timeout time.Duration) *httpGateway {
+	return &httpGateway{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+func (g *httpGateway) Authorize(ctx context.Context, orderID uuid.UUID, amount money.Money, idempotencyKey string) (*Transaction, error) {
+	req := newOperationRequest(amount)
+	req.OrderID = orderID
+	req.IdempotencyKey = idempotencyKey
+	return g.post(ctx, OperationAuthorize, req)
+}
+
+func (g *httpGateway) Capture(ctx context.Context, authorizationID string, amount money.Money, idempotencyKey string) (*Transaction, error) {
+	req := newOperationRequest(amount)
+	req.Reference = authorizationID
+	req.IdempotencyKey = idempotencyKey
+	return g.post(ctx, OperationCapture, req)
+}
+
+func (g *httpGateway) Void(ctx context.Context, authorizationID string, amount money.Money) (*Transaction, error) {
+	req := newOperationRequest(amount)
+	req.Reference = authorizationID
+	return g.post(ctx, OperationVoid, req)
+}
+
+func (g *httpGateway) Refund(ctx context.Context, chargeID string, amount money.Money, idempotencyKey string) (*Transaction, error) {
+	req := newOperationRequest(amount)
+	req.Reference = chargeID
+	req.IdempotencyKey = idempotencyKey
+	return g.post(ctx, OperationRefund, req)
+}
+
+func (g *httpGateway) Payout(ctx context.Context, sellerID uuid.UUID, amount money.Money, idempotencyKey string) (*Transaction, error) {
+	req := newOperationRequest(amount)
+	req.SellerID = sellerID
+	req.IdempotencyKey = idempotencyKey
+	return g.post(ctx, OperationPayout, req)
+}
+
+func (g *httpGateway) post(ctx context.Context, op Operation, body operationRequest) (*Transaction, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("encoding %s request: %w", op, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/%s", g.baseURL, op), bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("building %s request: %w", op, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", errorutils.ErrPaymentGatewayUnavailable, op, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+	case resp.StatusCode == http.StatusPaymentRequired:
+		return nil, fmt.Errorf("%w: %s: %s", errorutils.ErrPaymentDeclined, op, readError(resp))
+	default:
+		return nil, fmt.Errorf("%w: %s: status %d: %s", errorutils.ErrPaymentGatewayUnavailable, op, resp.StatusCode, readError(resp))
+	}
+
+	var txn Transaction
+	if err := json.NewDecoder(resp.Body).Decode(&txn); err != nil {
+		return nil, fmt.Errorf("%w: decoding %s response: %v", errorutils.ErrPaymentGatewayUnavailable, op, err)
+	}
+
+	return &txn, nil
+}
+
+func readError(resp *http.Response) string {
+	var body struct {
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Error == "" {
+		return http.StatusText(resp.StatusCode)
+	}
+	return body.Error
+}
+
+// NewStubHandler serves a PaymentGateway over HTTP using the same wire format as httpGateway.
+// Declines map to 402, anything else to 503, so the client can rebuild the sentinel errors.
+func NewStubHandler(gateway PaymentGateway, logger *slog.Logger) http.Handler {
+	mux := http.NewServeMux()
+
+	handle := func(op Operation, call func(ctx context.Context, req operationRequest, amount money.Money) (*Transaction, error)) {
+		mux.HandleFunc("POST /"+string(op), func(w http.ResponseWriter, r *http.Request) {
+			var req operationRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+				return
+			}
+
+			amount, err := req.money()
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+
+			txn, err := call(r.Context(), req, amount)
+			if err != nil {
+				logger.Info("stub operation failed",
+					slog.String("operation", string(op)),
+					slog.String("error", err.Error()))
+
+				status := http.StatusServiceUnavailable
+				if errors.Is(err, errorutils.ErrPaymentDeclined) {
+					status = http.StatusPaymentRequired
+				}
+				writeJSON(w, status, map[string]string{"error": err.Error()})
+				return
+			}
+
+			logger.Info("stub operation succeeded",
+				slog.String("operation", string(op)),
+				slog.String("reference", txn.ID),
+				slog.Float64("amount", txn.Amount),
+				slog.String("currency", string(txn.Currency)))
+
+			writeJSON(w, http.StatusOK, txn)
+		})
+	}
+
+	handle(OperationAuthorize, func(ctx context.Context, req operationRequest, amount money.Money) (*Transaction, error) {
+		return gateway.Authorize(ctx, req.OrderID, amount, req.IdempotencyKey)
+	})
+	handle(OperationCapture, func(ctx context.Context, req operationRequest, amount money.Money) (*Transaction, error) {
+		return gateway.Capture(ctx, req.Reference, amount, req.IdempotencyKey)
+	})
+	handle(OperationVoid, func(ctx context.Context, req operationRequest, amount money.Money) (*Transaction, error) {
+		return gateway.Void(ctx, req.Reference, amount)
+	})
+	handle(OperationRefund, func(ctx context.Context, req operationRequest, amount money.Money) (*Transaction, error) {
+		return gateway.Refund(ctx, req.Reference, amount, req.IdempotencyKey)
+	})
+	handle(OperationPayout, func(ctx context.Context, req operationRequest, amount money.Money) (*Transaction, error) {
+		return gateway.Payout(ctx, req.SellerID, amount, req.IdempotencyKey)
+	})
+
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
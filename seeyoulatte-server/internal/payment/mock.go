@@ -0,0 +1,113 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/money"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/google/uuid"
+)
+
+// MockConfig controls how a mock provider misbehaves
+type MockConfig struct {
+	Latency        time.Duration      // Added to every operation
+	FailureRate    float64            // 0..1 chance an operation fails as unavailable
+	FailOperations map[Operation]bool // Operations that are always declined
+}
+
+// mockGateway is an in-process provider. No money moves, it only hands out references.
+type mockGateway struct {
+	config MockConfig
+
+	mu        sync.Mutex
+	completed map[string]*Transaction // Successful operations by idempotency key
+}
+
+func NewMockGateway(config MockConfig) *mockGateway {
+	return &mockGateway{config: config, completed: map[string]*Transaction{}}
+}
+
+func (g *mockGateway) Authorize(ctx context.Context, orderID uuid.UUID, amount money.Money, idempotencyKey string) (*Transaction, error) {
+	return g.performOnce(ctx, OperationAuthorize, amount, idempotencyKey)
+}
+
+func (g *mockGateway) Capture(ctx context.Context, authorizationID string, amount money.Money, idempotencyKey string) (*Transaction, error) {
+	if authorizationID == "" {
+		return nil, fmt.Errorf("%w: missing authorization reference", errorutils.ErrPaymentDeclined)
+	}
+	return g.performOnce(ctx, OperationCapture, amount, idempotencyKey)
+}
+
+func (g *mockGateway) Void(ctx context.Context, authorizationID string, amount money.Money) (*Transaction, error) {
+	if authorizationID == "" {
+		return nil, fmt.Errorf("%w: missing authorization reference", errorutils.ErrPaymentDeclined)
+	}
+	return g.perform(ctx, OperationVoid, amount)
+}
+
+func (g *mockGateway) Refund(ctx context.Context, chargeID string, amount money.Money, idempotencyKey string) (*Transaction, error) {
+	if chargeID == "" {
+		return nil, fmt.Errorf("%w: missing charge reference", errorutils.ErrPaymentDeclined)
+	}
+	return g.performOnce(ctx, OperationRefund, amount, idempotencyKey)
+}
+
+func (g *mockGateway) Payout(ctx context.Context, sellerID uuid.UUID, amount money.Money, idempotencyKey string) (*Transaction, error) {
+	return g.performOnce(ctx, OperationPayout, amount, idempotencyKey)
+}
+
+// performOnce replays the transaction of an earlier successful call with the same key,
+// the way a real provider honours idempotency keys
+func (g *mockGateway) performOnce(ctx context.Context, op Operation, amount money.Money, idempotencyKey string) (*Transaction, error) {
+	if idempotencyKey == "" {
+		return nil, fmt.Errorf("%w: %s needs an idempotency key", errorutils.ErrPaymentDeclined, op)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if txn, ok := g.completed[idempotencyKey]; ok {
+		return txn, nil
+	}
+
+	txn, err := g.perform(ctx, op, amount)
+	if err != nil {
+		return nil, err
+	}
+	g.completed[idempotencyKey] = txn
+	return txn, nil
+}
+
+func (g *mockGateway) perform(ctx context.Context, op Operation, amount money.Money) (*Transaction, error) {
+	if g.config.Latency > 0 {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w: %v", errorutils.ErrPaymentGatewayUnavailable, ctx.Err())
+		case <-time.After(g.config.Latency):
+		}
+	}
+
+	if !amount.IsPositive() {
+		return nil, fmt.Errorf("%w: %s amount must be positive, got %s", errorutils.ErrPaymentDeclined, op, amount)
+	}
+
+	if g.config.FailOperations[op] {
+		return nil, fmt.Errorf("%w: %s rejected by mock configuration", errorutils.ErrPaymentDeclined, op)
+	}
+
+	if g.config.FailureRate > 0 && rand.Float64() < g.config.FailureRate {
+		return nil, fmt.Errorf("%w: simulated %s failure", errorutils.ErrPaymentGatewayUnavailable, op)
+	}
+
+	return &Transaction{
+		ID:        fmt.Sprintf("mock_%s_%s", op, uuid.New().String()),
+		Operation: op,
+		Amount:    amount.Float64(),
+		Currency:  amount.Currency,
+		CreatedAt: time.Now(),
+	}, nil
+}
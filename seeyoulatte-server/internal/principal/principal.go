@@ -0,0 +1,89 @@
+// Package principal describes who is making an authenticated request. AuthRequired loads it
+// from the database on every request and services authorize against it, so the buyer, seller
+// and admin rules from "Capability Rules" in SPECIFICATION.md live here and nowhere else.
+package principal
+
+import (
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Method is how the request authenticated
+type Method string
+
+const (
+	MethodJWT    Method = "jwt"
+	MethodAPIKey Method = "api_key"
+)
+
+// contextKey is where the auth middleware stores the principal on the gin context
+const contextKey = "principal"
+
+type Principal struct {
+	UserID           uuid.UUID
+	IsAdmin          bool
+	SellerVerified   bool
+	EmailVerified    bool
+	TwoFactorEnabled bool
+	IsFrozen         bool
+	Method           Method
+	APIKeyID         *uuid.UUID // Set when Method is MethodAPIKey
+}
+
+// RequireActive refuses frozen accounts, deleted accounts are frozen too
+func (p *Principal) RequireActive() error {
+	if p.IsFrozen {
+		return errorutils.ErrUserIsFrozen
+	}
+	return nil
+}
+
+// RequireBuyer checks the user may purchase: not frozen and with a confirmed email address
+func (p *Principal) RequireBuyer() error {
+	if p.IsFrozen {
+		return errorutils.ErrBuyerIsFrozen
+	}
+	if !p.EmailVerified {
+		return errorutils.ErrEmailNotVerified
+	}
+	return nil
+}
+
+// RequireSeller checks the user completed seller onboarding and is not frozen
+func (p *Principal) RequireSeller() error {
+	if p.IsFrozen {
+		return errorutils.ErrUserIsFrozen
+	}
+	if !p.SellerVerified {
+		return errorutils.ErrSellerNotVerified
+	}
+	return nil
+}
+
+// RequireAdmin checks the user currently holds admin access. Admins must have two-factor
+// authentication enabled to use it, and API keys never carry it.
+func (p *Principal) RequireAdmin() error {
+	if !p.IsAdmin || p.IsFrozen || p.Method != MethodJWT {
+		return errorutils.ErrForbidden
+	}
+	if !p.TwoFactorEnabled {
+		return errorutils.ErrTwoFactorRequired
+	}
+	return nil
+}
+
+// Set stores the principal for the handlers after the auth middleware
+func Set(c *gin.Context, p *Principal) {
+	c.Set(contextKey, p)
+}
+
+// FromContext returns the principal set by the auth middleware, false on unauthenticated routes
+func FromContext(c *gin.Context) (*Principal, bool) {
+	value, exists := c.Get(contextKey)
+	if !exists {
+		return nil, false
+	}
+	p, ok := value.(*Principal)
+	return p, ok && p != nil
+}
@@ -0,0 +1,59 @@
+package profile
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Service interface {
+	GetPublicProfile(ctx context.Context, id uuid.UUID) (*PublicProfile, error)
+}
+
+type Handler struct {
+	service Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service Service, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// GetPublicProfile - GET /api/users/:id/profile (public)
+func (h *Handler) GetPublicProfile(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+		})
+		return
+	}
+
+	profile, err := h.service.GetPublicProfile(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, errorutils.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "User not found",
+			})
+			return
+		}
+
+		h.logger.Error("failed to get public profile",
+			slog.String("user_id", id.String()),
+			slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get profile",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
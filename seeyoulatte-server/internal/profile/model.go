@@ -0,0 +1,31 @@
+package profile
+
+import (
+	"time"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/listing"
+	"github.com/google/uuid"
+)
+
+// PublicProfile is what anyone can see about a user. It never includes the email
+// or anything else that would let a stranger contact or locate them precisely.
+type PublicProfile struct {
+	ID              uuid.UUID         `json:"id"`
+	Name            string            `json:"name"`
+	Bio             *string           `json:"bio,omitempty"`
+	LocationText    *string           `json:"location_text,omitempty"` // General area as entered by the user, e.g. a district
+	AvatarURL       *string           `json:"avatar_url,omitempty"`
+	IsSeller        bool              `json:"is_seller"`
+	JoinedAt        time.Time         `json:"joined_at"`
+	ActiveListings  []listing.Listing `json:"active_listings"`
+	AverageRating   *float64          `json:"average_rating"` // Null until the first review
+	ReviewCount     int               `json:"review_count"`
+	CompletedOrders int               `json:"completed_orders"`
+}
+
+// SellerStats are aggregated from the orders a user sold and the buyer reviews on them
+type SellerStats struct {
+	AverageRating   *float64 `db:"average_rating"`
+	ReviewCount     int      `db:"review_count"`
+	CompletedOrders int      `db:"completed_orders"`
+}
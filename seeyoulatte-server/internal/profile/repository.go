@@ -0,0 +1,40 @@
+package profile
+
+import (
+	"context"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *repository {
+	return &repository{db: db}
+}
+
+// GetSellerStats counts completed sales and averages the ratings buyers left on them
+func (r *repository) GetSellerStats(ctx context.Context, sellerID uuid.UUID) (*SellerStats, error) {
+	var stats SellerStats
+	query := `
+		SELECT
+			ROUND(AVG(rv.rating)::numeric, 2)::float8 as average_rating,
+			COUNT(rv.id) as review_count,
+			COUNT(o.id) FILTER (WHERE o.state = 'completed') as completed_orders
+		FROM orders as o
+		LEFT JOIN reviews as rv
+		ON rv.order_id = o.id
+			AND rv.reviewer_id = o.buyer_id
+		WHERE o.seller_id = $1
+	`
+
+	err := r.db.GetContext(ctx, &stats, query, sellerID)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return &stats, nil
+}
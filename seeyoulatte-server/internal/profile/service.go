@@ -0,0 +1,79 @@
+package profile
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/listing"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/user"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/google/uuid"
+)
+
+type Repository interface {
+	GetSellerStats(ctx context.Context, sellerID uuid.UUID) (*SellerStats, error)
+}
+
+type UserService interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*user.User, error)
+}
+
+type ListingService interface {
+	GetPublicBySeller(ctx context.Context, sellerID uuid.UUID) ([]listing.Listing, error)
+}
+
+type service struct {
+	repo           Repository
+	logger         *slog.Logger
+	userService    UserService
+	listingService ListingService
+}
+
+func NewService(repo Repository, logger *slog.Logger, userService UserService, listingService ListingService) *service {
+	return &service{
+		repo:           repo,
+		logger:         logger,
+		userService:    userService,
+		listingService: listingService,
+	}
+}
+
+// GetPublicProfile builds a user's public page. Frozen users have no public profile,
+// the same way their listings drop out of the catalogue.
+func (s *service) GetPublicProfile(ctx context.Context, id uuid.UUID) (*PublicProfile, error) {
+	u, err := s.userService.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("getting user: %w", err)
+	}
+	if u == nil || u.IsFrozen {
+		return nil, errorutils.ErrNotFound
+	}
+
+	listings, err := s.listingService.GetPublicBySeller(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if listings == nil {
+		listings = []listing.Listing{}
+	}
+
+	stats, err := s.repo.GetSellerStats(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("getting seller stats: %w", err)
+	}
+
+	return &PublicProfile{
+		ID:              u.ID,
+		Name:            u.Name,
+		Bio:             u.Bio,
+		LocationText:    u.LocationText,
+		AvatarURL:       u.AvatarURL,
+		IsSeller:        u.SellerVerifiedAt != nil,
+		JoinedAt:        u.CreatedAt,
+		ActiveListings:  listings,
+		AverageRating:   stats.AverageRating,
+		ReviewCount:     stats.ReviewCount,
+		CompletedOrders: stats.CompletedOrders,
+	}, nil
+}
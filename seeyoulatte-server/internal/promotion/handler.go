@@ -0,0 +1,106 @@
+package promotion
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/principal"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Service interface {
+	Create(ctx context.Context, p *principal.Principal, req *CreatePromotionRequest) (*Promotion, error)
+	GetAll(ctx context.Context) ([]Promotion, error)
+	Deactivate(ctx context.Context, id uuid.UUID) error
+}
+
+type Handler struct {
+	service Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service Service, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// CreatePromotion - POST /api/admin/promotions (requires admin)
+func (h *Handler) CreatePromotion(c *gin.Context) {
+	p, ok := principal.FromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	var req CreatePromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	promo, err := h.service.Create(c.Request.Context(), p, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, errorutils.ErrForbidden), errors.Is(err, errorutils.ErrTwoFactorRequired):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+		case errors.Is(err, errorutils.ErrInvalidInput),
+			errors.Is(err, errorutils.ErrUnsupportedCurrency),
+			errors.Is(err, errorutils.ErrInvalidAmount):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, errorutils.ErrDuplicateResource):
+			c.JSON(http.StatusConflict, gin.H{"error": "A promotion with this code already exists"})
+		default:
+			h.logger.Error("failed to create promotion",
+				slog.String("error", err.Error()),
+				slog.String("admin_id", p.UserID.String()))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create promotion"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, promo)
+}
+
+// GetPromotions - GET /api/admin/promotions (requires admin)
+func (h *Handler) GetPromotions(c *gin.Context) {
+	promos, err := h.service.GetAll(c.Request.Context())
+	if err != nil {
+		h.logger.Error("failed to get promotions", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get promotions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"promotions": promos,
+		"count":      len(promos),
+	})
+}
+
+// DeactivatePromotion - POST /api/admin/promotions/:id/deactivate (requires admin)
+func (h *Handler) DeactivatePromotion(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid promotion ID"})
+		return
+	}
+
+	if err := h.service.Deactivate(c.Request.Context(), id); err != nil {
+		if errors.Is(err, errorutils.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Promotion not found"})
+			return
+		}
+		h.logger.Error("failed to deactivate promotion",
+			slog.String("error", err.Error()),
+			slog.String("promotion_id", id.String()))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to deactivate promotion"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Promotion deactivated"})
+}
@@ -0,0 +1,73 @@
+package promotion
+
+import (
+	"time"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/money"
+	"github.com/google/uuid"
+)
+
+// DiscountType decides how DiscountValue is read
+type DiscountType string
+
+const (
+	DiscountTypePercentage DiscountType = "percentage" // DiscountValue is percent off the subtotal
+	DiscountTypeFixed      DiscountType = "fixed"      // DiscountValue is an amount off, in Currency
+)
+
+type Promotion struct {
+	ID              uuid.UUID       `db:"id" json:"id"`
+	Code            string          `db:"code" json:"code"`
+	DiscountType    DiscountType    `db:"discount_type" json:"discount_type"`
+	DiscountValue   float64         `db:"discount_value" json:"discount_value"`
+	Currency        *money.Currency `db:"currency" json:"currency,omitempty"`
+	SellerID        *uuid.UUID      `db:"seller_id" json:"seller_id,omitempty"`
+	Category        *string         `db:"category" json:"category,omitempty"`
+	StartsAt        *time.Time      `db:"starts_at" json:"starts_at,omitempty"`
+	EndsAt          *time.Time      `db:"ends_at" json:"ends_at,omitempty"`
+	MaxRedemptions  *int            `db:"max_redemptions" json:"max_redemptions,omitempty"`
+	MaxPerUser      *int            `db:"max_per_user" json:"max_per_user,omitempty"`
+	RedemptionCount int             `db:"redemption_count" json:"redemption_count"`
+	IsActive        bool            `db:"is_active" json:"is_active"`
+	CreatedBy       uuid.UUID       `db:"created_by" json:"created_by"`
+	CreatedAt       time.Time       `db:"created_at" json:"created_at"`
+}
+
+type CreatePromotionRequest struct {
+	Code           string       `json:"code" binding:"required,min=3,max=50,alphanum"`
+	DiscountType   DiscountType `json:"discount_type" binding:"required,oneof=percentage fixed"`
+	DiscountValue  float64      `json:"discount_value" binding:"required,gt=0"`
+	Currency       *string      `json:"currency,omitempty" binding:"omitempty,len=3"` // Required for fixed discounts
+	SellerID       *uuid.UUID   `json:"seller_id,omitempty"`
+	Category       *string      `json:"category,omitempty" binding:"omitempty,oneof=product experience"`
+	StartsAt       *time.Time   `json:"starts_at,omitempty"`
+	EndsAt         *time.Time   `json:"ends_at,omitempty"`
+	MaxRedemptions *int         `json:"max_redemptions,omitempty" binding:"omitempty,min=1"`
+	MaxPerUser     *int         `json:"max_per_user,omitempty" binding:"omitempty,min=1"`
+}
+
+// Redemption records which order used a code and how much it took off
+type Redemption struct {
+	ID             int            `db:"id" json:"id"`
+	PromotionID    uuid.UUID      `db:"promotion_id" json:"promotion_id"`
+	OrderID        uuid.UUID      `db:"order_id" json:"order_id"`
+	UserID         uuid.UUID      `db:"user_id" json:"user_id"`
+	DiscountAmount float64        `db:"discount_amount" json:"discount_amount"`
+	Currency       money.Currency `db:"currency" json:"currency"`
+	CreatedAt      time.Time      `db:"created_at" json:"created_at"`
+}
+
+// Target is the order a code is being applied to, taken from the locked listing
+type Target struct {
+	BuyerID  uuid.UUID
+	SellerID uuid.UUID
+	Category string
+	Subtotal money.Money
+}
+
+// Discount is a code that has been applied and counted against its limits
+type Discount struct {
+	PromotionID uuid.UUID
+	Code        string
+	Amount      money.Money
+}
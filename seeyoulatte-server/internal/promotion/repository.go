@@ -0,0 +1,205 @@
+package promotion
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *repository {
+	return &repository{db: db}
+}
+
+const promotionColumns = `
+	id, code, discount_type, discount_value, currency, seller_id, category,
+	starts_at, ends_at, max_redemptions, max_per_user, redemption_count,
+	is_active, created_by, created_at
+`
+
+func (r *repository) Create(ctx context.Context, promo *Promotion) error {
+	query := `
+		INSERT INTO promotions (
+			code, discount_type, discount_value, currency, seller_id, category,
+			starts_at, ends_at, max_redemptions, max_per_user, created_by
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+		) RETURNING id, redemption_count, is_active, created_at
+	`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		promo.Code,
+		promo.DiscountType,
+		promo.DiscountValue,
+		promo.Currency,
+		promo.SellerID,
+		promo.Category,
+		promo.StartsAt,
+		promo.EndsAt,
+		promo.MaxRedemptions,
+		promo.MaxPerUser,
+		promo.CreatedBy,
+	).Scan(&promo.ID, &promo.RedemptionCount, &promo.IsActive, &promo.CreatedAt)
+
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
+
+func (r *repository) GetAll(ctx context.Context) ([]Promotion, error) {
+	var promos []Promotion
+	query := `SELECT ` + promotionColumns + ` FROM promotions ORDER BY created_at DESC`
+
+	err := r.db.SelectContext(ctx, &promos, query)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return promos, nil
+}
+
+// GetByCodeForUpdateTx locks the promotion row, serializing every redemption of the same code
+func (r *repository) GetByCodeForUpdateTx(ctx context.Context, tx *sqlx.Tx, code string) (*Promotion, error) {
+	var promo Promotion
+	query := `SELECT ` + promotionColumns + ` FROM promotions WHERE code = $1 FOR UPDATE`
+
+	err := tx.GetContext(ctx, &promo, query, code)
+	if err != nil {
+		dbErr := errorutils.AnalyzeDBErr(err)
+		if dbErr == errorutils.ErrNotFound {
+			return nil, nil
+		}
+		return nil, dbErr
+	}
+
+	return &promo, nil
+}
+
+func (r *repository) CountRedemptionsByUserTx(ctx context.Context, tx *sqlx.Tx, promotionID uuid.UUID, userID uuid.UUID) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM promotion_redemptions WHERE promotion_id = $1 AND user_id = $2`
+
+	err := tx.GetContext(ctx, &count, query, promotionID, userID)
+	if err != nil {
+		return 0, errorutils.AnalyzeDBErr(err)
+	}
+
+	return count, nil
+}
+
+// IncrementRedemptionsTx counts one redemption, returning false once the total limit is reached
+// The limit is part of the UPDATE, so the count can never pass it even without the row lock
+func (r *repository) IncrementRedemptionsTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (bool, error) {
+	query := `
+		UPDATE promotions
+		SET redemption_count = redemption_count + 1
+		WHERE id = $1
+			AND (max_redemptions IS NULL OR redemption_count < max_redemptions)
+	`
+
+	result, err := tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return false, errorutils.AnalyzeDBErr(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("checking affected rows: %w", err)
+	}
+
+	return rowsAffected == 1, nil
+}
+
+func (r *repository) CreateRedemptionTx(ctx context.Context, tx *sqlx.Tx, redemption *Redemption) error {
+	query := `
+		INSERT INTO promotion_redemptions (
+			promotion_id, order_id, user_id, discount_amount, currency
+		) VALUES (
+			$1, $2, $3, $4, $5
+		) RETURNING id, created_at
+	`
+
+	err := tx.QueryRowContext(
+		ctx,
+		query,
+		redemption.PromotionID,
+		redemption.OrderID,
+		redemption.UserID,
+		redemption.DiscountAmount,
+		redemption.Currency,
+	).Scan(&redemption.ID, &redemption.CreatedAt)
+
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
+
+// DeleteRedemptionByOrderTx removes an order's redemption, returning the promotion it counted
+// against or nil if the order used no code
+func (r *repository) DeleteRedemptionByOrderTx(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID) (*uuid.UUID, error) {
+	var promotionID uuid.UUID
+	query := `
+		DELETE FROM promotion_redemptions
+		WHERE order_id = $1
+		RETURNING promotion_id
+	`
+
+	err := tx.GetContext(ctx, &promotionID, query, orderID)
+	if err != nil {
+		dbErr := errorutils.AnalyzeDBErr(err)
+		if dbErr == errorutils.ErrNotFound {
+			return nil, nil
+		}
+		return nil, dbErr
+	}
+
+	return &promotionID, nil
+}
+
+// DecrementRedemptionsTx gives back one redemption to the total limit
+func (r *repository) DecrementRedemptionsTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) error {
+	query := `
+		UPDATE promotions
+		SET redemption_count = redemption_count - 1
+		WHERE id = $1 AND redemption_count > 0
+	`
+
+	_, err := tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
+
+func (r *repository) Deactivate(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE promotions SET is_active = false WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return errorutils.ErrNotFound
+	}
+
+	return nil
+}
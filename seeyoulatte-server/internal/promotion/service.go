@@ -0,0 +1,244 @@
+package promotion
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/money"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/principal"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	Create(ctx context.Context, promo *Promotion) error
+	GetAll(ctx context.Context) ([]Promotion, error)
+	GetByCodeForUpdateTx(ctx context.Context, tx *sqlx.Tx, code string) (*Promotion, error)
+	CountRedemptionsByUserTx(ctx context.Context, tx *sqlx.Tx, promotionID uuid.UUID, userID uuid.UUID) (int, error)
+	IncrementRedemptionsTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (bool, error)
+	CreateRedemptionTx(ctx context.Context, tx *sqlx.Tx, redemption *Redemption) error
+	DeleteRedemptionByOrderTx(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID) (*uuid.UUID, error)
+	DecrementRedemptionsTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) error
+	Deactivate(ctx context.Context, id uuid.UUID) error
+}
+
+type service struct {
+	repo   Repository
+	logger *slog.Logger
+}
+
+func NewService(repo Repository, logger *slog.Logger) *service {
+	return &service{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// NormalizeCode makes codes case-insensitive, they are stored uppercase
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func (s *service) Create(ctx context.Context, p *principal.Principal, req *CreatePromotionRequest) (*Promotion, error) {
+	if err := p.RequireAdmin(); err != nil {
+		return nil, err
+	}
+
+	promo := &Promotion{
+		Code:           NormalizeCode(req.Code),
+		DiscountType:   req.DiscountType,
+		DiscountValue:  req.DiscountValue,
+		SellerID:       req.SellerID,
+		Category:       req.Category,
+		StartsAt:       req.StartsAt,
+		EndsAt:         req.EndsAt,
+		MaxRedemptions: req.MaxRedemptions,
+		MaxPerUser:     req.MaxPerUser,
+		CreatedBy:      p.UserID,
+	}
+
+	switch req.DiscountType {
+	case DiscountTypePercentage:
+		if req.DiscountValue >= 100 {
+			return nil, fmt.Errorf("%w: percentage discounts must be below 100", errorutils.ErrInvalidInput)
+		}
+		if req.Currency != nil {
+			return nil, fmt.Errorf("%w: percentage discounts apply to any currency", errorutils.ErrInvalidInput)
+		}
+	case DiscountTypeFixed:
+		if req.Currency == nil {
+			return nil, fmt.Errorf("%w: fixed discounts require a currency", errorutils.ErrInvalidInput)
+		}
+		currency, err := money.ParseCurrency(*req.Currency)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := money.New(req.DiscountValue, currency); err != nil {
+			return nil, err
+		}
+		promo.Currency = &currency
+	}
+
+	if req.StartsAt != nil && req.EndsAt != nil && !req.EndsAt.After(*req.StartsAt) {
+		return nil, fmt.Errorf("%w: ends_at must be after starts_at", errorutils.ErrInvalidInput)
+	}
+
+	if err := s.repo.Create(ctx, promo); err != nil {
+		return nil, fmt.Errorf("creating promotion: %w", err)
+	}
+
+	s.logger.Info("promotion created",
+		slog.String("promotion_id", promo.ID.String()),
+		slog.String("code", promo.Code),
+		slog.String("created_by", p.UserID.String()))
+
+	return promo, nil
+}
+
+func (s *service) GetAll(ctx context.Context) ([]Promotion, error) {
+	promos, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting promotions: %w", err)
+	}
+	return promos, nil
+}
+
+func (s *service) Deactivate(ctx context.Context, id uuid.UUID) error {
+	if err := s.repo.Deactivate(ctx, id); err != nil {
+		return fmt.Errorf("deactivating promotion: %w", err)
+	}
+
+	s.logger.Info("promotion deactivated", slog.String("promotion_id", id.String()))
+	return nil
+}
+
+// ApplyTx validates a code against the order being created and counts the redemption.
+// It runs in the order's transaction: the promotion row lock serializes concurrent uses of
+// the code, so the per-user count read here cannot go stale before the redemption is recorded,
+// and a rolled back order releases the redemption with it.
+func (s *service) ApplyTx(ctx context.Context, tx *sqlx.Tx, code string, target Target, now time.Time) (*Discount, error) {
+	promo, err := s.repo.GetByCodeForUpdateTx(ctx, tx, NormalizeCode(code))
+	if err != nil {
+		return nil, fmt.Errorf("getting promotion: %w", err)
+	}
+	if promo == nil || !promo.IsActive {
+		return nil, errorutils.ErrPromoCodeInvalid
+	}
+
+	if promo.StartsAt != nil && now.Before(*promo.StartsAt) {
+		return nil, fmt.Errorf("%w: not valid until %s", errorutils.ErrPromoCodeInvalid, promo.StartsAt.Format(time.RFC3339))
+	}
+	if promo.EndsAt != nil && !now.Before(*promo.EndsAt) {
+		return nil, fmt.Errorf("%w: expired", errorutils.ErrPromoCodeInvalid)
+	}
+
+	if promo.SellerID != nil && *promo.SellerID != target.SellerID {
+		return nil, fmt.Errorf("%w: only valid for another seller's listings", errorutils.ErrPromoCodeNotApplicable)
+	}
+	if promo.Category != nil && *promo.Category != target.Category {
+		return nil, fmt.Errorf("%w: only valid for %s listings", errorutils.ErrPromoCodeNotApplicable, *promo.Category)
+	}
+	if promo.Currency != nil && *promo.Currency != target.Subtotal.Currency {
+		return nil, fmt.Errorf("%w: only valid for orders in %s", errorutils.ErrPromoCodeNotApplicable, *promo.Currency)
+	}
+
+	if promo.MaxPerUser != nil {
+		used, err := s.repo.CountRedemptionsByUserTx(ctx, tx, promo.ID, target.BuyerID)
+		if err != nil {
+			return nil, fmt.Errorf("counting redemptions: %w", err)
+		}
+		if used >= *promo.MaxPerUser {
+			return nil, fmt.Errorf("%w: already used %d times", errorutils.ErrPromoCodeExhausted, used)
+		}
+	}
+
+	amount := discountFor(promo, target.Subtotal)
+	if !amount.IsPositive() {
+		return nil, fmt.Errorf("%w: order is too small for a discount", errorutils.ErrPromoCodeNotApplicable)
+	}
+
+	counted, err := s.repo.IncrementRedemptionsTx(ctx, tx, promo.ID)
+	if err != nil {
+		return nil, fmt.Errorf("counting redemption: %w", err)
+	}
+	if !counted {
+		return nil, errorutils.ErrPromoCodeExhausted
+	}
+
+	return &Discount{
+		PromotionID: promo.ID,
+		Code:        promo.Code,
+		Amount:      amount,
+	}, nil
+}
+
+// RecordRedemptionTx links an applied discount to the order it was created for
+func (s *service) RecordRedemptionTx(ctx context.Context, tx *sqlx.Tx, discount *Discount, orderID uuid.UUID, userID uuid.UUID) error {
+	redemption := &Redemption{
+		PromotionID:    discount.PromotionID,
+		OrderID:        orderID,
+		UserID:         userID,
+		DiscountAmount: discount.Amount.Float64(),
+		Currency:       discount.Amount.Currency,
+	}
+
+	if err := s.repo.CreateRedemptionTx(ctx, tx, redemption); err != nil {
+		return fmt.Errorf("recording redemption: %w", err)
+	}
+
+	s.logger.Info("promotion redeemed",
+		slog.String("promotion_id", discount.PromotionID.String()),
+		slog.String("order_id", orderID.String()),
+		slog.String("discount", discount.Amount.String()))
+
+	return nil
+}
+
+// ReleaseTx gives an order's redemption back when the order is cancelled or deleted, so the
+// code counts towards its limits again. Orders that used no code are left alone.
+func (s *service) ReleaseTx(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID) error {
+	promotionID, err := s.repo.DeleteRedemptionByOrderTx(ctx, tx, orderID)
+	if err != nil {
+		return fmt.Errorf("deleting redemption: %w", err)
+	}
+	if promotionID == nil {
+		return nil
+	}
+
+	if err := s.repo.DecrementRedemptionsTx(ctx, tx, *promotionID); err != nil {
+		return fmt.Errorf("releasing redemption: %w", err)
+	}
+
+	s.logger.Info("promotion redemption released",
+		slog.String("promotion_id", promotionID.String()),
+		slog.String("order_id", orderID.String()))
+
+	return nil
+}
+
+// discountFor computes the amount off in the subtotal's minor units.
+// Percentages round down so the discount never exceeds what the code advertises, and the
+// discount is capped one minor unit below the subtotal because orders must charge something.
+func discountFor(promo *Promotion, subtotal money.Money) money.Money {
+	var minor int64
+	switch promo.DiscountType {
+	case DiscountTypePercentage:
+		minor = int64(math.Floor(float64(subtotal.Minor) * promo.DiscountValue / 100))
+	case DiscountTypeFixed:
+		minor = money.FromDecimal(promo.DiscountValue, subtotal.Currency).Minor
+	}
+
+	if minor > subtotal.Minor-1 {
+		minor = subtotal.Minor - 1
+	}
+	if minor < 0 {
+		minor = 0
+	}
+
+	return money.Money{Minor: minor, Currency: subtotal.Currency}
+}
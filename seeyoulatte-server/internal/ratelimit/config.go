@@ -0,0 +1,31 @@
+package ratelimit
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// NewLimiterFromEnv picks the bucket store named by RATE_LIMIT_STORE:
+// "memory" (default) for a single replica, "postgres" to share limits between replicas.
+func NewLimiterFromEnv(db *sqlx.DB, logger *slog.Logger) (Limiter, error) {
+	store := os.Getenv("RATE_LIMIT_STORE")
+	if store == "" {
+		store = "memory"
+	}
+
+	switch store {
+	case "memory":
+		logger.Info("using in-memory rate limiter")
+		return NewMemoryLimiter(), nil
+
+	case "postgres":
+		logger.Info("using postgres rate limiter")
+		return NewPostgresLimiter(db), nil
+
+	default:
+		return nil, fmt.Errorf("unknown RATE_LIMIT_STORE %q, use memory or postgres", store)
+	}
+}
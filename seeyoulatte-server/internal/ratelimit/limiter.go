@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+var ErrLimited = errors.New("rate limit exceeded")
+
+// Limit is a token bucket holding up to Burst tokens that refills completely over Per.
+// A Limit of {Burst: 5, Per: 15 * time.Minute} allows 5 requests at once, then one every 3 minutes.
+type Limit struct {
+	Burst int
+	Per   time.Duration
+}
+
+func (l Limit) ratePerSecond() float64 {
+	return float64(l.Burst) / l.Per.Seconds()
+}
+
+// Result reports whether a request may proceed and, if not, how long until it may
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// Limiter keeps one token bucket per key. Keys are namespaced by the caller,
+// e.g. "signin:ip:203.0.113.7", so one limiter serves every budget.
+type Limiter interface {
+	// Allow takes a token if one is available
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+	// Peek reports whether a token is available without taking it
+	Peek(ctx context.Context, key string, limit Limit) (Result, error)
+	// Reset refills the bucket, e.g. after a successful sign-in
+	Reset(ctx context.Context, key string) error
+}
+
+// Error carries how long the caller has to wait, so handlers can set Retry-After
+type Error struct {
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%v, retry after %s", e.Err, e.RetryAfter)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// RetryAfterSeconds rounds up, a client retrying after the header value must succeed
+func RetryAfterSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// bucket is the state both implementations store
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// refill adds the tokens earned since the last update, capped at the burst
+func (b *bucket) refill(now time.Time, limit Limit) {
+	elapsed := now.Sub(b.updatedAt).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.ratePerSecond())
+	}
+	b.updatedAt = now
+}
+
+// take refills the bucket and, when consume is set, takes a token if one is available
+func (b *bucket) take(now time.Time, limit Limit, consume bool) Result {
+	b.refill(now, limit)
+
+	if b.tokens >= 1 {
+		if consume {
+			b.tokens--
+		}
+		return Result{Allowed: true, Remaining: int(b.tokens)}
+	}
+
+	wait := (1 - b.tokens) / limit.ratePerSecond()
+	return Result{Allowed: false, RetryAfter: time.Duration(wait * float64(time.Second))}
+}
+
+func newBucket(now time.Time, limit Limit) *bucket {
+	return &bucket{tokens: float64(limit.Burst), updatedAt: now}
+}
@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryLimiter keeps buckets in process memory. Limits apply per replica,
+// use PostgresLimiter when the API runs more than one.
+type MemoryLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{buckets: make(map[string]*bucket), lastSweep: time.Now()}
+}
+
+func (m *MemoryLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	return m.take(key, limit, true), nil
+}
+
+func (m *MemoryLimiter) Peek(ctx context.Context, key string, limit Limit) (Result, error) {
+	return m.take(key, limit, false), nil
+}
+
+func (m *MemoryLimiter) Reset(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.buckets, key)
+	return nil
+}
+
+func (m *MemoryLimiter) take(key string, limit Limit, consume bool) Result {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.sweep(now)
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = newBucket(now, limit)
+		m.buckets[key] = b
+	}
+
+	return b.take(now, limit, consume)
+}
+
+// sweep drops buckets untouched for an hour, they would be full again by now
+// for any limit this application uses
+func (m *MemoryLimiter) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < time.Minute {
+		return
+	}
+	m.lastSweep = now
+
+	for key, b := range m.buckets {
+		if now.Sub(b.updatedAt) > time.Hour {
+			delete(m.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	dbutils "github.com/darkphotonKN/seeyoulatte-app/internal/utils/db"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgresLimiter keeps buckets in the rate_limit_buckets table so every replica
+// shares the same budget. Each call locks the key's row for the duration of one short transaction.
+type PostgresLimiter struct {
+	db *sqlx.DB
+}
+
+func NewPostgresLimiter(db *sqlx.DB) *PostgresLimiter {
+	return &PostgresLimiter{db: db}
+}
+
+func (p *PostgresLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	return p.take(ctx, key, limit, true)
+}
+
+func (p *PostgresLimiter) Peek(ctx context.Context, key string, limit Limit) (Result, error) {
+	return p.take(ctx, key, limit, false)
+}
+
+func (p *PostgresLimiter) Reset(ctx context.Context, key string) error {
+	_, err := p.db.ExecContext(ctx, `DELETE FROM rate_limit_buckets WHERE key = $1`, key)
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+	return nil
+}
+
+// Prune deletes buckets untouched since the cutoff
+func (p *PostgresLimiter) Prune(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := p.db.ExecContext(ctx, `DELETE FROM rate_limit_buckets WHERE updated_at < $1`, cutoff)
+	if err != nil {
+		return 0, errorutils.AnalyzeDBErr(err)
+	}
+	return result.RowsAffected()
+}
+
+func (p *PostgresLimiter) take(ctx context.Context, key string, limit Limit, consume bool) (Result, error) {
+	var result Result
+	now := time.Now()
+
+	err := dbutils.ExecTx(ctx, p.db, func(tx *sqlx.Tx) error {
+		// Create the bucket full on first use, then lock it
+		insert := `
+			INSERT INTO rate_limit_buckets (key, tokens, updated_at)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (key) DO NOTHING
+		`
+		if _, err := tx.ExecContext(ctx, insert, key, float64(limit.Burst), now); err != nil {
+			return errorutils.AnalyzeDBErr(err)
+		}
+
+		var b bucket
+		query := `SELECT tokens, updated_at FROM rate_limit_buckets WHERE key = $1 FOR UPDATE`
+		if err := tx.QueryRowContext(ctx, query, key).Scan(&b.tokens, &b.updatedAt); err != nil {
+			return errorutils.AnalyzeDBErr(err)
+		}
+
+		result = b.take(now, limit, consume)
+
+		update := `UPDATE rate_limit_buckets SET tokens = $1, updated_at = $2 WHERE key = $3`
+		if _, err := tx.ExecContext(ctx, update, b.tokens, b.updatedAt, key); err != nil {
+			return errorutils.AnalyzeDBErr(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return Result{}, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,59 @@
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+type Pruner interface {
+	Prune(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// Worker periodically deletes idle buckets from the Postgres store. A bucket idle
+// for longer than the longest limit's Per is full again, so dropping it changes nothing.
+type Worker struct {
+	pruner   Pruner
+	interval time.Duration
+	idle     time.Duration
+	logger   *slog.Logger
+}
+
+func NewWorker(pruner Pruner, interval time.Duration, idle time.Duration, logger *slog.Logger) *Worker {
+	return &Worker{
+		pruner:   pruner,
+		interval: interval,
+		idle:     idle,
+		logger:   logger,
+	}
+}
+
+// Start blocks until the context is cancelled, pruning every interval
+func (w *Worker) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	w.logger.Info("rate limit prune worker started", slog.Duration("interval", w.interval))
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.logger.Info("rate limit prune worker stopped")
+			return
+		case <-ticker.C:
+			w.run(ctx)
+		}
+	}
+}
+
+func (w *Worker) run(ctx context.Context) {
+	deleted, err := w.pruner.Prune(ctx, time.Now().Add(-w.idle))
+	if err != nil {
+		w.logger.Error("rate limit prune failed", slog.String("error", err.Error()))
+		return
+	}
+
+	if deleted > 0 {
+		w.logger.Info("rate limit prune complete", slog.Int64("buckets_deleted", deleted))
+	}
+}
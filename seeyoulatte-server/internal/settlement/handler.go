@@ -0,0 +1,144 @@
+package settlement
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/principal"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Service interface {
+	GetBalance(ctx context.Context, sellerID uuid.UUID) (*SellerBalance, error)
+	GetMySettlements(ctx context.Context, sellerID uuid.UUID) ([]Settlement, error)
+	GetStatement(ctx context.Context, id uuid.UUID, sellerID uuid.UUID) (*Settlement, []Item, error)
+}
+
+type Handler struct {
+	service Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service Service, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// GetBalance - GET /api/seller/balance (requires auth)
+func (h *Handler) GetBalance(c *gin.Context) {
+	sellerID, ok := h.sellerID(c)
+	if !ok {
+		return
+	}
+
+	balance, err := h.service.GetBalance(c.Request.Context(), sellerID)
+	if err != nil {
+		h.logger.Error("failed to get seller balance",
+			slog.String("error", err.Error()),
+			slog.String("seller_id", sellerID.String()))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get balance"})
+		return
+	}
+
+	c.JSON(http.StatusOK, balance)
+}
+
+// GetSettlements - GET /api/seller/settlements (requires auth)
+func (h *Handler) GetSettlements(c *gin.Context) {
+	sellerID, ok := h.sellerID(c)
+	if !ok {
+		return
+	}
+
+	settlements, err := h.service.GetMySettlements(c.Request.Context(), sellerID)
+	if err != nil {
+		h.logger.Error("failed to get settlements",
+			slog.String("error", err.Error()),
+			slog.String("seller_id", sellerID.String()))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get settlements"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"settlements": settlements,
+		"count":       len(settlements),
+	})
+}
+
+// DownloadStatement - GET /api/seller/settlements/:id/csv (requires auth & ownership)
+func (h *Handler) DownloadStatement(c *gin.Context) {
+	sellerID, ok := h.sellerID(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid settlement ID"})
+		return
+	}
+
+	settlement, items, err := h.service.GetStatement(c.Request.Context(), id, sellerID)
+	if err != nil {
+		if errors.Is(err, errorutils.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Settlement not found"})
+			return
+		}
+		if errors.Is(err, errorutils.ErrForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You can only download your own statements"})
+			return
+		}
+		h.logger.Error("failed to get settlement statement",
+			slog.String("error", err.Error()),
+			slog.String("settlement_id", id.String()),
+			slog.String("seller_id", sellerID.String()))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get statement"})
+		return
+	}
+
+	filename := fmt.Sprintf("settlement-%s-%s-%s.csv", settlement.PeriodEnd.Format("2006-01-02"), settlement.Currency, settlement.ID)
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write([]string{"settlement_id", "ledger_entry_id", "entry_type", "order_id", "amount", "currency", "entry_created_at"})
+	for _, item := range items {
+		_ = w.Write([]string{
+			item.SettlementID.String(),
+			strconv.Itoa(item.LedgerEntryID),
+			string(item.EntryType),
+			item.OrderID.String(),
+			strconv.FormatFloat(item.Amount, 'f', settlement.Currency.MinorUnits(), 64),
+			string(settlement.Currency),
+			item.EntryCreatedAt.Format(time.RFC3339),
+		})
+	}
+	w.Flush()
+
+	if err := w.Error(); err != nil {
+		h.logger.Error("failed to write settlement csv",
+			slog.String("error", err.Error()),
+			slog.String("settlement_id", id.String()))
+	}
+}
+
+// sellerID extracts the authenticated user ID, writing the error response if it is missing
+func (h *Handler) sellerID(c *gin.Context) (uuid.UUID, bool) {
+	p, ok := principal.FromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return uuid.Nil, false
+	}
+	return p.UserID, true
+}
@@ -0,0 +1,63 @@
+package settlement
+
+import (
+	"time"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/ledger"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/money"
+	"github.com/google/uuid"
+)
+
+// SellerBalance is what a seller is owed, derived entirely from the ledger
+// Amounts in different currencies are never added together, there is one entry per currency
+type SellerBalance struct {
+	SellerID uuid.UUID         `json:"seller_id"`
+	Balances []CurrencyBalance `json:"balances"`
+}
+
+// CurrencyBalance is the seller's balance in a single currency
+// Pending: escrow still held on open orders
+// Available: PAYOUT entries that have not been included in a settlement yet
+// Tips are reported on their own and are not included in Pending or Available
+type CurrencyBalance struct {
+	Currency      money.Currency `db:"currency" json:"currency"`
+	Pending       float64        `db:"pending" json:"pending"`
+	PendingOrders int            `db:"pending_orders" json:"pending_orders"`
+	Available     float64        `db:"available" json:"available"`
+	PendingTips   float64        `db:"pending_tips" json:"pending_tips"`
+	AvailableTips float64        `db:"available_tips" json:"available_tips"`
+}
+
+// Settlement is a periodic statement covering a seller's unsettled PAYOUT entries in one currency
+type Settlement struct {
+	ID          uuid.UUID      `db:"id" json:"id"`
+	SellerID    uuid.UUID      `db:"seller_id" json:"seller_id"`
+	Currency    money.Currency `db:"currency" json:"currency"`
+	PeriodStart time.Time      `db:"period_start" json:"period_start"`
+	PeriodEnd   time.Time      `db:"period_end" json:"period_end"`
+	TotalAmount float64        `db:"total_amount" json:"total_amount"` // Includes TipAmount
+	TipAmount   float64        `db:"tip_amount" json:"tip_amount"`
+	EntryCount  int            `db:"entry_count" json:"entry_count"`
+	CreatedAt   time.Time      `db:"created_at" json:"created_at"`
+}
+
+// Item is a single statement line, referencing the ledger entry it settles
+type Item struct {
+	ID             int              `db:"id" json:"id"`
+	SettlementID   uuid.UUID        `db:"settlement_id" json:"settlement_id"`
+	LedgerEntryID  int              `db:"ledger_entry_id" json:"ledger_entry_id"`
+	EntryType      ledger.EntryType `db:"entry_type" json:"entry_type"` // PAYOUT or TIP_PAYOUT
+	OrderID        uuid.UUID        `db:"order_id" json:"order_id"`
+	Amount         float64          `db:"amount" json:"amount"`
+	EntryCreatedAt time.Time        `db:"entry_created_at" json:"entry_created_at"`
+}
+
+// UnsettledPayout is a PAYOUT or TIP_PAYOUT ledger entry not yet referenced by any settlement item
+type UnsettledPayout struct {
+	LedgerEntryID int              `db:"ledger_entry_id"`
+	EntryType     ledger.EntryType `db:"entry_type"`
+	OrderID       uuid.UUID        `db:"order_id"`
+	Amount        float64          `db:"amount"`
+	Currency      money.Currency   `db:"currency"`
+	CreatedAt     time.Time        `db:"created_at"`
+}
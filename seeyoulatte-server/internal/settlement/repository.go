@@ -0,0 +1,255 @@
+package settlement
+
+import (
+	"context"
+	"time"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *repository {
+	return &repository{db: db}
+}
+
+// GetSellerBalance derives pending and available amounts from ledger entries, per currency
+// Pending is the escrow balance of orders that have not reached a terminal state
+func (r *repository) GetSellerBalance(ctx context.Context, sellerID uuid.UUID) (*SellerBalance, error) {
+	var balances []CurrencyBalance
+	query := `
+		WITH pending AS (
+			SELECT
+				le.currency,
+				SUM(
+					CASE
+						WHEN le.entry_type = 'ESCROW' THEN le.amount
+						WHEN le.entry_type IN ('PAYOUT', 'REFUND', 'REVERSAL') THEN -le.amount
+						ELSE 0
+					END
+				) as pending,
+				SUM(
+					CASE
+						WHEN le.entry_type = 'TIP' THEN le.amount
+						WHEN le.entry_type = 'TIP_PAYOUT' THEN -le.amount
+						ELSE 0
+					END
+				) as pending_tips,
+				COUNT(DISTINCT o.id) as pending_orders
+			FROM orders as o
+			JOIN ledger_entries as le
+			ON le.order_id = o.id
+			WHERE o.seller_id = $1
+				AND o.state IN ('paid', 'accepted', 'fulfilled', 'disputed', 'under_review')
+			GROUP BY le.currency
+		),
+		available AS (
+			SELECT
+				le.currency,
+				SUM(CASE WHEN le.entry_type = 'PAYOUT' THEN le.amount ELSE 0 END) as available,
+				SUM(CASE WHEN le.entry_type = 'TIP_PAYOUT' THEN le.amount ELSE 0 END) as available_tips
+			FROM ledger_entries as le
+			JOIN orders as o
+			ON o.id = le.order_id
+			LEFT JOIN settlement_items as si
+			ON si.ledger_entry_id = le.id
+			WHERE o.seller_id = $1
+				AND le.entry_type IN ('PAYOUT', 'TIP_PAYOUT')
+				AND si.id IS NULL
+			GROUP BY le.currency
+		)
+		SELECT
+			COALESCE(p.currency, a.currency) as currency,
+			COALESCE(p.pending, 0) as pending,
+			COALESCE(p.pending_orders, 0) as pending_orders,
+			COALESCE(a.available, 0) as available,
+			COALESCE(p.pending_tips, 0) as pending_tips,
+			COALESCE(a.available_tips, 0) as available_tips
+		FROM pending as p
+		FULL OUTER JOIN available as a
+		ON a.currency = p.currency
+		ORDER BY currency
+	`
+
+	err := r.db.SelectContext(ctx, &balances, query, sellerID)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	if balances == nil {
+		balances = []CurrencyBalance{}
+	}
+
+	return &SellerBalance{SellerID: sellerID, Balances: balances}, nil
+}
+
+// GetSellersWithUnsettledPayouts returns every seller that has PAYOUT or TIP_PAYOUT entries
+// created before the cutoff which no settlement covers yet
+func (r *repository) GetSellersWithUnsettledPayouts(ctx context.Context, cutoff time.Time) ([]uuid.UUID, error) {
+	var sellerIDs []uuid.UUID
+	query := `
+		SELECT DISTINCT o.seller_id
+		FROM ledger_entries as le
+		JOIN orders as o
+		ON o.id = le.order_id
+		LEFT JOIN settlement_items as si
+		ON si.ledger_entry_id = le.id
+		WHERE le.entry_type IN ('PAYOUT', 'TIP_PAYOUT')
+			AND le.created_at < $1
+			AND si.id IS NULL
+	`
+
+	err := r.db.SelectContext(ctx, &sellerIDs, query, cutoff)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return sellerIDs, nil
+}
+
+// GetUnsettledPayoutsForUpdateTx locks the seller's unsettled PAYOUT and TIP_PAYOUT entries
+// SKIP LOCKED lets concurrent workers pass over entries another worker is settling
+func (r *repository) GetUnsettledPayoutsForUpdateTx(ctx context.Context, tx *sqlx.Tx, sellerID uuid.UUID, cutoff time.Time) ([]UnsettledPayout, error) {
+	var payouts []UnsettledPayout
+	query := `
+		SELECT
+			le.id as ledger_entry_id, le.entry_type, le.order_id, le.amount, le.currency, le.created_at
+		FROM ledger_entries as le
+		JOIN orders as o
+		ON o.id = le.order_id
+		WHERE o.seller_id = $1
+			AND le.entry_type IN ('PAYOUT', 'TIP_PAYOUT')
+			AND le.created_at < $2
+			AND NOT EXISTS (
+				SELECT 1 FROM settlement_items as si WHERE si.ledger_entry_id = le.id
+			)
+		ORDER BY le.created_at ASC, le.id ASC
+		FOR UPDATE OF le SKIP LOCKED
+	`
+
+	err := tx.SelectContext(ctx, &payouts, query, sellerID, cutoff)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return payouts, nil
+}
+
+func (r *repository) CreateTx(ctx context.Context, tx *sqlx.Tx, settlement *Settlement) error {
+	query := `
+		INSERT INTO settlements (
+			seller_id, currency, period_start, period_end, total_amount, tip_amount, entry_count
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7
+		) RETURNING id, created_at
+	`
+
+	err := tx.QueryRowContext(
+		ctx,
+		query,
+		settlement.SellerID,
+		settlement.Currency,
+		settlement.PeriodStart,
+		settlement.PeriodEnd,
+		settlement.TotalAmount,
+		settlement.TipAmount,
+		settlement.EntryCount,
+	).Scan(&settlement.ID, &settlement.CreatedAt)
+
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
+
+func (r *repository) CreateItemTx(ctx context.Context, tx *sqlx.Tx, item *Item) error {
+	query := `
+		INSERT INTO settlement_items (
+			settlement_id, ledger_entry_id, order_id, amount
+		) VALUES (
+			$1, $2, $3, $4
+		) RETURNING id
+	`
+
+	err := tx.QueryRowContext(
+		ctx,
+		query,
+		item.SettlementID,
+		item.LedgerEntryID,
+		item.OrderID,
+		item.Amount,
+	).Scan(&item.ID)
+
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
+
+func (r *repository) GetBySellerID(ctx context.Context, sellerID uuid.UUID) ([]Settlement, error) {
+	var settlements []Settlement
+	query := `
+		SELECT
+			id, seller_id, currency, period_start, period_end,
+			total_amount, tip_amount, entry_count, created_at
+		FROM settlements
+		WHERE seller_id = $1
+		ORDER BY created_at DESC
+	`
+
+	err := r.db.SelectContext(ctx, &settlements, query, sellerID)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return settlements, nil
+}
+
+func (r *repository) GetByID(ctx context.Context, id uuid.UUID) (*Settlement, error) {
+	var settlement Settlement
+	query := `
+		SELECT
+			id, seller_id, currency, period_start, period_end,
+			total_amount, tip_amount, entry_count, created_at
+		FROM settlements
+		WHERE id = $1
+	`
+
+	err := r.db.GetContext(ctx, &settlement, query, id)
+	if err != nil {
+		dbErr := errorutils.AnalyzeDBErr(err)
+		if dbErr == errorutils.ErrNotFound {
+			return nil, nil
+		}
+		return nil, dbErr
+	}
+
+	return &settlement, nil
+}
+
+func (r *repository) GetItems(ctx context.Context, settlementID uuid.UUID) ([]Item, error) {
+	var items []Item
+	query := `
+		SELECT
+			si.id, si.settlement_id, si.ledger_entry_id, le.entry_type, si.order_id,
+			si.amount, le.created_at as entry_created_at
+		FROM settlement_items as si
+		JOIN ledger_entries as le
+		ON le.id = si.ledger_entry_id
+		WHERE si.settlement_id = $1
+		ORDER BY le.created_at ASC, le.id ASC
+	`
+
+	err := r.db.SelectContext(ctx, &items, query, settlementID)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return items, nil
+}
@@ -0,0 +1,201 @@
+package settlement
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/ledger"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/money"
+	dbutils "github.com/darkphotonKN/seeyoulatte-app/internal/utils/db"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	GetSellerBalance(ctx context.Context, sellerID uuid.UUID) (*SellerBalance, error)
+	GetSellersWithUnsettledPayouts(ctx context.Context, cutoff time.Time) ([]uuid.UUID, error)
+	GetUnsettledPayoutsForUpdateTx(ctx context.Context, tx *sqlx.Tx, sellerID uuid.UUID, cutoff time.Time) ([]UnsettledPayout, error)
+	CreateTx(ctx context.Context, tx *sqlx.Tx, settlement *Settlement) error
+	CreateItemTx(ctx context.Context, tx *sqlx.Tx, item *Item) error
+	GetBySellerID(ctx context.Context, sellerID uuid.UUID) ([]Settlement, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*Settlement, error)
+	GetItems(ctx context.Context, settlementID uuid.UUID) ([]Item, error)
+}
+
+type service struct {
+	repo   Repository
+	db     *sqlx.DB
+	logger *slog.Logger
+}
+
+func NewService(repo Repository, db *sqlx.DB, logger *slog.Logger) *service {
+	return &service{
+		repo:   repo,
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (s *service) GetBalance(ctx context.Context, sellerID uuid.UUID) (*SellerBalance, error) {
+	balance, err := s.repo.GetSellerBalance(ctx, sellerID)
+	if err != nil {
+		return nil, fmt.Errorf("getting seller balance: %w", err)
+	}
+	return balance, nil
+}
+
+func (s *service) GetMySettlements(ctx context.Context, sellerID uuid.UUID) ([]Settlement, error) {
+	settlements, err := s.repo.GetBySellerID(ctx, sellerID)
+	if err != nil {
+		return nil, fmt.Errorf("getting settlements: %w", err)
+	}
+	return settlements, nil
+}
+
+// GetStatement returns a settlement with its line items, only to the seller it belongs to
+func (s *service) GetStatement(ctx context.Context, id uuid.UUID, sellerID uuid.UUID) (*Settlement, []Item, error) {
+	settlement, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("getting settlement: %w", err)
+	}
+	if settlement == nil {
+		return nil, nil, errorutils.ErrNotFound
+	}
+
+	if settlement.SellerID != sellerID {
+		return nil, nil, errorutils.ErrForbidden
+	}
+
+	items, err := s.repo.GetItems(ctx, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("getting settlement items: %w", err)
+	}
+
+	return settlement, items, nil
+}
+
+// GenerateSettlements creates one statement per seller and currency covering every PAYOUT entry
+// recorded before the cutoff that has not been settled yet.
+// Each seller is settled in its own transaction so one failure does not block the rest.
+func (s *service) GenerateSettlements(ctx context.Context, cutoff time.Time) (int, error) {
+	sellerIDs, err := s.repo.GetSellersWithUnsettledPayouts(ctx, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("getting sellers with unsettled payouts: %w", err)
+	}
+
+	created := 0
+	for _, sellerID := range sellerIDs {
+		settlements, err := s.settleSeller(ctx, sellerID, cutoff)
+		if err != nil {
+			s.logger.Error("failed to settle seller",
+				slog.String("seller_id", sellerID.String()),
+				slog.String("error", err.Error()))
+			continue
+		}
+
+		// empty when another worker already picked up these entries
+		created += len(settlements)
+	}
+
+	return created, nil
+}
+
+func (s *service) settleSeller(ctx context.Context, sellerID uuid.UUID, cutoff time.Time) ([]Settlement, error) {
+	var settlements []Settlement
+
+	err := dbutils.ExecTx(ctx, s.db, func(tx *sqlx.Tx) error {
+		// re-read inside the transaction, entries may have been settled since the batch query
+		payouts, err := s.repo.GetUnsettledPayoutsForUpdateTx(ctx, tx, sellerID, cutoff)
+		if err != nil {
+			return fmt.Errorf("getting unsettled payouts: %w", err)
+		}
+
+		// payouts are ordered oldest first, so each currency's first entry starts its period
+		var currencies []money.Currency
+		byCurrency := make(map[money.Currency][]UnsettledPayout)
+		for _, p := range payouts {
+			if _, seen := byCurrency[p.Currency]; !seen {
+				currencies = append(currencies, p.Currency)
+			}
+			byCurrency[p.Currency] = append(byCurrency[p.Currency], p)
+		}
+
+		for _, currency := range currencies {
+			settlement, err := s.createSettlementTx(ctx, tx, sellerID, currency, cutoff, byCurrency[currency])
+			if err != nil {
+				return err
+			}
+			settlements = append(settlements, *settlement)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, settlement := range settlements {
+		s.logger.Info("settlement created",
+			slog.String("settlement_id", settlement.ID.String()),
+			slog.String("seller_id", sellerID.String()),
+			slog.String("currency", string(settlement.Currency)),
+			slog.Float64("total_amount", settlement.TotalAmount),
+			slog.Int("entry_count", settlement.EntryCount))
+	}
+
+	return settlements, nil
+}
+
+// createSettlementTx writes one statement and its items for payouts that share a currency
+func (s *service) createSettlementTx(ctx context.Context, tx *sqlx.Tx, sellerID uuid.UUID, currency money.Currency, cutoff time.Time, payouts []UnsettledPayout) (*Settlement, error) {
+	total := money.Money{Currency: currency}
+	tips := money.Money{Currency: currency}
+	for _, p := range payouts {
+		amount := money.FromDecimal(p.Amount, p.Currency)
+
+		var err error
+		total, err = total.Add(amount)
+		if err != nil {
+			return nil, fmt.Errorf("totalling ledger entry %d: %w", p.LedgerEntryID, err)
+		}
+
+		if p.EntryType == ledger.EntryTypeTipPayout {
+			if tips, err = tips.Add(amount); err != nil {
+				return nil, fmt.Errorf("totalling tip entry %d: %w", p.LedgerEntryID, err)
+			}
+		}
+	}
+
+	settlement := &Settlement{
+		SellerID:    sellerID,
+		Currency:    currency,
+		PeriodStart: payouts[0].CreatedAt,
+		PeriodEnd:   cutoff,
+		TotalAmount: total.Float64(),
+		TipAmount:   tips.Float64(),
+		EntryCount:  len(payouts),
+	}
+
+	if err := s.repo.CreateTx(ctx, tx, settlement); err != nil {
+		return nil, fmt.Errorf("creating %s settlement: %w", currency, err)
+	}
+
+	for _, p := range payouts {
+		item := &Item{
+			SettlementID:  settlement.ID,
+			LedgerEntryID: p.LedgerEntryID,
+			OrderID:       p.OrderID,
+			Amount:        p.Amount,
+		}
+
+		if err := s.repo.CreateItemTx(ctx, tx, item); err != nil {
+			return nil, fmt.Errorf("creating settlement item for ledger entry %d: %w", p.LedgerEntryID, err)
+		}
+	}
+
+	return settlement, nil
+}
@@ -0,0 +1,56 @@
+package settlement
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+type WorkerService interface {
+	GenerateSettlements(ctx context.Context, cutoff time.Time) (int, error)
+}
+
+// Worker periodically generates settlement statements on a ticker
+type Worker struct {
+	service  WorkerService
+	interval time.Duration
+	logger   *slog.Logger
+}
+
+func NewWorker(service WorkerService, interval time.Duration, logger *slog.Logger) *Worker {
+	return &Worker{
+		service:  service,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Start blocks until the context is cancelled, generating statements every interval
+func (w *Worker) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	w.logger.Info("settlement worker started", slog.Duration("interval", w.interval))
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.logger.Info("settlement worker stopped")
+			return
+		case <-ticker.C:
+			w.run(ctx)
+		}
+	}
+}
+
+func (w *Worker) run(ctx context.Context) {
+	created, err := w.service.GenerateSettlements(ctx, time.Now())
+	if err != nil {
+		w.logger.Error("settlement run failed", slog.String("error", err.Error()))
+		return
+	}
+
+	if created > 0 {
+		w.logger.Info("settlement run complete", slog.Int("settlements_created", created))
+	}
+}
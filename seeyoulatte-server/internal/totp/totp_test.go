@@ -0,0 +1,86 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 seed from RFC 6238 Appendix B, the ASCII "12345678901234567890"
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCodeMatchesRFC6238Vectors(t *testing.T) {
+	// The RFC lists 8 digit codes, these are their last 6 digits
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+		{unix: 20000000000, want: "353130"},
+	}
+
+	for _, tt := range tests {
+		t.Run(time.Unix(tt.unix, 0).UTC().Format(time.RFC3339), func(t *testing.T) {
+			got, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+			if err != nil {
+				t.Fatalf("Code() error = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("Code() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateStepWindow(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+
+	tests := []struct {
+		name   string
+		step   int64
+		wantOK bool
+	}{
+		{name: "current step", step: current, wantOK: true},
+		{name: "one step behind", step: current - 1, wantOK: true},
+		{name: "one step ahead", step: current + 1, wantOK: true},
+		{name: "two steps behind", step: current - 2},
+		{name: "two steps ahead", step: current + 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := Code(rfcSecret, tt.step)
+			if err != nil {
+				t.Fatalf("Code() error = %v", err)
+			}
+
+			step, ok := Validate(rfcSecret, code, now)
+			if ok != tt.wantOK {
+				t.Fatalf("Validate() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && step != tt.step {
+				t.Fatalf("Validate() step = %d, want %d", step, tt.step)
+			}
+		})
+	}
+}
+
+func TestValidateRefusesMalformedCodes(t *testing.T) {
+	now := time.Unix(59, 0)
+
+	for _, code := range []string{"", "28708", "2870821", "abcdef"} {
+		if _, ok := Validate(rfcSecret, code, now); ok {
+			t.Errorf("Validate(%q) ok = true, want false", code)
+		}
+	}
+	if _, ok := Validate(rfcSecret, " 287082 ", now); !ok {
+		t.Errorf("Validate() ok = false for a code with surrounding spaces, want true")
+	}
+	if _, ok := Validate("not base32!", "287082", now); ok {
+		t.Errorf("Validate() ok = true with an undecodable secret, want false")
+	}
+}
@@ -0,0 +1,40 @@
+package transfer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/money"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/payment"
+	"github.com/google/uuid"
+)
+
+// Transfer is a refund or payout the provider still has to make, or has made
+type Transfer struct {
+	ID                uuid.UUID         `db:"id" json:"id"`
+	OrderID           uuid.UUID         `db:"order_id" json:"order_id"`
+	Operation         payment.Operation `db:"operation" json:"operation"`
+	IdempotencyKey    string            `db:"idempotency_key" json:"idempotency_key"`
+	ChargeReference   *string           `db:"charge_reference" json:"charge_reference,omitempty"`
+	SellerID          *uuid.UUID        `db:"seller_id" json:"seller_id,omitempty"`
+	Amount            float64           `db:"amount" json:"amount"`
+	Currency          money.Currency    `db:"currency" json:"currency"`
+	ProviderReference *string           `db:"provider_reference" json:"provider_reference,omitempty"`
+	Attempts          int               `db:"attempts" json:"attempts"`
+	LastError         *string           `db:"last_error" json:"last_error,omitempty"`
+	CompletedAt       *time.Time        `db:"completed_at" json:"completed_at,omitempty"`
+	FailedAt          *time.Time        `db:"failed_at" json:"failed_at,omitempty"`
+	CreatedAt         time.Time         `db:"created_at" json:"created_at"`
+}
+
+// Money returns the amount in its currency's minor units
+func (t *Transfer) Money() money.Money {
+	return money.FromDecimal(t.Amount, t.Currency)
+}
+
+// IdempotencyKey names the money movement behind a ledger entry. An order can have several
+// REFUND or REVERSAL entries, so the key is the entry's id: stable across retries of the
+// transfer and unique per movement.
+func IdempotencyKey(ledgerEntryID int) string {
+	return fmt.Sprintf("ledger-entry:%d", ledgerEntryID)
+}
@@ -0,0 +1,125 @@
+package transfer
+
+import (
+	"context"
+	"time"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *repository {
+	return &repository{
+		db: db,
+	}
+}
+
+// CreateTx queues a transfer as part of the order transition that owes it
+func (r *repository) CreateTx(ctx context.Context, tx *sqlx.Tx, t *Transfer) error {
+	query := `
+		INSERT INTO transfers (
+			order_id, operation, idempotency_key, charge_reference, seller_id, amount, currency
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7
+		) RETURNING id, created_at
+	`
+
+	err := tx.QueryRowContext(
+		ctx,
+		query,
+		t.OrderID,
+		t.Operation,
+		t.IdempotencyKey,
+		t.ChargeReference,
+		t.SellerID,
+		t.Amount,
+		t.Currency,
+	).Scan(&t.ID, &t.CreatedAt)
+
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
+
+// GetPendingIDs returns transfers that are neither completed nor failed, oldest first
+func (r *repository) GetPendingIDs(ctx context.Context, limit int) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	query := `
+		SELECT id
+		FROM transfers
+		WHERE completed_at IS NULL AND failed_at IS NULL
+		ORDER BY created_at ASC
+		LIMIT $1
+	`
+
+	err := r.db.SelectContext(ctx, &ids, query, limit)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return ids, nil
+}
+
+// GetPendingByIDForUpdateSkipLockedTx locks a pending transfer, returning nil if another worker
+// holds it or it was settled since the batch query
+func (r *repository) GetPendingByIDForUpdateSkipLockedTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (*Transfer, error) {
+	var t Transfer
+	query := `
+		SELECT
+			id, order_id, operation, idempotency_key, charge_reference, seller_id, amount, currency,
+			provider_reference, attempts, last_error, completed_at, failed_at, created_at
+		FROM transfers
+		WHERE id = $1 AND completed_at IS NULL AND failed_at IS NULL
+		FOR UPDATE SKIP LOCKED
+	`
+
+	err := tx.GetContext(ctx, &t, query, id)
+	if err != nil {
+		dbErr := errorutils.AnalyzeDBErr(err)
+		if dbErr == errorutils.ErrNotFound {
+			return nil, nil
+		}
+		return nil, dbErr
+	}
+
+	return &t, nil
+}
+
+// MarkCompletedTx records the provider's transaction for a transfer
+func (r *repository) MarkCompletedTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, providerReference string, now time.Time) error {
+	query := `
+		UPDATE transfers
+		SET provider_reference = $2, attempts = attempts + 1, last_error = NULL, completed_at = $3
+		WHERE id = $1
+	`
+
+	_, err := tx.ExecContext(ctx, query, id, providerReference, now)
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
+
+// RecordFailureTx counts a failed attempt, failedAt is set once the transfer will not be retried
+func (r *repository) RecordFailureTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, reason string, failedAt *time.Time) error {
+	query := `
+		UPDATE transfers
+		SET attempts = attempts + 1, last_error = $2, failed_at = $3
+		WHERE id = $1
+	`
+
+	_, err := tx.ExecContext(ctx, query, id, reason, failedAt)
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
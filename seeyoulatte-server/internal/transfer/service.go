@@ -0,0 +1,175 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/money"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/payment"
+	dbutils "github.com/darkphotonKN/seeyoulatte-app/internal/utils/db"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// batchSize caps how many transfers a single run sends
+	batchSize = 100
+	// MaxAttempts is how often an unreachable gateway is retried before an operator has to step in
+	MaxAttempts = 10
+)
+
+type Repository interface {
+	CreateTx(ctx context.Context, tx *sqlx.Tx, t *Transfer) error
+	GetPendingIDs(ctx context.Context, limit int) ([]uuid.UUID, error)
+	GetPendingByIDForUpdateSkipLockedTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (*Transfer, error)
+	MarkCompletedTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, providerReference string, now time.Time) error
+	RecordFailureTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, reason string, failedAt *time.Time) error
+}
+
+// service moves money out through the payment gateway after the transaction that owes it has
+// committed. Each transfer is one gateway call under an idempotency key, so a retry after a
+// timeout or a lost commit replays the provider's first answer instead of paying twice.
+type service struct {
+	repo    Repository
+	db      *sqlx.DB
+	gateway payment.PaymentGateway
+	logger  *slog.Logger
+}
+
+func NewService(repo Repository, db *sqlx.DB, gateway payment.PaymentGateway, logger *slog.Logger) *service {
+	return &service{
+		repo:    repo,
+		db:      db,
+		gateway: gateway,
+		logger:  logger,
+	}
+}
+
+// QueueRefundTx queues a refund of the order's charge alongside its REFUND ledger entry
+func (s *service) QueueRefundTx(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID, ledgerEntryID int, chargeID string, amount money.Money) error {
+	return s.repo.CreateTx(ctx, tx, &Transfer{
+		OrderID:         orderID,
+		Operation:       payment.OperationRefund,
+		IdempotencyKey:  IdempotencyKey(ledgerEntryID),
+		ChargeReference: &chargeID,
+		Amount:          amount.Float64(),
+		Currency:        amount.Currency,
+	})
+}
+
+// QueueChargeRefundTx queues a refund of a charge that never reached escrow, such as a reported
+// payment that did not match its order. It is keyed on the charge, so a charge is refunded once.
+func (s *service) QueueChargeRefundTx(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID, chargeID string, amount money.Money) error {
+	return s.repo.CreateTx(ctx, tx, &Transfer{
+		OrderID:         orderID,
+		Operation:       payment.OperationRefund,
+		IdempotencyKey:  fmt.Sprintf("%s:CHARGE_REFUND", chargeID),
+		ChargeReference: &chargeID,
+		Amount:          amount.Float64(),
+		Currency:        amount.Currency,
+	})
+}
+
+// QueuePayoutTx queues a payout to the seller alongside its PAYOUT or TIP_PAYOUT ledger entry
+func (s *service) QueuePayoutTx(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID, ledgerEntryID int, sellerID uuid.UUID, amount money.Money) error {
+	return s.repo.CreateTx(ctx, tx, &Transfer{
+		OrderID:        orderID,
+		Operation:      payment.OperationPayout,
+		IdempotencyKey: IdempotencyKey(ledgerEntryID),
+		SellerID:       &sellerID,
+		Amount:         amount.Float64(),
+		Currency:       amount.Currency,
+	})
+}
+
+// ProcessPending sends queued transfers to the gateway, returning how many completed
+func (s *service) ProcessPending(ctx context.Context) (int, error) {
+	ids, err := s.repo.GetPendingIDs(ctx, batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("getting pending transfers: %w", err)
+	}
+
+	completed := 0
+	for _, id := range ids {
+		if s.process(ctx, id) {
+			completed++
+		}
+	}
+
+	return completed, nil
+}
+
+// process makes a single transfer's gateway call while holding its row, so two workers never
+// send the same transfer at once. A decline is final and left for an operator, an unreachable
+// gateway is retried on the next run until MaxAttempts.
+func (s *service) process(ctx context.Context, id uuid.UUID) bool {
+	completed := false
+
+	err := dbutils.ExecTx(ctx, s.db, func(tx *sqlx.Tx) error {
+		t, err := s.repo.GetPendingByIDForUpdateSkipLockedTx(ctx, tx, id)
+		if err != nil {
+			return fmt.Errorf("locking transfer: %w", err)
+		}
+
+		// locked by another worker or settled since the batch query
+		if t == nil {
+			return nil
+		}
+
+		txn, err := s.send(ctx, t)
+		if err != nil {
+			now := time.Now()
+			var failedAt *time.Time
+			if errors.Is(err, errorutils.ErrPaymentDeclined) || t.Attempts+1 >= MaxAttempts {
+				failedAt = &now
+			}
+
+			s.logger.Error("transfer failed",
+				slog.String("transfer_id", t.ID.String()),
+				slog.String("order_id", t.OrderID.String()),
+				slog.String("operation", string(t.Operation)),
+				slog.Int("attempt", t.Attempts+1),
+				slog.Bool("final", failedAt != nil),
+				slog.String("error", err.Error()))
+
+			return s.repo.RecordFailureTx(ctx, tx, t.ID, err.Error(), failedAt)
+		}
+
+		if err := s.repo.MarkCompletedTx(ctx, tx, t.ID, txn.ID, time.Now()); err != nil {
+			return err
+		}
+
+		completed = true
+		return nil
+	})
+
+	if err != nil {
+		s.logger.Error("failed to process transfer",
+			slog.String("transfer_id", id.String()),
+			slog.String("error", err.Error()))
+		return false
+	}
+
+	return completed
+}
+
+func (s *service) send(ctx context.Context, t *Transfer) (*payment.Transaction, error) {
+	switch t.Operation {
+	case payment.OperationRefund:
+		if t.ChargeReference == nil {
+			return nil, fmt.Errorf("%w: refund has no charge reference", errorutils.ErrPaymentDeclined)
+		}
+		return s.gateway.Refund(ctx, *t.ChargeReference, t.Money(), t.IdempotencyKey)
+	case payment.OperationPayout:
+		if t.SellerID == nil {
+			return nil, fmt.Errorf("%w: payout has no seller", errorutils.ErrPaymentDeclined)
+		}
+		return s.gateway.Payout(ctx, *t.SellerID, t.Money(), t.IdempotencyKey)
+	default:
+		return nil, fmt.Errorf("%w: unknown transfer operation %q", errorutils.ErrPaymentDeclined, t.Operation)
+	}
+}
@@ -0,0 +1,56 @@
+package transfer
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+type WorkerService interface {
+	ProcessPending(ctx context.Context) (int, error)
+}
+
+// Worker polls for queued refunds and payouts and sends them to the payment gateway
+type Worker struct {
+	service  WorkerService
+	interval time.Duration
+	logger   *slog.Logger
+}
+
+func NewWorker(service WorkerService, interval time.Duration, logger *slog.Logger) *Worker {
+	return &Worker{
+		service:  service,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Start blocks until the context is cancelled, processing pending transfers every interval
+func (w *Worker) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	w.logger.Info("transfer worker started", slog.Duration("interval", w.interval))
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.logger.Info("transfer worker stopped")
+			return
+		case <-ticker.C:
+			w.run(ctx)
+		}
+	}
+}
+
+func (w *Worker) run(ctx context.Context) {
+	processed, err := w.service.ProcessPending(ctx)
+	if err != nil {
+		w.logger.Error("transfer run failed", slog.String("error", err.Error()))
+		return
+	}
+
+	if processed > 0 {
+		w.logger.Info("transfer run complete", slog.Int("transfers_completed", processed))
+	}
+}
@@ -2,9 +2,14 @@ package user
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"net/http"
+	"strconv"
 
+	"github.com/darkphotonKN/seeyoulatte-app/internal/principal"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/ratelimit"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -14,8 +19,23 @@ type Service interface {
 	SignUp(ctx context.Context, req *SignUpRequest) (*AuthResponse, error)
 	SignIn(ctx context.Context, req *SignInRequest) (*AuthResponse, error)
 	GoogleAuth(ctx context.Context, idToken string) (*AuthResponse, error)
+	Refresh(ctx context.Context, rawToken string) (*AuthResponse, error)
+	Logout(ctx context.Context, rawToken string) error
+	RevokeAllSessions(ctx context.Context, userID uuid.UUID) error
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, rawToken string, password string) error
+	VerifyEmail(ctx context.Context, rawToken string) error
+	ResendVerification(ctx context.Context, userID uuid.UUID) error
 	GetByID(ctx context.Context, id uuid.UUID) (*User, error)
 	GenerateJWT(user *User) (string, error)
+	OnboardSeller(ctx context.Context, id uuid.UUID) (*User, error)
+	UpdateProfile(ctx context.Context, id uuid.UUID, req *UpdateProfileRequest) (*User, error)
+	VerifyTwoFactor(ctx context.Context, req *TwoFactorVerifyRequest) (*AuthResponse, error)
+	EnrollTwoFactor(ctx context.Context, userID uuid.UUID) (*TwoFactorEnrollment, error)
+	ConfirmTwoFactor(ctx context.Context, userID uuid.UUID, code string) (*RecoveryCodesResponse, error)
+	RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) (*RecoveryCodesResponse, error)
+	DisableTwoFactor(ctx context.Context, userID uuid.UUID, code string) error
+	GetAll(ctx context.Context, filter ListUsersFilter) ([]User, error)
 }
 
 type Handler struct {
@@ -78,6 +98,14 @@ func (h *Handler) SignIn(c *gin.Context) {
 			slog.String("error", err.Error()))
 
 		// Check for specific errors
+		var limited *ratelimit.Error
+		if errors.As(err, &limited) {
+			c.Header("Retry-After", strconv.Itoa(ratelimit.RetryAfterSeconds(limited.RetryAfter)))
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error": "Too many failed sign-in attempts. Try again later",
+			})
+			return
+		}
 		if err.Error() == "invalid credentials" {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error": "Invalid email or password",
@@ -136,24 +164,212 @@ func (h *Handler) GoogleAuth(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
-func (h *Handler) GetCurrentUser(c *gin.Context) {
-	// Get user ID from context (set by auth middleware)
-	userIDStr, exists := c.Get("user_id")
-	if !exists {
+// Refresh - POST /api/auth/refresh
+func (h *Handler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+
+	resp, err := h.service.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, errorutils.ErrInvalidRefreshToken):
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid or expired refresh token",
+			})
+		case errors.Is(err, errorutils.ErrRefreshTokenReused):
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Refresh token was already used. Please sign in again",
+			})
+		case errors.Is(err, errorutils.ErrUserIsFrozen):
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Your account has been suspended",
+			})
+		default:
+			h.logger.Error("token refresh failed",
+				slog.String("error", err.Error()))
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to refresh token",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Logout - POST /api/auth/logout, ends the session of the given refresh token
+func (h *Handler) Logout(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+
+	if err := h.service.Logout(c.Request.Context(), req.RefreshToken); err != nil {
+		h.logger.Error("logout failed",
+			slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to log out",
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// LogoutAll - POST /api/auth/logout-all (requires auth), signs the user out on every device
+func (h *Handler) LogoutAll(c *gin.Context) {
+	p, ok := principal.FromContext(c)
+	if !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"error": "Not authenticated",
 		})
 		return
 	}
+	userID := p.UserID
 
-	userID, err := uuid.Parse(userIDStr.(string))
-	if err != nil {
+	if err := h.service.RevokeAllSessions(c.Request.Context(), userID); err != nil {
+		h.logger.Error("logout-all failed",
+			slog.String("user_id", userID.String()),
+			slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to log out",
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ForgotPassword - POST /api/auth/password/forgot, answers the same whether or not the email is registered
+func (h *Handler) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid user ID",
+			"error": "Invalid request format",
+		})
+		return
+	}
+
+	if err := h.service.ForgotPassword(c.Request.Context(), req.Email); err != nil {
+		h.logger.Error("password reset request failed",
+			slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to request password reset",
 		})
 		return
 	}
 
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "If an account exists for this email, a reset link has been sent",
+	})
+}
+
+// ResetPassword - POST /api/auth/password/reset
+func (h *Handler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+
+	if err := h.service.ResetPassword(c.Request.Context(), req.Token, req.Password); err != nil {
+		if errors.Is(err, errorutils.ErrInvalidResetToken) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Reset link is invalid or has expired",
+			})
+			return
+		}
+
+		h.logger.Error("password reset failed",
+			slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to reset password",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Password updated. Please sign in again",
+	})
+}
+
+// VerifyEmail - GET /api/auth/verify?token=, the link sent on signup
+func (h *Handler) VerifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "token is required",
+		})
+		return
+	}
+
+	if err := h.service.VerifyEmail(c.Request.Context(), token); err != nil {
+		if errors.Is(err, errorutils.ErrInvalidVerifyToken) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Verification link is invalid or has expired",
+			})
+			return
+		}
+
+		h.logger.Error("email verification failed",
+			slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to verify email",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Email verified",
+	})
+}
+
+// ResendVerification - POST /api/auth/verify/resend (requires auth)
+func (h *Handler) ResendVerification(c *gin.Context) {
+	p, ok := principal.FromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Not authenticated",
+		})
+		return
+	}
+	userID := p.UserID
+
+	if err := h.service.ResendVerification(c.Request.Context(), userID); err != nil {
+		h.logger.Error("failed to resend verification email",
+			slog.String("user_id", userID.String()),
+			slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to send verification email",
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Verification email sent",
+	})
+}
+
+func (h *Handler) GetCurrentUser(c *gin.Context) {
+	p, ok := principal.FromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Not authenticated",
+		})
+		return
+	}
+	userID := p.UserID
+
 	user, err := h.service.GetByID(c.Request.Context(), userID)
 	if err != nil || user == nil {
 		c.JSON(http.StatusNotFound, gin.H{
@@ -163,4 +379,276 @@ func (h *Handler) GetCurrentUser(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, user)
-}
\ No newline at end of file
+}
+
+// UpdateCurrentUser - PATCH /api/me (requires auth)
+func (h *Handler) UpdateCurrentUser(c *gin.Context) {
+	p, ok := principal.FromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Not authenticated",
+		})
+		return
+	}
+	userID := p.UserID
+
+	var req UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	user, err := h.service.UpdateProfile(c.Request.Context(), userID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, errorutils.ErrInvalidInput):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+		case errors.Is(err, errorutils.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "User not found",
+			})
+		default:
+			h.logger.Error("profile update failed",
+				slog.String("user_id", userID.String()),
+				slog.String("error", err.Error()))
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to update profile",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// VerifyTwoFactor - POST /api/auth/2fa/verify, second step of signing in to an account with two-factor authentication
+func (h *Handler) VerifyTwoFactor(c *gin.Context) {
+	var req TwoFactorVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+
+	resp, err := h.service.VerifyTwoFactor(c.Request.Context(), &req)
+	if err != nil {
+		h.twoFactorError(c, err, uuid.Nil, "Failed to sign in")
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// EnrollTwoFactor - POST /api/me/2fa/enroll (requires auth), returns a secret to confirm with a code
+func (h *Handler) EnrollTwoFactor(c *gin.Context) {
+	p, ok := principal.FromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Not authenticated",
+		})
+		return
+	}
+	userID := p.UserID
+
+	enrollment, err := h.service.EnrollTwoFactor(c.Request.Context(), userID)
+	if err != nil {
+		h.twoFactorError(c, err, userID, "Failed to start two-factor enrollment")
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// ConfirmTwoFactor - POST /api/me/2fa/confirm (requires auth), enables two-factor authentication
+// and returns the recovery codes
+func (h *Handler) ConfirmTwoFactor(c *gin.Context) {
+	userID, req, ok := h.bindTwoFactorCode(c)
+	if !ok {
+		return
+	}
+
+	codes, err := h.service.ConfirmTwoFactor(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		h.twoFactorError(c, err, userID, "Failed to enable two-factor authentication")
+		return
+	}
+
+	c.JSON(http.StatusOK, codes)
+}
+
+// RegenerateRecoveryCodes - POST /api/me/2fa/recovery-codes (requires auth), replaces every recovery code
+func (h *Handler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, req, ok := h.bindTwoFactorCode(c)
+	if !ok {
+		return
+	}
+
+	codes, err := h.service.RegenerateRecoveryCodes(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		h.twoFactorError(c, err, userID, "Failed to regenerate recovery codes")
+		return
+	}
+
+	c.JSON(http.StatusOK, codes)
+}
+
+// DisableTwoFactor - POST /api/me/2fa/disable (requires auth)
+func (h *Handler) DisableTwoFactor(c *gin.Context) {
+	userID, req, ok := h.bindTwoFactorCode(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DisableTwoFactor(c.Request.Context(), userID, req.Code); err != nil {
+		h.twoFactorError(c, err, userID, "Failed to disable two-factor authentication")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) bindTwoFactorCode(c *gin.Context) (uuid.UUID, *TwoFactorCodeRequest, bool) {
+	p, ok := principal.FromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Not authenticated",
+		})
+		return uuid.Nil, nil, false
+	}
+	userID := p.UserID
+
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return uuid.Nil, nil, false
+	}
+
+	return userID, &req, true
+}
+
+// twoFactorError maps the errors shared by the two-factor endpoints
+func (h *Handler) twoFactorError(c *gin.Context, err error, userID uuid.UUID, message string) {
+	var limited *ratelimit.Error
+	switch {
+	case errors.As(err, &limited):
+		c.Header("Retry-After", strconv.Itoa(ratelimit.RetryAfterSeconds(limited.RetryAfter)))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error": "Too many failed attempts. Try again later",
+		})
+	case errors.Is(err, errorutils.ErrInvalidTwoFactorCode):
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid two-factor code",
+		})
+	case errors.Is(err, errorutils.ErrInvalidChallenge):
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid or expired sign-in challenge. Please sign in again",
+		})
+	case errors.Is(err, errorutils.ErrUserIsFrozen):
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Your account has been suspended",
+		})
+	case errors.Is(err, errorutils.ErrTwoFactorAlreadyEnabled):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Two-factor authentication is already enabled",
+		})
+	case errors.Is(err, errorutils.ErrTwoFactorNotEnrolled):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Two-factor authentication is not set up",
+		})
+	case errors.Is(err, errorutils.ErrTwoFactorRequired):
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Admins cannot disable two-factor authentication",
+		})
+	case errors.Is(err, errorutils.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "User not found",
+		})
+	default:
+		h.logger.Error("two-factor request failed",
+			slog.String("user_id", userID.String()),
+			slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": message,
+		})
+	}
+}
+
+// OnboardSeller - POST /api/seller/onboard (requires auth)
+func (h *Handler) OnboardSeller(c *gin.Context) {
+	p, ok := principal.FromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Not authenticated",
+		})
+		return
+	}
+	userID := p.UserID
+
+	user, err := h.service.OnboardSeller(c.Request.Context(), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, errorutils.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "User not found",
+			})
+		case errors.Is(err, errorutils.ErrUserIsFrozen):
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Your account has been suspended",
+			})
+		case errors.Is(err, errorutils.ErrEmailNotVerified):
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Verify your email address before onboarding as a seller",
+				"code":  "email_not_verified",
+			})
+		default:
+			h.logger.Error("seller onboarding failed",
+				slog.String("user_id", userID.String()),
+				slog.String("error", err.Error()))
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to complete seller onboarding",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// ListUsers - GET /api/admin/users?frozen=&search= (requires admin)
+func (h *Handler) ListUsers(c *gin.Context) {
+	var filter ListUsersFilter
+
+	if v := c.Query("frozen"); v != "" {
+		frozen, err := strconv.ParseBool(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "frozen must be true or false",
+			})
+			return
+		}
+		filter.IsFrozen = &frozen
+	}
+	filter.Search = c.Query("search")
+
+	users, err := h.service.GetAll(c.Request.Context(), filter)
+	if err != nil {
+		h.logger.Error("failed to list users",
+			slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to list users",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users": users,
+		"count": len(users),
+	})
+}
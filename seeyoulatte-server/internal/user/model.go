@@ -13,6 +13,8 @@ type User struct {
 	Bio                       *string    `db:"bio" json:"bio,omitempty"`
 	LocationText              *string    `db:"location_text" json:"location_text,omitempty"`
 	IsFrozen                  bool       `db:"is_frozen" json:"is_frozen"`
+	IsAdmin                   bool       `db:"is_admin" json:"is_admin"`
+	SellerVerifiedAt          *time.Time `db:"seller_verified_at" json:"seller_verified_at,omitempty"` // Set by seller onboarding
 	GoogleID                  *string    `db:"google_id" json:"-"`
 	AvatarURL                 *string    `db:"avatar_url" json:"avatar_url,omitempty"`
 	IsVerified                bool       `db:"is_verified" json:"is_verified"`
@@ -20,6 +22,11 @@ type User struct {
 	CreatedAt                 time.Time  `db:"created_at" json:"created_at"`
 	UpdatedAt                 time.Time  `db:"updated_at" json:"updated_at"`
 	LastLoginAt               *time.Time `db:"last_login_at" json:"last_login_at,omitempty"`
+	TokensValidAfter          *time.Time `db:"tokens_valid_after" json:"-"` // Access tokens issued earlier are rejected
+	TOTPSecret                *string    `db:"totp_secret" json:"-"`
+	TOTPEnabledAt             *time.Time `db:"totp_enabled_at" json:"totp_enabled_at,omitempty"` // Set once enrollment is confirmed
+	TOTPLastStep              *int64     `db:"totp_last_step" json:"-"`
+	DeletedAt                 *time.Time `db:"deleted_at" json:"deleted_at,omitempty"` // Set when the account was deleted and anonymized
 }
 
 type SignUpRequest struct {
@@ -37,9 +44,99 @@ type GoogleAuthRequest struct {
 	IDToken string `json:"id_token" binding:"required"`
 }
 
+// AuthResponse either starts a session, or for accounts with two-factor authentication
+// only carries a challenge to exchange at /api/auth/2fa/verify
 type AuthResponse struct {
-	User  *User  `json:"user"`
-	Token string `json:"token"`
+	User               *User      `json:"user,omitempty"`
+	Token              string     `json:"token,omitempty"` // Short-lived access token
+	TokenExpiresAt     *time.Time `json:"token_expires_at,omitempty"`
+	RefreshToken       string     `json:"refresh_token,omitempty"` // Single use, exchange at /api/auth/refresh
+	TwoFactorRequired  bool       `json:"two_factor_required,omitempty"`
+	ChallengeToken     string     `json:"challenge_token,omitempty"`
+	ChallengeExpiresAt *time.Time `json:"challenge_expires_at,omitempty"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// RefreshToken is one link in a rotation chain. Every refresh revokes the presented token
+// and issues a new one in the same family, so presenting a revoked token means it was stolen.
+type RefreshToken struct {
+	ID         uuid.UUID  `db:"id"`
+	UserID     uuid.UUID  `db:"user_id"`
+	FamilyID   uuid.UUID  `db:"family_id"`
+	TokenHash  string     `db:"token_hash"`
+	ExpiresAt  time.Time  `db:"expires_at"`
+	RevokedAt  *time.Time `db:"revoked_at"`
+	ReplacedBy *uuid.UUID `db:"replaced_by"`
+	CreatedAt  time.Time  `db:"created_at"`
+}
+
+// UpdateProfileRequest changes only the fields that are present. An empty string clears
+// an optional field, the name can be changed but not cleared.
+type UpdateProfileRequest struct {
+	Name                        *string `json:"name" binding:"omitempty,min=1,max=255"`
+	Bio                         *string `json:"bio" binding:"omitempty,max=1000"`
+	LocationText                *string `json:"location_text" binding:"omitempty,max=255"`
+	AvatarURL                   *string `json:"avatar_url" binding:"omitempty,url,max=500"`
+	PreferredPickupInstructions *string `json:"preferred_pickup_instructions" binding:"omitempty,max=1000"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=8"`
+}
+
+// PasswordResetToken is emailed as a link, it works once and only until it expires
+type PasswordResetToken struct {
+	ID        uuid.UUID  `db:"id"`
+	UserID    uuid.UUID  `db:"user_id"`
+	TokenHash string     `db:"token_hash"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+// EmailVerificationToken is emailed on signup, it works once and only until it expires
+type EmailVerificationToken struct {
+	ID        uuid.UUID  `db:"id"`
+	UserID    uuid.UUID  `db:"user_id"`
+	TokenHash string     `db:"token_hash"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+// TwoFactorCodeRequest carries a code from the authenticator app, or where allowed a recovery code
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required,max=32"`
+}
+
+type TwoFactorVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required,max=32"` // TOTP or recovery code
+}
+
+// TwoFactorEnrollment is shown once so the user can add the secret to an authenticator app
+type TwoFactorEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"` // otpauth:// URI, usually rendered as a QR code
+}
+
+// RecoveryCodesResponse is the only time the codes are shown, only their hashes are stored
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// ListUsersFilter narrows the admin user list, nil fields match everyone
+type ListUsersFilter struct {
+	IsFrozen *bool
+	Search   string // Case-insensitive match on email or name
 }
 
 type GoogleUserInfo struct {
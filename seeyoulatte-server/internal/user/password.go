@@ -0,0 +1,104 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/mailer"
+	dbutils "github.com/darkphotonKN/seeyoulatte-app/internal/utils/db"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/jmoiron/sqlx"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const PasswordResetTTL = time.Hour
+
+// ForgotPassword emails a reset link. It succeeds for unknown and frozen accounts too,
+// and swallows delivery errors, so the response never reveals whether an email is registered.
+func (s *service) ForgotPassword(ctx context.Context, email string) error {
+	user, err := s.repo.GetByEmail(ctx, email)
+	if err != nil {
+		return fmt.Errorf("getting user: %w", err)
+	}
+	if user == nil || user.IsFrozen {
+		return nil
+	}
+
+	raw, err := newOpaqueToken()
+	if err != nil {
+		return fmt.Errorf("generating reset token: %w", err)
+	}
+
+	now := time.Now()
+	token := &PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(raw),
+		ExpiresAt: now.Add(PasswordResetTTL),
+	}
+
+	err = dbutils.ExecTx(ctx, s.db, func(tx *sqlx.Tx) error {
+		if err := s.repo.InvalidatePasswordResetTokensTx(ctx, tx, user.ID, now); err != nil {
+			return err
+		}
+		return s.repo.CreatePasswordResetTokenTx(ctx, tx, token)
+	})
+	if err != nil {
+		return fmt.Errorf("storing reset token: %w", err)
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", s.appURL, url.QueryEscape(raw))
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your SeeYouLatte password",
+		Body: fmt.Sprintf("Hi %s,\n\nUse this link to choose a new password. It expires in %s and works once.\n\n%s\n\nIf you did not ask for this, you can ignore this email.\n",
+			user.Name, PasswordResetTTL, link),
+	}
+
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		s.logger.Error("failed to send password reset email",
+			slog.String("user_id", user.ID.String()),
+			slog.String("error", err.Error()))
+	}
+
+	return nil
+}
+
+// ResetPassword sets a new password with a reset token. The token is spent and every
+// existing session is revoked in the same transaction, so a stolen session dies with the old password.
+func (s *service) ResetPassword(ctx context.Context, rawToken string, password string) error {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("hashing password: %w", err)
+	}
+
+	var token *PasswordResetToken
+	now := time.Now()
+
+	err = dbutils.ExecTx(ctx, s.db, func(tx *sqlx.Tx) error {
+		token, err = s.repo.GetPasswordResetTokenByHashForUpdateTx(ctx, tx, hashToken(rawToken))
+		if err != nil {
+			return err
+		}
+		if token == nil || token.UsedAt != nil || now.After(token.ExpiresAt) {
+			return errorutils.ErrInvalidResetToken
+		}
+
+		if err := s.repo.UpdatePasswordTx(ctx, tx, token.UserID, string(hashed)); err != nil {
+			return err
+		}
+		if err := s.repo.MarkPasswordResetTokenUsedTx(ctx, tx, token.ID, now); err != nil {
+			return err
+		}
+		return s.revokeAllSessionsTx(ctx, tx, token.UserID, now)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("password reset", slog.String("user_id", token.UserID.String()))
+
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type repository struct {
@@ -53,8 +54,9 @@ func (r *repository) GetByID(ctx context.Context, id uuid.UUID) (*User, error) {
 	query := `
 		SELECT
 			id, email, password_hash, name, bio, location_text,
-			is_frozen, google_id, avatar_url, is_verified,
-			preferred_pickup_instructions, created_at, updated_at, last_login_at
+			is_frozen, is_admin, seller_verified_at, google_id, avatar_url, is_verified,
+			preferred_pickup_instructions, created_at, updated_at, last_login_at, tokens_valid_after,
+			totp_secret, totp_enabled_at, totp_last_step, deleted_at
 		FROM users
 		WHERE id = $1
 	`
@@ -76,8 +78,9 @@ func (r *repository) GetByIDForUpdateTx(ctx context.Context, tx *sqlx.Tx, id uui
 	query := `
 		SELECT
 			id, email, password_hash, name, bio, location_text,
-			is_frozen, google_id, avatar_url, is_verified,
-			preferred_pickup_instructions, created_at, updated_at, last_login_at
+			is_frozen, is_admin, seller_verified_at, google_id, avatar_url, is_verified,
+			preferred_pickup_instructions, created_at, updated_at, last_login_at, tokens_valid_after,
+			totp_secret, totp_enabled_at, totp_last_step, deleted_at
 		FROM users
 		WHERE id = $1
 		FOR UPDATE
@@ -123,8 +126,9 @@ func (r *repository) GetByEmail(ctx context.Context, email string) (*User, error
 	query := `
 		SELECT
 			id, email, password_hash, name, bio, location_text,
-			is_frozen, google_id, avatar_url, is_verified,
-			preferred_pickup_instructions, created_at, updated_at, last_login_at
+			is_frozen, is_admin, seller_verified_at, google_id, avatar_url, is_verified,
+			preferred_pickup_instructions, created_at, updated_at, last_login_at, tokens_valid_after,
+			totp_secret, totp_enabled_at, totp_last_step, deleted_at
 		FROM users
 		WHERE email = $1
 	`
@@ -146,8 +150,9 @@ func (r *repository) GetByGoogleID(ctx context.Context, googleID string) (*User,
 	query := `
 		SELECT
 			id, email, password_hash, name, bio, location_text,
-			is_frozen, google_id, avatar_url, is_verified,
-			preferred_pickup_instructions, created_at, updated_at, last_login_at
+			is_frozen, is_admin, seller_verified_at, google_id, avatar_url, is_verified,
+			preferred_pickup_instructions, created_at, updated_at, last_login_at, tokens_valid_after,
+			totp_secret, totp_enabled_at, totp_last_step, deleted_at
 		FROM users
 		WHERE google_id = $1
 	`
@@ -209,6 +214,69 @@ func (r *repository) Update(ctx context.Context, user *User) error {
 	return nil
 }
 
+func (r *repository) GetAll(ctx context.Context, filter ListUsersFilter) ([]User, error) {
+	var users []User
+	query := `
+		SELECT
+			id, email, password_hash, name, bio, location_text,
+			is_frozen, is_admin, seller_verified_at, google_id, avatar_url, is_verified,
+			preferred_pickup_instructions, created_at, updated_at, last_login_at, tokens_valid_after,
+			totp_secret, totp_enabled_at, totp_last_step, deleted_at
+		FROM users
+		WHERE ($1::boolean IS NULL OR is_frozen = $1)
+			AND ($2 = '' OR email ILIKE '%' || $2 || '%' OR name ILIKE '%' || $2 || '%')
+		ORDER BY created_at DESC
+	`
+
+	err := r.db.SelectContext(ctx, &users, query, filter.IsFrozen, filter.Search)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return users, nil
+}
+
+func (r *repository) SetFrozen(ctx context.Context, id uuid.UUID, frozen bool) error {
+	query := `UPDATE users SET is_frozen = $1, updated_at = NOW() WHERE id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, frozen, id)
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return errorutils.ErrNotFound
+	}
+
+	return nil
+}
+
+// MarkSellerVerified records seller onboarding, keeping the original time if it was already set
+func (r *repository) MarkSellerVerified(ctx context.Context, id uuid.UUID, at time.Time) error {
+	query := `UPDATE users SET seller_verified_at = COALESCE(seller_verified_at, $1), updated_at = NOW() WHERE id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, at, id)
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return errorutils.ErrNotFound
+	}
+
+	return nil
+}
+
 func (r *repository) UpdateLastLogin(ctx context.Context, userID uuid.UUID) error {
 	now := time.Now()
 	query := `UPDATE users SET last_login_at = $1 WHERE id = $2`
@@ -220,3 +288,496 @@ func (r *repository) UpdateLastLogin(ctx context.Context, userID uuid.UUID) erro
 
 	return nil
 }
+
+func (r *repository) CreateRefreshToken(ctx context.Context, token *RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (
+			user_id, family_id, token_hash, expires_at
+		) VALUES (
+			$1, $2, $3, $4
+		) RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		token.UserID,
+		token.FamilyID,
+		token.TokenHash,
+		token.ExpiresAt,
+	).Scan(&token.ID, &token.CreatedAt)
+
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
+
+func (r *repository) CreateRefreshTokenTx(ctx context.Context, tx *sqlx.Tx, token *RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (
+			user_id, family_id, token_hash, expires_at
+		) VALUES (
+			$1, $2, $3, $4
+		) RETURNING id, created_at
+	`
+
+	err := tx.QueryRowContext(
+		ctx,
+		query,
+		token.UserID,
+		token.FamilyID,
+		token.TokenHash,
+		token.ExpiresAt,
+	).Scan(&token.ID, &token.CreatedAt)
+
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
+
+// GetRefreshTokenByHashForUpdateTx locks the token so two concurrent refreshes
+// with the same token cannot both rotate it
+func (r *repository) GetRefreshTokenByHashForUpdateTx(ctx context.Context, tx *sqlx.Tx, tokenHash string) (*RefreshToken, error) {
+	var token RefreshToken
+	query := `
+		SELECT
+			id, user_id, family_id, token_hash, expires_at, revoked_at, replaced_by, created_at
+		FROM refresh_tokens
+		WHERE token_hash = $1
+		FOR UPDATE
+	`
+
+	err := tx.GetContext(ctx, &token, query, tokenHash)
+	if err != nil {
+		dbErr := errorutils.AnalyzeDBErr(err)
+		if dbErr == errorutils.ErrNotFound {
+			return nil, nil
+		}
+		return nil, dbErr
+	}
+
+	return &token, nil
+}
+
+func (r *repository) RevokeRefreshTokenTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, replacedBy *uuid.UUID, at time.Time) error {
+	query := `UPDATE refresh_tokens SET revoked_at = $1, replaced_by = $2 WHERE id = $3`
+
+	_, err := tx.ExecContext(ctx, query, at, replacedBy, id)
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
+
+func (r *repository) RevokeRefreshTokenFamilyTx(ctx context.Context, tx *sqlx.Tx, familyID uuid.UUID, at time.Time) error {
+	query := `UPDATE refresh_tokens SET revoked_at = $1 WHERE family_id = $2 AND revoked_at IS NULL`
+
+	_, err := tx.ExecContext(ctx, query, at, familyID)
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
+
+// RevokeRefreshTokenFamilyByHash ends the session the token belongs to, unknown tokens are ignored
+func (r *repository) RevokeRefreshTokenFamilyByHash(ctx context.Context, tokenHash string, at time.Time) error {
+	query := `
+		UPDATE refresh_tokens SET revoked_at = $1
+		WHERE family_id = (SELECT family_id FROM refresh_tokens WHERE token_hash = $2)
+			AND revoked_at IS NULL
+	`
+
+	_, err := r.db.ExecContext(ctx, query, at, tokenHash)
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
+
+func (r *repository) RevokeRefreshTokensByUserTx(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, at time.Time) error {
+	query := `UPDATE refresh_tokens SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL`
+
+	_, err := tx.ExecContext(ctx, query, at, userID)
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
+
+func (r *repository) SetTokensValidAfterTx(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, at time.Time) error {
+	query := `UPDATE users SET tokens_valid_after = $1, updated_at = NOW() WHERE id = $2`
+
+	result, err := tx.ExecContext(ctx, query, at, userID)
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return errorutils.ErrNotFound
+	}
+
+	return nil
+}
+
+// InvalidatePasswordResetTokensTx retires the user's unused reset tokens, only the newest link works
+func (r *repository) InvalidatePasswordResetTokensTx(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, at time.Time) error {
+	query := `UPDATE password_reset_tokens SET used_at = $1 WHERE user_id = $2 AND used_at IS NULL`
+
+	_, err := tx.ExecContext(ctx, query, at, userID)
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
+
+func (r *repository) CreatePasswordResetTokenTx(ctx context.Context, tx *sqlx.Tx, token *PasswordResetToken) error {
+	query := `
+		INSERT INTO password_reset_tokens (
+			user_id, token_hash, expires_at
+		) VALUES (
+			$1, $2, $3
+		) RETURNING id, created_at
+	`
+
+	err := tx.QueryRowContext(
+		ctx,
+		query,
+		token.UserID,
+		token.TokenHash,
+		token.ExpiresAt,
+	).Scan(&token.ID, &token.CreatedAt)
+
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
+
+func (r *repository) GetPasswordResetTokenByHashForUpdateTx(ctx context.Context, tx *sqlx.Tx, tokenHash string) (*PasswordResetToken, error) {
+	var token PasswordResetToken
+	query := `
+		SELECT
+			id, user_id, token_hash, expires_at, used_at, created_at
+		FROM password_reset_tokens
+		WHERE token_hash = $1
+		FOR UPDATE
+	`
+
+	err := tx.GetContext(ctx, &token, query, tokenHash)
+	if err != nil {
+		dbErr := errorutils.AnalyzeDBErr(err)
+		if dbErr == errorutils.ErrNotFound {
+			return nil, nil
+		}
+		return nil, dbErr
+	}
+
+	return &token, nil
+}
+
+func (r *repository) MarkPasswordResetTokenUsedTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, at time.Time) error {
+	query := `UPDATE password_reset_tokens SET used_at = $1 WHERE id = $2`
+
+	_, err := tx.ExecContext(ctx, query, at, id)
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
+
+func (r *repository) UpdatePasswordTx(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, passwordHash string) error {
+	query := `UPDATE users SET password_hash = $1, updated_at = NOW() WHERE id = $2`
+
+	result, err := tx.ExecContext(ctx, query, passwordHash, userID)
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return errorutils.ErrNotFound
+	}
+
+	return nil
+}
+
+// InvalidateEmailVerificationTokensTx retires the user's unused verification tokens, only the newest link works
+func (r *repository) InvalidateEmailVerificationTokensTx(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, at time.Time) error {
+	query := `UPDATE email_verification_tokens SET used_at = $1 WHERE user_id = $2 AND used_at IS NULL`
+
+	_, err := tx.ExecContext(ctx, query, at, userID)
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
+
+func (r *repository) CreateEmailVerificationTokenTx(ctx context.Context, tx *sqlx.Tx, token *EmailVerificationToken) error {
+	query := `
+		INSERT INTO email_verification_tokens (
+			user_id, token_hash, expires_at
+		) VALUES (
+			$1, $2, $3
+		) RETURNING id, created_at
+	`
+
+	err := tx.QueryRowContext(
+		ctx,
+		query,
+		token.UserID,
+		token.TokenHash,
+		token.ExpiresAt,
+	).Scan(&token.ID, &token.CreatedAt)
+
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
+
+func (r *repository) GetEmailVerificationTokenByHashForUpdateTx(ctx context.Context, tx *sqlx.Tx, tokenHash string) (*EmailVerificationToken, error) {
+	var token EmailVerificationToken
+	query := `
+		SELECT
+			id, user_id, token_hash, expires_at, used_at, created_at
+		FROM email_verification_tokens
+		WHERE token_hash = $1
+		FOR UPDATE
+	`
+
+	err := tx.GetContext(ctx, &token, query, tokenHash)
+	if err != nil {
+		dbErr := errorutils.AnalyzeDBErr(err)
+		if dbErr == errorutils.ErrNotFound {
+			return nil, nil
+		}
+		return nil, dbErr
+	}
+
+	return &token, nil
+}
+
+func (r *repository) MarkEmailVerificationTokenUsedTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, at time.Time) error {
+	query := `UPDATE email_verification_tokens SET used_at = $1 WHERE id = $2`
+
+	_, err := tx.ExecContext(ctx, query, at, id)
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
+
+func (r *repository) MarkEmailVerifiedTx(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID) error {
+	query := `UPDATE users SET is_verified = true, updated_at = NOW() WHERE id = $1`
+
+	result, err := tx.ExecContext(ctx, query, userID)
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return errorutils.ErrNotFound
+	}
+
+	return nil
+}
+
+// SetTOTPSecret stores a pending secret. It replaces an unconfirmed one but never the secret of an enabled account.
+func (r *repository) SetTOTPSecret(ctx context.Context, userID uuid.UUID, secret string) error {
+	query := `
+		UPDATE users
+		SET totp_secret = $1, totp_last_step = NULL, updated_at = NOW()
+		WHERE id = $2 AND totp_enabled_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, secret, userID)
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return errorutils.ErrTwoFactorAlreadyEnabled
+	}
+
+	return nil
+}
+
+func (r *repository) EnableTOTPTx(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, at time.Time, step int64) error {
+	query := `
+		UPDATE users
+		SET totp_enabled_at = $1, totp_last_step = $2, updated_at = NOW()
+		WHERE id = $3 AND totp_secret IS NOT NULL AND totp_enabled_at IS NULL
+	`
+
+	result, err := tx.ExecContext(ctx, query, at, step, userID)
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return errorutils.ErrTwoFactorAlreadyEnabled
+	}
+
+	return nil
+}
+
+func (r *repository) DisableTOTPTx(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID) error {
+	query := `
+		UPDATE users
+		SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = NULL, updated_at = NOW()
+		WHERE id = $1
+	`
+
+	if _, err := tx.ExecContext(ctx, query, userID); err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM totp_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
+
+// AdvanceTOTPStep records an accepted time step. It reports false when the step is not newer
+// than the last accepted one, which means the code was already used.
+func (r *repository) AdvanceTOTPStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	query := `
+		UPDATE users
+		SET totp_last_step = $1
+		WHERE id = $2 AND (totp_last_step IS NULL OR totp_last_step < $1)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, step, userID)
+	if err != nil {
+		return false, errorutils.AnalyzeDBErr(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("checking affected rows: %w", err)
+	}
+
+	return rowsAffected == 1, nil
+}
+
+// ReplaceRecoveryCodesTx drops the user's previous recovery codes, used or not, and stores the new hashes
+func (r *repository) ReplaceRecoveryCodesTx(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, codeHashes []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM totp_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	query := `
+		INSERT INTO totp_recovery_codes (user_id, code_hash)
+		SELECT $1, unnest($2::text[])
+	`
+
+	if _, err := tx.ExecContext(ctx, query, userID, pq.Array(codeHashes)); err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
+
+// UseRecoveryCode marks a matching unused code as used. It reports false when there is none.
+func (r *repository) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string, at time.Time) (bool, error) {
+	query := `
+		UPDATE totp_recovery_codes
+		SET used_at = $1
+		WHERE user_id = $2 AND code_hash = $3 AND used_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, at, userID, codeHash)
+	if err != nil {
+		return false, errorutils.AnalyzeDBErr(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("checking affected rows: %w", err)
+	}
+
+	return rowsAffected == 1, nil
+}
+
+// AnonymizeTx scrubs the personal fields of a deleted account. The row stays, frozen, because
+// orders and ledger entries that must be retained still reference it.
+func (r *repository) AnonymizeTx(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, at time.Time) error {
+	query := `
+		UPDATE users
+		SET
+			email = 'deleted-' || id || '@deleted.invalid',
+			password_hash = NULL,
+			name = 'Deleted user',
+			bio = NULL,
+			location_text = NULL,
+			google_id = NULL,
+			avatar_url = NULL,
+			preferred_pickup_instructions = NULL,
+			totp_secret = NULL,
+			totp_enabled_at = NULL,
+			totp_last_step = NULL,
+			is_frozen = true,
+			deleted_at = $1,
+			updated_at = NOW()
+		WHERE id = $2 AND deleted_at IS NULL
+	`
+
+	result, err := tx.ExecContext(ctx, query, at, userID)
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return errorutils.ErrNotFound
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM totp_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
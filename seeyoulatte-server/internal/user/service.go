@@ -2,14 +2,19 @@ package user
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
-	"net/http"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/darkphotonKN/seeyoulatte-app/internal/googleauth"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/jwtauth"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/mailer"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/principal"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/ratelimit"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
@@ -28,19 +33,81 @@ type Repository interface {
 	GetByGoogleID(ctx context.Context, googleID string) (*User, error)
 	Update(ctx context.Context, user *User) error
 	UpdateLastLogin(ctx context.Context, userID uuid.UUID) error
+	MarkSellerVerified(ctx context.Context, id uuid.UUID, at time.Time) error
+	GetAll(ctx context.Context, filter ListUsersFilter) ([]User, error)
+	SetFrozen(ctx context.Context, id uuid.UUID, frozen bool) error
+	SetTokensValidAfterTx(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, at time.Time) error
+	CreateRefreshToken(ctx context.Context, token *RefreshToken) error
+	CreateRefreshTokenTx(ctx context.Context, tx *sqlx.Tx, token *RefreshToken) error
+	GetRefreshTokenByHashForUpdateTx(ctx context.Context, tx *sqlx.Tx, tokenHash string) (*RefreshToken, error)
+	RevokeRefreshTokenTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, replacedBy *uuid.UUID, at time.Time) error
+	RevokeRefreshTokenFamilyTx(ctx context.Context, tx *sqlx.Tx, familyID uuid.UUID, at time.Time) error
+	RevokeRefreshTokenFamilyByHash(ctx context.Context, tokenHash string, at time.Time) error
+	RevokeRefreshTokensByUserTx(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, at time.Time) error
+	InvalidatePasswordResetTokensTx(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, at time.Time) error
+	CreatePasswordResetTokenTx(ctx context.Context, tx *sqlx.Tx, token *PasswordResetToken) error
+	GetPasswordResetTokenByHashForUpdateTx(ctx context.Context, tx *sqlx.Tx, tokenHash string) (*PasswordResetToken, error)
+	MarkPasswordResetTokenUsedTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, at time.Time) error
+	UpdatePasswordTx(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, passwordHash string) error
+	InvalidateEmailVerificationTokensTx(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, at time.Time) error
+	CreateEmailVerificationTokenTx(ctx context.Context, tx *sqlx.Tx, token *EmailVerificationToken) error
+	GetEmailVerificationTokenByHashForUpdateTx(ctx context.Context, tx *sqlx.Tx, tokenHash string) (*EmailVerificationToken, error)
+	MarkEmailVerificationTokenUsedTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, at time.Time) error
+	MarkEmailVerifiedTx(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID) error
+	SetTOTPSecret(ctx context.Context, userID uuid.UUID, secret string) error
+	EnableTOTPTx(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, at time.Time, step int64) error
+	DisableTOTPTx(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID) error
+	AdvanceTOTPStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error)
+	ReplaceRecoveryCodesTx(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, codeHashes []string) error
+	UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string, at time.Time) (bool, error)
+	AnonymizeTx(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, at time.Time) error
+}
+
+// TokenManager issues JWTs with the currently active signing key and verifies them,
+// the service verifies its own two-factor challenges
+type TokenManager interface {
+	Sign(claims jwt.MapClaims) (string, error)
+	Parse(tokenString string) (jwt.MapClaims, error)
+}
+
+// GoogleVerifier checks Google ID tokens, locally against Google's published keys
+type GoogleVerifier interface {
+	VerifyIDToken(ctx context.Context, idToken string) (*googleauth.Identity, error)
+}
+
+// Limiter tracks failed sign-ins per account in a token bucket
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error)
+	Peek(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error)
+	Reset(ctx context.Context, key string) error
+}
+
+// Mailer delivers account emails such as password reset links
+type Mailer interface {
+	Send(ctx context.Context, msg mailer.Message) error
 }
 
 type service struct {
 	repo         Repository
+	db           *sqlx.DB
 	logger       *slog.Logger
-	jwtSecret    []byte
+	tokens       TokenManager
+	mailer       Mailer
+	google       GoogleVerifier
+	limiter      Limiter
+	appURL       string // Frontend base URL for links in emails
+	apiURL       string // Public base URL of this API, for links handled by the API itself
 	googleConfig *oauth2.Config
 }
 
-func NewService(repo Repository, logger *slog.Logger) *service {
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-in-production"
+func NewService(repo Repository, db *sqlx.DB, logger *slog.Logger, tokens TokenManager, mailer Mailer, googleVerifier GoogleVerifier, limiter Limiter) *service {
+	appURL := os.Getenv("APP_URL")
+	if appURL == "" {
+		appURL = "http://localhost:3000"
+	}
+	apiURL := os.Getenv("API_URL")
+	if apiURL == "" {
+		apiURL = "http://localhost:8080"
 	}
 
 	googleConfig := &oauth2.Config{
@@ -56,8 +123,14 @@ func NewService(repo Repository, logger *slog.Logger) *service {
 
 	return &service{
 		repo:         repo,
+		db:           db,
 		logger:       logger,
-		jwtSecret:    []byte(jwtSecret),
+		tokens:       tokens,
+		mailer:       mailer,
+		google:       googleVerifier,
+		limiter:      limiter,
+		appURL:       strings.TrimRight(appURL, "/"),
+		apiURL:       strings.TrimRight(apiURL, "/"),
 		googleConfig: googleConfig,
 	}
 }
@@ -92,28 +165,39 @@ func (s *service) SignUp(ctx context.Context, req *SignUpRequest) (*AuthResponse
 		return nil, fmt.Errorf("creating user: %w", err)
 	}
 
-	// Generate JWT
-	token, err := s.GenerateJWT(user)
+	// Send the verification link, a failed delivery can be retried with a resend
+	if err := s.sendVerification(ctx, user); err != nil {
+		s.logger.Error("failed to send verification email",
+			slog.String("user_id", user.ID.String()),
+			slog.String("error", err.Error()))
+	}
+
+	// Generate access and refresh tokens
+	resp, err := s.issueTokens(ctx, user)
 	if err != nil {
-		return nil, fmt.Errorf("generating token: %w", err)
+		return nil, err
 	}
 
 	// Update last login
 	_ = s.repo.UpdateLastLogin(ctx, user.ID)
 
-	return &AuthResponse{
-		User:  user,
-		Token: token,
-	}, nil
+	return resp, nil
 }
 
 func (s *service) SignIn(ctx context.Context, req *SignInRequest) (*AuthResponse, error) {
+	// Refuse locked accounts before looking at the password
+	lockKey := signInLockKey(req.Email)
+	if err := s.checkSignInLock(ctx, lockKey); err != nil {
+		return nil, err
+	}
+
 	// Get user by email
 	user, err := s.repo.GetByEmail(ctx, req.Email)
 	if err != nil {
 		return nil, fmt.Errorf("getting user: %w", err)
 	}
 	if user == nil {
+		s.recordSignInFailure(ctx, lockKey)
 		return nil, errors.New("invalid credentials")
 	}
 
@@ -129,27 +213,20 @@ func (s *service) SignIn(ctx context.Context, req *SignInRequest) (*AuthResponse
 
 	err = bcrypt.CompareHashAndPassword([]byte(*user.PasswordHash), []byte(req.Password))
 	if err != nil {
+		s.recordSignInFailure(ctx, lockKey)
 		return nil, errors.New("invalid credentials")
 	}
 
-	// Generate JWT
-	token, err := s.GenerateJWT(user)
-	if err != nil {
-		return nil, fmt.Errorf("generating token: %w", err)
-	}
+	// A correct password forgives earlier failures
+	s.resetSignInFailures(ctx, lockKey)
 
-	// Update last login
-	_ = s.repo.UpdateLastLogin(ctx, user.ID)
-
-	return &AuthResponse{
-		User:  user,
-		Token: token,
-	}, nil
+	// Start the session, or ask for the second factor first
+	return s.startSession(ctx, user)
 }
 
 func (s *service) GoogleAuth(ctx context.Context, idToken string) (*AuthResponse, error) {
 	// Verify the ID token with Google
-	googleUser, err := s.verifyGoogleIDToken(idToken)
+	googleUser, err := s.verifyGoogleIDToken(ctx, idToken)
 	if err != nil {
 		return nil, fmt.Errorf("verifying Google token: %w", err)
 	}
@@ -160,6 +237,14 @@ func (s *service) GoogleAuth(ctx context.Context, idToken string) (*AuthResponse
 		return nil, fmt.Errorf("getting user by Google ID: %w", err)
 	}
 
+	// Google may have verified the address since the account was created
+	if user != nil && !user.IsVerified && googleUser.VerifiedEmail {
+		user.IsVerified = true
+		if err := s.repo.Update(ctx, user); err != nil {
+			return nil, fmt.Errorf("marking Google email verified: %w", err)
+		}
+	}
+
 	// If no user with Google ID, try to find by email
 	if user == nil {
 		user, err = s.repo.GetByEmail(ctx, googleUser.Email)
@@ -167,11 +252,15 @@ func (s *service) GoogleAuth(ctx context.Context, idToken string) (*AuthResponse
 			return nil, fmt.Errorf("getting user by email: %w", err)
 		}
 
-		// If user exists with email, link Google account
+		// If user exists with email, link Google account. Only a verified Google email proves
+		// ownership of the address, otherwise anyone could take over the password account.
 		if user != nil {
+			if !googleUser.VerifiedEmail {
+				return nil, errors.New("google email is not verified")
+			}
 			user.GoogleID = &googleUser.ID
 			user.AvatarURL = &googleUser.Picture
-			user.IsVerified = true // Google accounts are verified
+			user.IsVerified = true
 			if err := s.repo.Update(ctx, user); err != nil {
 				return nil, fmt.Errorf("linking Google account: %w", err)
 			}
@@ -185,7 +274,7 @@ func (s *service) GoogleAuth(ctx context.Context, idToken string) (*AuthResponse
 			Name:       googleUser.Name,
 			GoogleID:   &googleUser.ID,
 			AvatarURL:  &googleUser.Picture,
-			IsVerified: true, // Google accounts are verified
+			IsVerified: googleUser.VerifiedEmail,
 			IsFrozen:   false,
 		}
 
@@ -199,19 +288,8 @@ func (s *service) GoogleAuth(ctx context.Context, idToken string) (*AuthResponse
 		return nil, errors.New("account is frozen")
 	}
 
-	// Generate JWT
-	token, err := s.GenerateJWT(user)
-	if err != nil {
-		return nil, fmt.Errorf("generating token: %w", err)
-	}
-
-	// Update last login
-	_ = s.repo.UpdateLastLogin(ctx, user.ID)
-
-	return &AuthResponse{
-		User:  user,
-		Token: token,
-	}, nil
+	// Start the session, or ask for the second factor first
+	return s.startSession(ctx, user)
 }
 
 func (s *service) GetByID(ctx context.Context, id uuid.UUID) (*User, error) {
@@ -226,55 +304,201 @@ func (s *service) VerifyUserNotFrozen(ctx context.Context, id uuid.UUID) error {
 	return s.repo.GetByIDNotIsFrozen(ctx, id)
 }
 
-func (s *service) GenerateJWT(user *User) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id": user.ID.String(),
-		"email":   user.Email,
-		"name":    user.Name,
-		"exp":     time.Now().Add(time.Hour * 24 * 7).Unix(), // 7 days
-		"iat":     time.Now().Unix(),
+// UpdateProfile applies the fields present in the request, trimming whitespace
+func (s *service) UpdateProfile(ctx context.Context, id uuid.UUID, req *UpdateProfileRequest) (*User, error) {
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errorutils.ErrNotFound
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, fmt.Errorf("%w: name cannot be blank", errorutils.ErrInvalidInput)
+		}
+		user.Name = name
+	}
+	if req.Bio != nil {
+		user.Bio = optionalText(*req.Bio)
+	}
+	if req.LocationText != nil {
+		user.LocationText = optionalText(*req.LocationText)
+	}
+	if req.AvatarURL != nil {
+		user.AvatarURL = optionalText(*req.AvatarURL)
+	}
+	if req.PreferredPickupInstructions != nil {
+		user.PreferredPickupInstructions = optionalText(*req.PreferredPickupInstructions)
+	}
+
+	if err := s.repo.Update(ctx, user); err != nil {
+		return nil, fmt.Errorf("updating profile: %w", err)
+	}
+
+	return s.repo.GetByID(ctx, id)
+}
+
+// optionalText maps blank input to NULL
+func optionalText(v string) *string {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return nil
 	}
+	return &v
+}
+
+// LoadPrincipal reads the user's flags from the database rather than the token,
+// so freezing or revoking admin access takes effect on the next request
+func (s *service) LoadPrincipal(ctx context.Context, id uuid.UUID, method principal.Method) (*principal.Principal, error) {
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errorutils.ErrNotFound
+	}
+	return newPrincipal(user, method), nil
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(s.jwtSecret)
+func newPrincipal(user *User, method principal.Method) *principal.Principal {
+	return &principal.Principal{
+		UserID:           user.ID,
+		IsAdmin:          user.IsAdmin,
+		SellerVerified:   user.SellerVerifiedAt != nil,
+		EmailVerified:    user.IsVerified,
+		TwoFactorEnabled: user.TOTPEnabledAt != nil,
+		IsFrozen:         user.IsFrozen,
+		Method:           method,
+	}
 }
 
-func (s *service) verifyGoogleIDToken(idToken string) (*GoogleUserInfo, error) {
-	// Verify token with Google's tokeninfo endpoint
-	resp, err := http.Get(fmt.Sprintf("https://oauth2.googleapis.com/tokeninfo?id_token=%s", idToken))
+// OnboardSeller completes seller onboarding, after which the user can create listings.
+// It requires a verified email. Onboarding twice is harmless and keeps the original verification time.
+func (s *service) OnboardSeller(ctx context.Context, id uuid.UUID) (*User, error) {
+	user, err := s.repo.GetByID(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("verifying token with Google: %w", err)
+		return nil, err
+	}
+	if user == nil {
+		return nil, errorutils.ErrNotFound
+	}
+	if user.IsFrozen {
+		return nil, errorutils.ErrUserIsFrozen
+	}
+	if !user.IsVerified {
+		return nil, errorutils.ErrEmailNotVerified
+	}
+	if user.SellerVerifiedAt != nil {
+		return user, nil
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, errors.New("invalid Google token")
+	if err := s.repo.MarkSellerVerified(ctx, id, time.Now()); err != nil {
+		return nil, fmt.Errorf("marking seller verified: %w", err)
 	}
 
-	var tokenInfo struct {
-		Email         string `json:"email"`
-		EmailVerified string `json:"email_verified"`
-		Name          string `json:"name"`
-		Picture       string `json:"picture"`
-		Sub           string `json:"sub"`
-		Aud           string `json:"aud"`
+	s.logger.Info("seller onboarded", slog.String("user_id", id.String()))
+
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *service) GetAll(ctx context.Context, filter ListUsersFilter) ([]User, error) {
+	users, err := s.repo.GetAll(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("getting users: %w", err)
 	}
+	return users, nil
+}
 
-	if err := json.NewDecoder(resp.Body).Decode(&tokenInfo); err != nil {
-		return nil, fmt.Errorf("decoding token info: %w", err)
+// Freeze blocks a user from buying, selling and signing in. Admins cannot freeze themselves,
+// so there is always someone left to undo it.
+func (s *service) Freeze(ctx context.Context, id uuid.UUID, adminID uuid.UUID) (*User, error) {
+	if id == adminID {
+		return nil, fmt.Errorf("%w: admins cannot freeze their own account", errorutils.ErrInvalidInput)
 	}
+	return s.setFrozen(ctx, id, adminID, true)
+}
 
-	// Verify the audience matches our client ID
-	expectedClientID := os.Getenv("GOOGLE_CLIENT_ID")
-	if expectedClientID != "" && tokenInfo.Aud != expectedClientID {
-		return nil, errors.New("invalid token audience")
+func (s *service) Unfreeze(ctx context.Context, id uuid.UUID, adminID uuid.UUID) (*User, error) {
+	return s.setFrozen(ctx, id, adminID, false)
+}
+
+func (s *service) setFrozen(ctx context.Context, id uuid.UUID, adminID uuid.UUID, frozen bool) (*User, error) {
+	// Deleted accounts stay frozen, unfreezing would let an anonymized seller's listings back into the catalogue
+	if !frozen {
+		user, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if user != nil && user.DeletedAt != nil {
+			return nil, fmt.Errorf("%w: deleted accounts cannot be unfrozen", errorutils.ErrInvalidInput)
+		}
+	}
+
+	if err := s.repo.SetFrozen(ctx, id, frozen); err != nil {
+		return nil, err
+	}
+
+	// A frozen user's existing sessions end now rather than when their tokens expire
+	if frozen {
+		if err := s.RevokeAllSessions(ctx, id); err != nil {
+			return nil, fmt.Errorf("revoking sessions: %w", err)
+		}
+	}
+
+	s.logger.Info("user freeze changed",
+		slog.String("user_id", id.String()),
+		slog.Bool("is_frozen", frozen),
+		slog.String("admin_id", adminID.String()))
+
+	return s.repo.GetByID(ctx, id)
+}
+
+// DeleteAccountTx anonymizes the user's personal data and ends every session. The caller
+// checks that nothing still needs the account and scrubs the data owned by other domains.
+func (s *service) DeleteAccountTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, at time.Time) error {
+	if err := s.repo.AnonymizeTx(ctx, tx, id, at); err != nil {
+		return err
+	}
+	if err := s.repo.InvalidatePasswordResetTokensTx(ctx, tx, id, at); err != nil {
+		return err
+	}
+	if err := s.repo.InvalidateEmailVerificationTokensTx(ctx, tx, id, at); err != nil {
+		return err
+	}
+	return s.revokeAllSessionsTx(ctx, tx, id, at)
+}
+
+// GenerateJWT issues a short-lived access token. iat carries microseconds so a token
+// issued right after a logout-all is not mistaken for one issued before it.
+func (s *service) GenerateJWT(user *User) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"user_id": user.ID.String(),
+		"email":   user.Email,
+		"name":    user.Name,
+		"exp":     now.Add(AccessTokenTTL).Unix(),
+		"iat":     float64(now.UnixMicro()) / 1e6,
+
+		jwtauth.ClaimTokenUse: jwtauth.UseAccess,
+	}
+
+	return s.tokens.Sign(claims)
+}
+
+func (s *service) verifyGoogleIDToken(ctx context.Context, idToken string) (*GoogleUserInfo, error) {
+	identity, err := s.google.VerifyIDToken(ctx, idToken)
+	if err != nil {
+		return nil, err
 	}
 
 	return &GoogleUserInfo{
-		ID:            tokenInfo.Sub,
-		Email:         tokenInfo.Email,
-		VerifiedEmail: tokenInfo.EmailVerified == "true",
-		Name:          tokenInfo.Name,
-		Picture:       tokenInfo.Picture,
+		ID:            identity.Subject,
+		Email:         identity.Email,
+		VerifiedEmail: identity.EmailVerified,
+		Name:          identity.Name,
+		Picture:       identity.Picture,
 	}, nil
 }
@@ -0,0 +1,189 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/principal"
+	dbutils "github.com/darkphotonKN/seeyoulatte-app/internal/utils/db"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// issueTokens starts a new session: an access token plus the first refresh token of a new family
+func (s *service) issueTokens(ctx context.Context, user *User) (*AuthResponse, error) {
+	raw, refresh, err := newRefreshToken(user.ID, uuid.New())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateRefreshToken(ctx, refresh); err != nil {
+		return nil, fmt.Errorf("storing refresh token: %w", err)
+	}
+
+	return s.authResponse(user, raw)
+}
+
+// Refresh exchanges a refresh token for a new access token and a new refresh token.
+// The presented token is revoked, and presenting it again revokes its whole family,
+// which logs out both the attacker and the legitimate client.
+func (s *service) Refresh(ctx context.Context, rawToken string) (*AuthResponse, error) {
+	var (
+		user   *User
+		raw    string
+		reused *RefreshToken
+	)
+	now := time.Now()
+
+	err := dbutils.ExecTx(ctx, s.db, func(tx *sqlx.Tx) error {
+		current, err := s.repo.GetRefreshTokenByHashForUpdateTx(ctx, tx, hashToken(rawToken))
+		if err != nil {
+			return err
+		}
+		if current == nil || now.After(current.ExpiresAt) {
+			return errorutils.ErrInvalidRefreshToken
+		}
+
+		// Revoking the family has to commit, so report the reuse after the transaction
+		if current.RevokedAt != nil {
+			reused = current
+			return s.repo.RevokeRefreshTokenFamilyTx(ctx, tx, current.FamilyID, now)
+		}
+
+		user, err = s.repo.GetByID(ctx, current.UserID)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return errorutils.ErrInvalidRefreshToken
+		}
+		if user.IsFrozen {
+			return errorutils.ErrUserIsFrozen
+		}
+
+		var next *RefreshToken
+		raw, next, err = newRefreshToken(user.ID, current.FamilyID)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.CreateRefreshTokenTx(ctx, tx, next); err != nil {
+			return fmt.Errorf("storing refresh token: %w", err)
+		}
+
+		return s.repo.RevokeRefreshTokenTx(ctx, tx, current.ID, &next.ID, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if reused != nil {
+		s.logger.Warn("refresh token reused, session revoked",
+			slog.String("user_id", reused.UserID.String()),
+			slog.String("family_id", reused.FamilyID.String()))
+		return nil, errorutils.ErrRefreshTokenReused
+	}
+
+	return s.authResponse(user, raw)
+}
+
+// Logout ends the session the refresh token belongs to. Unknown tokens are ignored
+// so the endpoint does not reveal which tokens exist.
+func (s *service) Logout(ctx context.Context, rawToken string) error {
+	return s.repo.RevokeRefreshTokenFamilyByHash(ctx, hashToken(rawToken), time.Now())
+}
+
+// RevokeAllSessions revokes every refresh token of the user and rejects every access token
+// issued until now, signing the user out everywhere
+func (s *service) RevokeAllSessions(ctx context.Context, userID uuid.UUID) error {
+	now := time.Now()
+
+	err := dbutils.ExecTx(ctx, s.db, func(tx *sqlx.Tx) error {
+		return s.revokeAllSessionsTx(ctx, tx, userID, now)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("all sessions revoked", slog.String("user_id", userID.String()))
+
+	return nil
+}
+
+func (s *service) revokeAllSessionsTx(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, now time.Time) error {
+	if err := s.repo.SetTokensValidAfterTx(ctx, tx, userID, now); err != nil {
+		return err
+	}
+	return s.repo.RevokeRefreshTokensByUserTx(ctx, tx, userID, now)
+}
+
+// AuthenticateToken rejects access tokens issued before the user's sessions were last revoked,
+// and otherwise returns the principal the request acts as
+func (s *service) AuthenticateToken(ctx context.Context, userID uuid.UUID, issuedAt time.Time) (*principal.Principal, error) {
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errorutils.ErrNotFound
+	}
+	if user.TokensValidAfter != nil && issuedAt.Before(*user.TokensValidAfter) {
+		return nil, errorutils.ErrTokenRevoked
+	}
+	return newPrincipal(user, principal.MethodJWT), nil
+}
+
+func (s *service) authResponse(user *User, refreshToken string) (*AuthResponse, error) {
+	token, err := s.GenerateJWT(user)
+	if err != nil {
+		return nil, fmt.Errorf("generating token: %w", err)
+	}
+
+	expiresAt := time.Now().Add(AccessTokenTTL)
+	return &AuthResponse{
+		User:           user,
+		Token:          token,
+		TokenExpiresAt: &expiresAt,
+		RefreshToken:   refreshToken,
+	}, nil
+}
+
+// newRefreshToken returns the raw token for the client and the row to store, which only holds its hash
+func newRefreshToken(userID uuid.UUID, familyID uuid.UUID) (string, *RefreshToken, error) {
+	raw, err := newOpaqueToken()
+	if err != nil {
+		return "", nil, fmt.Errorf("generating refresh token: %w", err)
+	}
+
+	return raw, &RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(raw),
+		ExpiresAt: time.Now().Add(RefreshTokenTTL),
+	}, nil
+}
+
+// newOpaqueToken returns 256 random bits, URL-safe so it can go in links
+func newOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken is how opaque tokens are stored, a leaked table cannot be replayed
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
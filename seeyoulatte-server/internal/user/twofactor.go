@@ -0,0 +1,323 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/jwtauth"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/totp"
+	dbutils "github.com/darkphotonKN/seeyoulatte-app/internal/utils/db"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// TwoFactorChallengeTTL is how long a password or Google sign-in waits for the second factor
+	TwoFactorChallengeTTL = 5 * time.Minute
+
+	TOTPIssuer        = "SeeYouLatte"
+	RecoveryCodeCount = 10
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// startSession issues tokens, unless the account has two-factor authentication enabled,
+// in which case the caller gets a challenge to exchange together with a code
+func (s *service) startSession(ctx context.Context, user *User) (*AuthResponse, error) {
+	if user.TOTPEnabledAt != nil {
+		return s.twoFactorChallenge(user)
+	}
+
+	resp, err := s.issueTokens(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update last login
+	_ = s.repo.UpdateLastLogin(ctx, user.ID)
+
+	return resp, nil
+}
+
+// twoFactorChallenge is a short-lived JWT that proves the first factor. Its token_use keeps
+// it from being accepted as an access token.
+func (s *service) twoFactorChallenge(user *User) (*AuthResponse, error) {
+	now := time.Now()
+	expiresAt := now.Add(TwoFactorChallengeTTL)
+	claims := jwt.MapClaims{
+		"user_id": user.ID.String(),
+		"exp":     expiresAt.Unix(),
+		"iat":     now.Unix(),
+
+		jwtauth.ClaimTokenUse: jwtauth.UseTwoFactorChallenge,
+	}
+
+	token, err := s.tokens.Sign(claims)
+	if err != nil {
+		return nil, fmt.Errorf("generating challenge token: %w", err)
+	}
+
+	return &AuthResponse{
+		TwoFactorRequired:  true,
+		ChallengeToken:     token,
+		ChallengeExpiresAt: &expiresAt,
+	}, nil
+}
+
+// VerifyTwoFactor completes a sign-in by exchanging the challenge and a TOTP or recovery code
+// for an access and refresh token
+func (s *service) VerifyTwoFactor(ctx context.Context, req *TwoFactorVerifyRequest) (*AuthResponse, error) {
+	claims, err := s.tokens.Parse(req.ChallengeToken)
+	if err != nil {
+		return nil, errorutils.ErrInvalidChallenge
+	}
+	if use, _ := claims[jwtauth.ClaimTokenUse].(string); use != jwtauth.UseTwoFactorChallenge {
+		return nil, errorutils.ErrInvalidChallenge
+	}
+	userIDStr, _ := claims["user_id"].(string)
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return nil, errorutils.ErrInvalidChallenge
+	}
+
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("getting user: %w", err)
+	}
+	if user == nil || user.TOTPEnabledAt == nil || user.TOTPSecret == nil {
+		return nil, errorutils.ErrInvalidChallenge
+	}
+	if user.IsFrozen {
+		return nil, errorutils.ErrUserIsFrozen
+	}
+
+	if err := s.checkSecondFactor(ctx, user, req.Code, true); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.issueTokens(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update last login
+	_ = s.repo.UpdateLastLogin(ctx, user.ID)
+
+	return resp, nil
+}
+
+// EnrollTwoFactor generates a new secret for the user to add to an authenticator app.
+// It is not enforced until confirmed, enrolling again before that replaces the secret.
+func (s *service) EnrollTwoFactor(ctx context.Context, userID uuid.UUID) (*TwoFactorEnrollment, error) {
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errorutils.ErrNotFound
+	}
+	if user.TOTPEnabledAt != nil {
+		return nil, errorutils.ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetTOTPSecret(ctx, userID, secret); err != nil {
+		return nil, err
+	}
+
+	return &TwoFactorEnrollment{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(TOTPIssuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTwoFactor enables two-factor authentication once the user proves the authenticator
+// app produces valid codes, and returns the recovery codes
+func (s *service) ConfirmTwoFactor(ctx context.Context, userID uuid.UUID, code string) (*RecoveryCodesResponse, error) {
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errorutils.ErrNotFound
+	}
+	if user.TOTPEnabledAt != nil {
+		return nil, errorutils.ErrTwoFactorAlreadyEnabled
+	}
+	if user.TOTPSecret == nil {
+		return nil, errorutils.ErrTwoFactorNotEnrolled
+	}
+
+	lockKey := twoFactorLockKey(userID)
+	if err := s.checkSignInLock(ctx, lockKey); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	step, ok := totp.Validate(*user.TOTPSecret, code, now)
+	if !ok {
+		s.recordSignInFailure(ctx, lockKey)
+		return nil, errorutils.ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = dbutils.ExecTx(ctx, s.db, func(tx *sqlx.Tx) error {
+		if err := s.repo.EnableTOTPTx(ctx, tx, userID, now, step); err != nil {
+			return err
+		}
+		return s.repo.ReplaceRecoveryCodesTx(ctx, tx, userID, hashes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.resetSignInFailures(ctx, lockKey)
+	s.logger.Info("two-factor authentication enabled", slog.String("user_id", userID.String()))
+
+	return &RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// RegenerateRecoveryCodes replaces every recovery code. It takes a code from the authenticator
+// app, a recovery code cannot be used to mint new ones.
+func (s *service) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) (*RecoveryCodesResponse, error) {
+	user, err := s.enabledTwoFactorUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkSecondFactor(ctx, user, code, false); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = dbutils.ExecTx(ctx, s.db, func(tx *sqlx.Tx) error {
+		return s.repo.ReplaceRecoveryCodesTx(ctx, tx, userID, hashes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("recovery codes regenerated", slog.String("user_id", userID.String()))
+
+	return &RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// DisableTwoFactor turns two-factor authentication off after checking a code. Admins cannot,
+// their access requires it.
+func (s *service) DisableTwoFactor(ctx context.Context, userID uuid.UUID, code string) error {
+	user, err := s.enabledTwoFactorUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.IsAdmin {
+		return errorutils.ErrTwoFactorRequired
+	}
+
+	if err := s.checkSecondFactor(ctx, user, code, true); err != nil {
+		return err
+	}
+
+	err = dbutils.ExecTx(ctx, s.db, func(tx *sqlx.Tx) error {
+		return s.repo.DisableTOTPTx(ctx, tx, userID)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("two-factor authentication disabled", slog.String("user_id", userID.String()))
+
+	return nil
+}
+
+func (s *service) enabledTwoFactorUser(ctx context.Context, userID uuid.UUID) (*User, error) {
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errorutils.ErrNotFound
+	}
+	if user.TOTPEnabledAt == nil || user.TOTPSecret == nil {
+		return nil, errorutils.ErrTwoFactorNotEnrolled
+	}
+	return user, nil
+}
+
+// checkSecondFactor accepts a current TOTP code, or an unused recovery code when allowRecovery
+// is set. Failures share the account lockout so codes cannot be guessed through any endpoint.
+func (s *service) checkSecondFactor(ctx context.Context, user *User, code string, allowRecovery bool) error {
+	lockKey := twoFactorLockKey(user.ID)
+	if err := s.checkSignInLock(ctx, lockKey); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	ok := false
+	if step, valid := totp.Validate(*user.TOTPSecret, code, now); valid {
+		// A code is accepted once, even though it stays valid for the rest of its step
+		advanced, err := s.repo.AdvanceTOTPStep(ctx, user.ID, step)
+		if err != nil {
+			return fmt.Errorf("recording code use: %w", err)
+		}
+		ok = advanced
+	} else if allowRecovery {
+		used, err := s.repo.UseRecoveryCode(ctx, user.ID, hashToken(normalizeRecoveryCode(code)), now)
+		if err != nil {
+			return fmt.Errorf("using recovery code: %w", err)
+		}
+		if used {
+			s.logger.Info("recovery code used", slog.String("user_id", user.ID.String()))
+		}
+		ok = used
+	}
+
+	if !ok {
+		s.recordSignInFailure(ctx, lockKey)
+		return errorutils.ErrInvalidTwoFactorCode
+	}
+
+	s.resetSignInFailures(ctx, lockKey)
+	return nil
+}
+
+// newRecoveryCodes returns the codes to show the user, formatted "xxxxx-xxxxx", and the hashes to store
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, RecoveryCodeCount)
+	hashes := make([]string, RecoveryCodeCount)
+
+	for i := range codes {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, fmt.Errorf("generating recovery code: %w", err)
+		}
+		raw := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))[:10]
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = hashToken(raw)
+	}
+
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode accepts codes typed with or without the dash, in any case
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
@@ -0,0 +1,84 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/ratelimit"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/totp"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/google/uuid"
+)
+
+// fakeRepository keeps totp_last_step the way AdvanceTOTPStep's conditional update does.
+// Methods checkSecondFactor does not use panic through the nil embedded interface.
+type fakeRepository struct {
+	Repository
+	lastStep *int64
+}
+
+func (r *fakeRepository) AdvanceTOTPStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	if r.lastStep != nil && *r.lastStep >= step {
+		return false, nil
+	}
+	r.lastStep = &step
+	return true, nil
+}
+
+func TestCheckSecondFactorRefusesReplayedCodes(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret() error = %v", err)
+	}
+	now := totp.Step(time.Now())
+	codeAt := func(step int64) string {
+		code, err := totp.Code(secret, step)
+		if err != nil {
+			t.Fatalf("Code() error = %v", err)
+		}
+		return code
+	}
+
+	tests := []struct {
+		name     string
+		lastStep *int64
+		code     string
+		wantErr  error
+	}{
+		{name: "first code", code: codeAt(now)},
+		{name: "code after the last accepted step", lastStep: ptr(now - 1), code: codeAt(now)},
+		{name: "code already used", lastStep: ptr(now), code: codeAt(now), wantErr: errorutils.ErrInvalidTwoFactorCode},
+		{name: "earlier code inside the window", lastStep: ptr(now), code: codeAt(now - 1), wantErr: errorutils.ErrInvalidTwoFactorCode},
+		{name: "wrong code", code: "000000", wantErr: errorutils.ErrInvalidTwoFactorCode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.code == "000000" && tt.code == codeAt(now) {
+				t.Skip("the random secret produced 000000")
+			}
+
+			repo := &fakeRepository{lastStep: tt.lastStep}
+			s := &service{repo: repo, logger: slog.New(slog.NewTextHandler(io.Discard, nil)), limiter: ratelimit.NewMemoryLimiter()}
+			user := &User{ID: uuid.New(), TOTPSecret: &secret}
+
+			err := s.checkSecondFactor(context.Background(), user, tt.code, false)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("checkSecondFactor() error = %v, want %v", err, tt.wantErr)
+			}
+
+			// the same code a second time is a replay however the first attempt went
+			if err := s.checkSecondFactor(context.Background(), user, tt.code, false); !errors.Is(err, errorutils.ErrInvalidTwoFactorCode) {
+				t.Fatalf("checkSecondFactor() replay error = %v, want %v", err, errorutils.ErrInvalidTwoFactorCode)
+			}
+		})
+	}
+}
+
+func ptr(step int64) *int64 {
+	return &step
+}
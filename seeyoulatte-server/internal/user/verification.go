@@ -0,0 +1,101 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/mailer"
+	dbutils "github.com/darkphotonKN/seeyoulatte-app/internal/utils/db"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const EmailVerificationTTL = 24 * time.Hour
+
+// sendVerification emails a fresh verification link, retiring any earlier one
+func (s *service) sendVerification(ctx context.Context, user *User) error {
+	raw, err := newOpaqueToken()
+	if err != nil {
+		return fmt.Errorf("generating verification token: %w", err)
+	}
+
+	now := time.Now()
+	token := &EmailVerificationToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(raw),
+		ExpiresAt: now.Add(EmailVerificationTTL),
+	}
+
+	err = dbutils.ExecTx(ctx, s.db, func(tx *sqlx.Tx) error {
+		if err := s.repo.InvalidateEmailVerificationTokensTx(ctx, tx, user.ID, now); err != nil {
+			return err
+		}
+		return s.repo.CreateEmailVerificationTokenTx(ctx, tx, token)
+	})
+	if err != nil {
+		return fmt.Errorf("storing verification token: %w", err)
+	}
+
+	link := fmt.Sprintf("%s/api/auth/verify?token=%s", s.apiURL, url.QueryEscape(raw))
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your SeeYouLatte email",
+		Body: fmt.Sprintf("Hi %s,\n\nConfirm your email address to start buying and selling. The link expires in %s.\n\n%s\n",
+			user.Name, EmailVerificationTTL, link),
+	}
+
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		return fmt.Errorf("sending verification email: %w", err)
+	}
+
+	return nil
+}
+
+// ResendVerification sends a new link to a signed-in user whose email is not verified yet
+func (s *service) ResendVerification(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errorutils.ErrNotFound
+	}
+	if user.IsVerified {
+		return nil
+	}
+
+	return s.sendVerification(ctx, user)
+}
+
+// VerifyEmail redeems a verification token and marks the owner's email verified
+func (s *service) VerifyEmail(ctx context.Context, rawToken string) error {
+	var token *EmailVerificationToken
+	now := time.Now()
+
+	err := dbutils.ExecTx(ctx, s.db, func(tx *sqlx.Tx) error {
+		var err error
+		token, err = s.repo.GetEmailVerificationTokenByHashForUpdateTx(ctx, tx, hashToken(rawToken))
+		if err != nil {
+			return err
+		}
+		if token == nil || token.UsedAt != nil || now.After(token.ExpiresAt) {
+			return errorutils.ErrInvalidVerifyToken
+		}
+
+		if err := s.repo.MarkEmailVerifiedTx(ctx, tx, token.UserID); err != nil {
+			return err
+		}
+		return s.repo.MarkEmailVerificationTokenUsedTx(ctx, tx, token.ID, now)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("email verified", slog.String("user_id", token.UserID.String()))
+
+	return nil
+}
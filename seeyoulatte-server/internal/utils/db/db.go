@@ -35,7 +35,7 @@ func ExecTx(ctx context.Context, db *sqlx.DB, fn func(tx *sqlx.Tx) error) (err e
 		}
 
 		if err != nil {
-			fmt.Printf("Error during transaction, rolling back: Error: %w\n", err)
+			fmt.Printf("Error during transaction, rolling back: Error: %v\n", err)
 			tx.Rollback()
 		}
 	}()
@@ -57,3 +57,26 @@ func ExecTx(ctx context.Context, db *sqlx.DB, fn func(tx *sqlx.Tx) error) (err e
 
 	return nil
 }
+
+/**
+* Runs fn under a savepoint of an open transaction. When fn fails only its writes are rolled
+* back, so the caller can still record the failure in the same transaction.
+**/
+func ExecSavepoint(ctx context.Context, tx *sqlx.Tx, name string, fn func() error) error {
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("creating savepoint %s: %w", name, err)
+	}
+
+	if err := fn(); err != nil {
+		if _, rollbackErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); rollbackErr != nil {
+			return fmt.Errorf("rolling back to savepoint %s after %v: %w", name, err, rollbackErr)
+		}
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("releasing savepoint %s: %w", name, err)
+	}
+
+	return nil
+}
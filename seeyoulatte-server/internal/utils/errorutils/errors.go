@@ -14,9 +14,63 @@ var (
 	ErrForbidden           = errors.New("You do not have permission to access this resource.")
 	ErrUnauthorized        = errors.New("Incorrect credentials entered during when attempting to authenticate.")
 
+	// auth
+	ErrInvalidRefreshToken = errors.New("Refresh token is invalid, expired or revoked.")
+	ErrRefreshTokenReused  = errors.New("Refresh token was already used, the session has been revoked.")
+	ErrTokenRevoked        = errors.New("Token was issued before the user's sessions were revoked.")
+	ErrInvalidResetToken   = errors.New("Password reset token is invalid, expired or already used.")
+	ErrInvalidVerifyToken  = errors.New("Email verification token is invalid, expired or already used.")
+	ErrAccountLocked       = errors.New("Too many failed sign-in attempts, the account is temporarily locked.")
+
+	// two-factor
+	ErrInvalidTwoFactorCode    = errors.New("Two-factor code is invalid or was already used.")
+	ErrInvalidChallenge        = errors.New("Two-factor challenge is invalid or expired.")
+	ErrTwoFactorAlreadyEnabled = errors.New("Two-factor authentication is already enabled.")
+	ErrTwoFactorNotEnrolled    = errors.New("Two-factor enrollment has not been started.")
+	ErrTwoFactorRequired       = errors.New("Two-factor authentication is required for this account.")
+
+	// api key
+	ErrInvalidAPIKey     = errors.New("API key is invalid or revoked.")
+	ErrInsufficientScope = errors.New("API key does not have the scope this request needs.")
+
 	// user
-	ErrUserIsFrozen   = errors.New("User account is frozen.")
-	ErrBuyerIsFrozen  = errors.New("Buyer's account is frozen.")
-	ErrSellerIsFrozen = errors.New("Seller's account is frozen.")
-)
+	ErrUserIsFrozen           = errors.New("User account is frozen.")
+	ErrBuyerIsFrozen          = errors.New("Buyer's account is frozen.")
+	ErrSellerIsFrozen         = errors.New("Seller's account is frozen.")
+	ErrSellerNotVerified      = errors.New("Seller onboarding has not been completed.")
+	ErrEmailNotVerified       = errors.New("Email address has not been verified.")
+	ErrAccountDeletionBlocked = errors.New("Account cannot be deleted yet.")
+
+	// order
+	ErrInvalidStateTransition = errors.New("Order cannot move to the requested state.")
+	ErrTipNotAllowed          = errors.New("Tips can only be added to fulfilled experience orders.")
+	ErrTipAlreadyAdded        = errors.New("A tip has already been added to this order.")
+
+	// dispute
+	ErrDisputeNotOpen = errors.New("Dispute has already been resolved.")
 
+	// promotion
+	ErrPromoCodeInvalid       = errors.New("Promo code is not valid.")
+	ErrPromoCodeNotApplicable = errors.New("Promo code does not apply to this order.")
+	ErrPromoCodeExhausted     = errors.New("Promo code has reached its redemption limit.")
+
+	// money
+	ErrUnsupportedCurrency = errors.New("Currency is not supported.")
+	ErrInvalidAmount       = errors.New("Amount is not valid for its currency.")
+	ErrCurrencyMismatch    = errors.New("Amounts are in different currencies.")
+
+	// ledger
+	ErrInsufficientEscrow = errors.New("Entry would take the order's escrow balance below zero.")
+	ErrDuplicatePayout    = errors.New("Order has already been paid out.")
+
+	// wallet
+	ErrInsufficientWalletBalance = errors.New("Wallet balance is too low for this payment.")
+
+	// payment
+	ErrPaymentDeclined           = errors.New("Payment was declined by the payment provider.")
+	ErrPaymentGatewayUnavailable = errors.New("Payment provider is unavailable.")
+	ErrPaymentMismatch           = errors.New("Payment does not match the order it was made for.")
+
+	// webhook
+	ErrInvalidSignature = errors.New("Webhook signature is missing, invalid or expired.")
+)
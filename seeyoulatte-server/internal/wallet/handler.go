@@ -0,0 +1,94 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/money"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/principal"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Service interface {
+	GetBalances(ctx context.Context, userID uuid.UUID) ([]Balance, error)
+	GetStatement(ctx context.Context, userID uuid.UUID, currency money.Currency) (*Statement, error)
+}
+
+type Handler struct {
+	service Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service Service, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// GetWallets - GET /api/wallet (requires auth)
+func (h *Handler) GetWallets(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	balances, err := h.service.GetBalances(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.Error("failed to get wallet balances",
+			slog.String("error", err.Error()),
+			slog.String("user_id", userID.String()))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get wallets"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"wallets": balances,
+		"count":   len(balances),
+	})
+}
+
+// GetStatement - GET /api/wallet/:currency/statement (requires auth)
+func (h *Handler) GetStatement(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	currency, err := money.ParseCurrency(strings.ToUpper(c.Param("currency")))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	statement, err := h.service.GetStatement(c.Request.Context(), userID, currency)
+	if err != nil {
+		if errors.Is(err, errorutils.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Wallet not found"})
+			return
+		}
+		h.logger.Error("failed to get wallet statement",
+			slog.String("error", err.Error()),
+			slog.String("user_id", userID.String()),
+			slog.String("currency", string(currency)))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get wallet statement"})
+		return
+	}
+
+	c.JSON(http.StatusOK, statement)
+}
+
+// userID extracts the authenticated user ID, writing the error response if it is missing
+func (h *Handler) userID(c *gin.Context) (uuid.UUID, bool) {
+	p, ok := principal.FromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return uuid.Nil, false
+	}
+	return p.UserID, true
+}
@@ -0,0 +1,59 @@
+package wallet
+
+import (
+	"time"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/ledger"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/money"
+	"github.com/google/uuid"
+)
+
+// Wallet holds a buyer's store credit in one currency
+// It has no balance column, the balance is always summed from ledger entries carrying its id
+type Wallet struct {
+	ID        uuid.UUID      `db:"id" json:"id"`
+	UserID    uuid.UUID      `db:"user_id" json:"user_id"`
+	Currency  money.Currency `db:"currency" json:"currency"`
+	CreatedAt time.Time      `db:"created_at" json:"created_at"`
+}
+
+// Balance is a wallet with its derived balance
+// Credits are REFUND entries paid into the wallet, debits are ESCROW entries paid from it
+type Balance struct {
+	WalletID uuid.UUID      `db:"wallet_id" json:"wallet_id"`
+	Currency money.Currency `db:"currency" json:"currency"`
+	Balance  float64        `db:"balance" json:"balance"`
+}
+
+// Money returns the balance in the wallet currency's minor units
+func (b *Balance) Money() money.Money {
+	return money.FromDecimal(b.Balance, b.Currency)
+}
+
+// Direction tells whether a statement line added to or took from the wallet
+type Direction string
+
+const (
+	DirectionCredit Direction = "credit"
+	DirectionDebit  Direction = "debit"
+)
+
+// StatementLine is a single ledger entry touching the wallet, with the balance after it
+type StatementLine struct {
+	LedgerEntryID int              `db:"ledger_entry_id" json:"ledger_entry_id"`
+	OrderID       uuid.UUID        `db:"order_id" json:"order_id"`
+	EntryType     ledger.EntryType `db:"entry_type" json:"entry_type"`
+	Direction     Direction        `db:"direction" json:"direction"`
+	Amount        float64          `db:"amount" json:"amount"`
+	BalanceAfter  float64          `db:"balance_after" json:"balance_after"`
+	Notes         *string          `db:"notes" json:"notes,omitempty"`
+	CreatedAt     time.Time        `db:"created_at" json:"created_at"`
+}
+
+// Statement is the full history of one wallet, oldest first
+type Statement struct {
+	WalletID uuid.UUID       `json:"wallet_id"`
+	Currency money.Currency  `json:"currency"`
+	Balance  float64         `json:"balance"`
+	Lines    []StatementLine `json:"lines"`
+}
@@ -0,0 +1,148 @@
+package wallet
+
+import (
+	"context"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/money"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *repository {
+	return &repository{db: db}
+}
+
+// walletBalanceExpr sums a wallet's ledger entries: refunds credit it, escrows paid from it debit it
+const walletBalanceExpr = `
+	COALESCE(SUM(CASE WHEN le.entry_type = 'REFUND' THEN le.amount ELSE -le.amount END), 0)
+`
+
+// GetOrCreateForUpdateTx returns the buyer's wallet in a currency, creating it on first use,
+// and locks it for the rest of the transaction so spends from the same wallet serialize
+func (r *repository) GetOrCreateForUpdateTx(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, currency money.Currency) (*Wallet, error) {
+	insert := `
+		INSERT INTO wallets (user_id, currency)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id, currency) DO NOTHING
+	`
+	if _, err := tx.ExecContext(ctx, insert, userID, currency); err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	var wallet Wallet
+	query := `
+		SELECT id, user_id, currency, created_at
+		FROM wallets
+		WHERE user_id = $1 AND currency = $2
+		FOR UPDATE
+	`
+
+	err := tx.GetContext(ctx, &wallet, query, userID, currency)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return &wallet, nil
+}
+
+// GetBalanceTx reads the balance under the caller's wallet lock
+func (r *repository) GetBalanceTx(ctx context.Context, tx *sqlx.Tx, walletID uuid.UUID) (float64, error) {
+	var balance float64
+	query := `SELECT ` + walletBalanceExpr + ` FROM ledger_entries le WHERE le.wallet_id = $1`
+
+	err := tx.GetContext(ctx, &balance, query, walletID)
+	if err != nil {
+		return 0, errorutils.AnalyzeDBErr(err)
+	}
+
+	return balance, nil
+}
+
+// GetByUserForUpdateTx locks every wallet the user has until the end of the transaction,
+// always in currency order so two callers cannot deadlock on them
+func (r *repository) GetByUserForUpdateTx(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID) ([]Wallet, error) {
+	var wallets []Wallet
+	query := `
+		SELECT id, user_id, currency, created_at
+		FROM wallets
+		WHERE user_id = $1
+		ORDER BY currency
+		FOR UPDATE
+	`
+
+	err := tx.SelectContext(ctx, &wallets, query, userID)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return wallets, nil
+}
+
+func (r *repository) GetByUserAndCurrency(ctx context.Context, userID uuid.UUID, currency money.Currency) (*Wallet, error) {
+	var wallet Wallet
+	query := `
+		SELECT id, user_id, currency, created_at
+		FROM wallets
+		WHERE user_id = $1 AND currency = $2
+	`
+
+	err := r.db.GetContext(ctx, &wallet, query, userID, currency)
+	if err != nil {
+		dbErr := errorutils.AnalyzeDBErr(err)
+		if dbErr == errorutils.ErrNotFound {
+			return nil, nil
+		}
+		return nil, dbErr
+	}
+
+	return &wallet, nil
+}
+
+// GetBalancesByUser returns every wallet the user has, one per currency
+func (r *repository) GetBalancesByUser(ctx context.Context, userID uuid.UUID) ([]Balance, error) {
+	var balances []Balance
+	query := `
+		SELECT w.id AS wallet_id, w.currency, ` + walletBalanceExpr + ` AS balance
+		FROM wallets w
+		LEFT JOIN ledger_entries le ON le.wallet_id = w.id
+		WHERE w.user_id = $1
+		GROUP BY w.id, w.currency
+		ORDER BY w.currency
+	`
+
+	err := r.db.SelectContext(ctx, &balances, query, userID)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return balances, nil
+}
+
+// GetStatementLines returns the wallet's entries oldest first, with a running balance
+func (r *repository) GetStatementLines(ctx context.Context, walletID uuid.UUID) ([]StatementLine, error) {
+	var lines []StatementLine
+	query := `
+		SELECT
+			le.id AS ledger_entry_id, le.order_id, le.entry_type,
+			CASE WHEN le.entry_type = 'REFUND' THEN 'credit' ELSE 'debit' END AS direction,
+			le.amount,
+			SUM(CASE WHEN le.entry_type = 'REFUND' THEN le.amount ELSE -le.amount END)
+				OVER (ORDER BY le.id) AS balance_after,
+			le.notes, le.created_at
+		FROM ledger_entries le
+		WHERE le.wallet_id = $1
+		ORDER BY le.id ASC
+	`
+
+	err := r.db.SelectContext(ctx, &lines, query, walletID)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return lines, nil
+}
@@ -0,0 +1,146 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/money"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	GetOrCreateForUpdateTx(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, currency money.Currency) (*Wallet, error)
+	GetBalanceTx(ctx context.Context, tx *sqlx.Tx, walletID uuid.UUID) (float64, error)
+	GetByUserForUpdateTx(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID) ([]Wallet, error)
+	GetByUserAndCurrency(ctx context.Context, userID uuid.UUID, currency money.Currency) (*Wallet, error)
+	GetBalancesByUser(ctx context.Context, userID uuid.UUID) ([]Balance, error)
+	GetStatementLines(ctx context.Context, walletID uuid.UUID) ([]StatementLine, error)
+}
+
+// LedgerService writes the entries that move money in and out of wallets
+type LedgerService interface {
+	CreateWalletEscrowEntryTx(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID, walletID uuid.UUID, amount money.Money, discount money.Money, actorID uuid.UUID) error
+	CreateWalletRefundEntryTx(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID, walletID uuid.UUID, amount money.Money, notes string) error
+}
+
+type service struct {
+	repo          Repository
+	ledgerService LedgerService
+	logger        *slog.Logger
+}
+
+func NewService(repo Repository, ledgerService LedgerService, logger *slog.Logger) *service {
+	return &service{
+		repo:          repo,
+		ledgerService: ledgerService,
+		logger:        logger,
+	}
+}
+
+// SpendTx pays an order's escrow from the buyer's wallet.
+// The wallet row stays locked until the caller's transaction ends, so the balance read here
+// cannot be spent by a concurrent order before the debit is written.
+func (s *service) SpendTx(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, orderID uuid.UUID, amount money.Money, discount money.Money) error {
+	w, err := s.repo.GetOrCreateForUpdateTx(ctx, tx, userID, amount.Currency)
+	if err != nil {
+		return fmt.Errorf("locking wallet: %w", err)
+	}
+
+	raw, err := s.repo.GetBalanceTx(ctx, tx, w.ID)
+	if err != nil {
+		return fmt.Errorf("getting wallet balance: %w", err)
+	}
+
+	balance := money.FromDecimal(raw, w.Currency)
+	if balance.Minor < amount.Minor {
+		return fmt.Errorf("%w: balance %s, order total %s", errorutils.ErrInsufficientWalletBalance, balance, amount)
+	}
+
+	if err := s.ledgerService.CreateWalletEscrowEntryTx(ctx, tx, orderID, w.ID, amount, discount, userID); err != nil {
+		return fmt.Errorf("recording wallet payment: %w", err)
+	}
+
+	s.logger.Info("wallet debited",
+		slog.String("wallet_id", w.ID.String()),
+		slog.String("order_id", orderID.String()),
+		slog.String("amount", amount.String()))
+
+	return nil
+}
+
+// CreditTx refunds an order's escrow into the buyer's wallet as store credit
+func (s *service) CreditTx(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, orderID uuid.UUID, amount money.Money, notes string) error {
+	w, err := s.repo.GetOrCreateForUpdateTx(ctx, tx, userID, amount.Currency)
+	if err != nil {
+		return fmt.Errorf("locking wallet: %w", err)
+	}
+
+	if err := s.ledgerService.CreateWalletRefundEntryTx(ctx, tx, orderID, w.ID, amount, notes); err != nil {
+		return fmt.Errorf("recording wallet credit: %w", err)
+	}
+
+	s.logger.Info("wallet credited",
+		slog.String("wallet_id", w.ID.String()),
+		slog.String("order_id", orderID.String()),
+		slog.String("amount", amount.String()))
+
+	return nil
+}
+
+func (s *service) GetBalances(ctx context.Context, userID uuid.UUID) ([]Balance, error) {
+	balances, err := s.repo.GetBalancesByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("getting wallet balances: %w", err)
+	}
+	return balances, nil
+}
+
+// GetBalancesForUpdateTx returns the user's balances under the same wallet locks SpendTx and
+// CreditTx take, so no spend or credit can change them before the caller's transaction ends
+func (s *service) GetBalancesForUpdateTx(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID) ([]Balance, error) {
+	wallets, err := s.repo.GetByUserForUpdateTx(ctx, tx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("locking wallets: %w", err)
+	}
+
+	balances := make([]Balance, 0, len(wallets))
+	for _, w := range wallets {
+		raw, err := s.repo.GetBalanceTx(ctx, tx, w.ID)
+		if err != nil {
+			return nil, fmt.Errorf("getting wallet balance: %w", err)
+		}
+		balances = append(balances, Balance{WalletID: w.ID, Currency: w.Currency, Balance: raw})
+	}
+
+	return balances, nil
+}
+
+// GetStatement returns the history of the user's wallet in one currency
+func (s *service) GetStatement(ctx context.Context, userID uuid.UUID, currency money.Currency) (*Statement, error) {
+	w, err := s.repo.GetByUserAndCurrency(ctx, userID, currency)
+	if err != nil {
+		return nil, fmt.Errorf("getting wallet: %w", err)
+	}
+	if w == nil {
+		return nil, errorutils.ErrNotFound
+	}
+
+	lines, err := s.repo.GetStatementLines(ctx, w.ID)
+	if err != nil {
+		return nil, fmt.Errorf("getting wallet statement: %w", err)
+	}
+
+	statement := &Statement{
+		WalletID: w.ID,
+		Currency: w.Currency,
+		Lines:    lines,
+	}
+	if len(lines) > 0 {
+		statement.Balance = lines[len(lines)-1].BalanceAfter
+	}
+
+	return statement, nil
+}
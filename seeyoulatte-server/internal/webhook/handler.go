@@ -0,0 +1,59 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/gin-gonic/gin"
+)
+
+type Service interface {
+	HandlePaymentEvent(ctx context.Context, body []byte, signature string) (*Result, error)
+}
+
+type Handler struct {
+	service Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service Service, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// PaymentEvent - POST /api/webhooks/payments (signed, no user auth)
+func (h *Handler) PaymentEvent(c *gin.Context) {
+	// the signature covers the exact bytes sent, so read the raw body instead of binding JSON
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	result, err := h.service.HandlePaymentEvent(c.Request.Context(), body, c.GetHeader(SignatureHeader))
+	if err != nil {
+		h.logger.Error("payment webhook failed",
+			slog.String("error", err.Error()),
+			slog.String("ip", c.ClientIP()))
+
+		switch {
+		case errors.Is(err, errorutils.ErrInvalidSignature):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid signature"})
+		case errors.Is(err, errorutils.ErrInvalidInput):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, errorutils.ErrNotFound):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Unknown order"})
+		default:
+			// non-2xx makes the provider retry later
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process event"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
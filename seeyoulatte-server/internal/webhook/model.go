@@ -0,0 +1,65 @@
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/money"
+	"github.com/google/uuid"
+)
+
+// EventType is the kind of outcome the payment provider is reporting
+type EventType string
+
+const (
+	EventTypePaymentSucceeded EventType = "payment.succeeded"
+	EventTypePaymentFailed    EventType = "payment.failed"
+	EventTypeRefundSettled    EventType = "refund.settled"
+)
+
+func (e EventType) IsValid() bool {
+	switch e {
+	case EventTypePaymentSucceeded, EventTypePaymentFailed, EventTypeRefundSettled:
+		return true
+	default:
+		return false
+	}
+}
+
+// Status records what happened to a received event
+type Status string
+
+const (
+	StatusApplied     Status = "applied"      // Moved the order through the state machine
+	StatusIgnored     Status = "ignored"      // Stale, the order already moved past what the event reports
+	StatusNeedsReview Status = "needs_review" // Captured money the order was not waiting for, refunded and left for an operator
+)
+
+// PaymentEvent is the signed JSON body posted to /api/webhooks/payments
+type PaymentEvent struct {
+	ID        string         `json:"id"`
+	Type      EventType      `json:"type"`
+	OrderID   uuid.UUID      `json:"order_id"`
+	Reference string         `json:"reference,omitempty"` // Provider charge or refund reference
+	Amount    float64        `json:"amount,omitempty"`
+	Currency  money.Currency `json:"currency,omitempty"` // ISO 4217, checked against the order when present
+	Reason    string         `json:"reason,omitempty"`   // Failure reason for payment.failed
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// StoredEvent is a received event kept for deduplication and audit
+type StoredEvent struct {
+	ID        string          `db:"id" json:"id"`
+	EventType EventType       `db:"event_type" json:"event_type"`
+	OrderID   *uuid.UUID      `db:"order_id" json:"order_id,omitempty"`
+	Status    Status          `db:"status" json:"status"`
+	Payload   json.RawMessage `db:"payload" json:"payload"`
+	CreatedAt time.Time       `db:"created_at" json:"created_at"`
+}
+
+// Result is returned to the provider
+type Result struct {
+	EventID   string `json:"event_id"`
+	Status    Status `json:"status"`
+	Duplicate bool   `json:"duplicate"`
+}
@@ -0,0 +1,81 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/jmoiron/sqlx"
+)
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *repository {
+	return &repository{db: db}
+}
+
+// CreateIfNotExistsTx stores the event unless its id was already received.
+// Returns false for a duplicate, which the caller acknowledges without reapplying.
+func (r *repository) CreateIfNotExistsTx(ctx context.Context, tx *sqlx.Tx, event *StoredEvent) (bool, error) {
+	query := `
+		INSERT INTO webhook_events (
+			id, event_type, order_id, status, payload
+		) VALUES (
+			$1, $2, $3, $4, $5
+		)
+		ON CONFLICT (id) DO NOTHING
+	`
+
+	result, err := tx.ExecContext(
+		ctx,
+		query,
+		event.ID,
+		event.EventType,
+		event.OrderID,
+		event.Status,
+		[]byte(event.Payload),
+	)
+	if err != nil {
+		return false, errorutils.AnalyzeDBErr(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("checking affected rows: %w", err)
+	}
+
+	return rowsAffected == 1, nil
+}
+
+func (r *repository) UpdateStatusTx(ctx context.Context, tx *sqlx.Tx, id string, status Status) error {
+	query := `UPDATE webhook_events SET status = $1 WHERE id = $2`
+
+	_, err := tx.ExecContext(ctx, query, status, id)
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
+
+func (r *repository) GetByID(ctx context.Context, id string) (*StoredEvent, error) {
+	var event StoredEvent
+	query := `
+		SELECT id, event_type, order_id, status, payload, created_at
+		FROM webhook_events
+		WHERE id = $1
+	`
+
+	err := r.db.GetContext(ctx, &event, query, id)
+	if err != nil {
+		dbErr := errorutils.AnalyzeDBErr(err)
+		if dbErr == errorutils.ErrNotFound {
+			return nil, nil
+		}
+		return nil, dbErr
+	}
+
+	return &event, nil
+}
@@ -0,0 +1,191 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/order"
+	dbutils "github.com/darkphotonKN/seeyoulatte-app/internal/utils/db"
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	CreateIfNotExistsTx(ctx context.Context, tx *sqlx.Tx, event *StoredEvent) (bool, error)
+	UpdateStatusTx(ctx context.Context, tx *sqlx.Tx, id string, status Status) error
+	GetByID(ctx context.Context, id string) (*StoredEvent, error)
+}
+
+type OrderService interface {
+	ApplyPaymentOutcomeTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, event order.Event, outcome order.PaymentOutcome) (*order.Order, error)
+	RefundUnmatchedPaymentTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, outcome order.PaymentOutcome) error
+}
+
+type service struct {
+	repo         Repository
+	db           *sqlx.DB
+	orderService OrderService
+	secret       []byte
+	tolerance    time.Duration
+	logger       *slog.Logger
+}
+
+func NewService(repo Repository, db *sqlx.DB, logger *slog.Logger, orderService OrderService, secret []byte) *service {
+	if len(secret) == 0 {
+		logger.Warn("PAYMENT_WEBHOOK_SECRET is not set, all payment webhooks will be rejected")
+	}
+
+	return &service{
+		repo:         repo,
+		db:           db,
+		orderService: orderService,
+		secret:       secret,
+		tolerance:    DefaultTolerance,
+		logger:       logger,
+	}
+}
+
+// HandlePaymentEvent verifies, deduplicates and applies a provider event.
+// The deduplication row and the order transition commit in the same transaction,
+// so an event that fails half way is retried by the provider instead of being lost.
+func (s *service) HandlePaymentEvent(ctx context.Context, body []byte, signature string) (*Result, error) {
+	if err := Verify(s.secret, signature, body, time.Now(), s.tolerance); err != nil {
+		return nil, err
+	}
+
+	var event PaymentEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, fmt.Errorf("%w: %v", errorutils.ErrInvalidInput, err)
+	}
+
+	if event.ID == "" || event.OrderID == uuid.Nil || !event.Type.IsValid() {
+		return nil, fmt.Errorf("%w: event id, order id and a known type are required", errorutils.ErrInvalidInput)
+	}
+
+	transition, outcome := toTransition(&event)
+	result := &Result{EventID: event.ID, Status: StatusApplied}
+
+	err := dbutils.ExecTx(ctx, s.db, func(tx *sqlx.Tx) error {
+		stored := &StoredEvent{
+			ID:        event.ID,
+			EventType: event.Type,
+			OrderID:   &event.OrderID,
+			Status:    StatusApplied,
+			Payload:   body,
+		}
+
+		created, err := s.repo.CreateIfNotExistsTx(ctx, tx, stored)
+		if err != nil {
+			return fmt.Errorf("recording webhook event: %w", err)
+		}
+
+		if !created {
+			result.Duplicate = true
+			return nil
+		}
+
+		// a failed outcome is rolled back to here, so nothing it wrote is kept with the event's status
+		err = dbutils.ExecSavepoint(ctx, tx, "payment_outcome", func() error {
+			_, err := s.orderService.ApplyPaymentOutcomeTx(ctx, tx, event.OrderID, transition, outcome)
+			return err
+		})
+		if err == nil {
+			return nil
+		}
+
+		// money was captured that the order will never hold, it is refunded and an operator
+		// looks at why the provider charged it
+		if errors.Is(err, errorutils.ErrPaymentMismatch) {
+			s.logger.Error("webhook payment does not match its order, refunding it",
+				slog.String("event_id", event.ID),
+				slog.String("order_id", event.OrderID.String()),
+				slog.String("reference", event.Reference),
+				slog.String("reason", err.Error()))
+
+			if err := s.refundUnmatchedPaymentTx(ctx, tx, &event, outcome); err != nil {
+				return err
+			}
+
+			result.Status = StatusNeedsReview
+			return s.repo.UpdateStatusTx(ctx, tx, event.ID, StatusNeedsReview)
+		}
+
+		// stale: the order already moved past what the event reports (e.g. paid synchronously)
+		// or its funds were already released; keep the event so it stays deduplicated
+		if errors.Is(err, errorutils.ErrInvalidStateTransition) ||
+			errors.Is(err, errorutils.ErrInsufficientEscrow) ||
+			errors.Is(err, errorutils.ErrDuplicatePayout) {
+			s.logger.Info("webhook event ignored",
+				slog.String("event_id", event.ID),
+				slog.String("order_id", event.OrderID.String()),
+				slog.String("reason", err.Error()))
+
+			result.Status = StatusIgnored
+			return s.repo.UpdateStatusTx(ctx, tx, event.ID, StatusIgnored)
+		}
+
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Duplicate {
+		existing, err := s.repo.GetByID(ctx, event.ID)
+		if err != nil {
+			return nil, fmt.Errorf("getting existing webhook event: %w", err)
+		}
+		if existing != nil {
+			result.Status = existing.Status
+		}
+	}
+
+	s.logger.Info("webhook event processed",
+		slog.String("event_id", event.ID),
+		slog.String("type", string(event.Type)),
+		slog.String("order_id", event.OrderID.String()),
+		slog.String("status", string(result.Status)),
+		slog.Bool("duplicate", result.Duplicate))
+
+	return result, nil
+}
+
+// refundUnmatchedPaymentTx queues the refund of a mismatched payment. A refund already queued for
+// the same charge, or a payment with nothing to refund, still leaves the event for review.
+func (s *service) refundUnmatchedPaymentTx(ctx context.Context, tx *sqlx.Tx, event *PaymentEvent, outcome order.PaymentOutcome) error {
+	err := dbutils.ExecSavepoint(ctx, tx, "payment_refund", func() error {
+		return s.orderService.RefundUnmatchedPaymentTx(ctx, tx, event.OrderID, outcome)
+	})
+
+	switch {
+	case err == nil, errors.Is(err, errorutils.ErrDuplicateResource):
+		return nil
+	case errors.Is(err, errorutils.ErrInvalidInput):
+		s.logger.Error("webhook payment could not be refunded",
+			slog.String("event_id", event.ID),
+			slog.String("order_id", event.OrderID.String()),
+			slog.String("error", err.Error()))
+		return nil
+	default:
+		return fmt.Errorf("queueing refund of unmatched payment: %w", err)
+	}
+}
+
+func toTransition(event *PaymentEvent) (order.Event, order.PaymentOutcome) {
+	outcome := order.PaymentOutcome{Reference: event.Reference, Amount: event.Amount, Currency: event.Currency}
+
+	switch event.Type {
+	case EventTypePaymentSucceeded:
+		return order.EventPaymentSucceeded, outcome
+	case EventTypePaymentFailed:
+		return order.EventPaymentFailed, outcome
+	default:
+		return order.EventRefundSettled, outcome
+	}
+}
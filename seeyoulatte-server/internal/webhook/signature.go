@@ -0,0 +1,81 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/darkphotonKN/seeyoulatte-app/internal/utils/errorutils"
+)
+
+// SignatureHeader carries "t=<unix seconds>,v1=<hex hmac>"
+const SignatureHeader = "X-Webhook-Signature"
+
+// DefaultTolerance is how far the signed timestamp may drift from the server clock
+const DefaultTolerance = 5 * time.Minute
+
+// Sign computes the signature header value for a payload.
+// The MAC covers "<timestamp>.<body>" so a captured signature cannot be replayed with a new timestamp.
+func Sign(secret []byte, timestamp time.Time, body []byte) string {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%s", ts, computeMAC(secret, ts, body))
+}
+
+// Verify checks the signature header against the body and rejects stale timestamps
+func Verify(secret []byte, header string, body []byte, now time.Time, tolerance time.Duration) error {
+	if len(secret) == 0 {
+		return fmt.Errorf("%w: webhook secret is not configured", errorutils.ErrInvalidSignature)
+	}
+
+	var ts, sig string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			ts = value
+		case "v1":
+			sig = value
+		}
+	}
+
+	if ts == "" || sig == "" {
+		return fmt.Errorf("%w: malformed signature header", errorutils.ErrInvalidSignature)
+	}
+
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid timestamp", errorutils.ErrInvalidSignature)
+	}
+
+	drift := now.Sub(time.Unix(unix, 0))
+	if drift > tolerance || drift < -tolerance {
+		return fmt.Errorf("%w: timestamp outside tolerance", errorutils.ErrInvalidSignature)
+	}
+
+	expected, err := hex.DecodeString(computeMAC(secret, ts, body))
+	if err != nil {
+		return fmt.Errorf("computing signature: %w", err)
+	}
+
+	given, err := hex.DecodeString(sig)
+	if err != nil || !hmac.Equal(expected, given) {
+		return fmt.Errorf("%w: signature mismatch", errorutils.ErrInvalidSignature)
+	}
+
+	return nil
+}
+
+func computeMAC(secret []byte, ts string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,3 @@
+-- Drop settlement tables
+DROP TABLE IF EXISTS settlement_items;
+DROP TABLE IF EXISTS settlements;
\ No newline at end of file
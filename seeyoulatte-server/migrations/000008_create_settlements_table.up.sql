@@ -0,0 +1,28 @@
+-- Create settlements table (periodic seller statements)
+CREATE TABLE settlements (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    seller_id UUID REFERENCES users(id) NOT NULL,
+    period_start TIMESTAMP NOT NULL,      -- Oldest PAYOUT entry covered by this statement
+    period_end TIMESTAMP NOT NULL,        -- Cutoff used when the statement was generated
+    total_amount DECIMAL(10,2) NOT NULL,  -- Sum of all line items
+    entry_count INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    CONSTRAINT check_settlement_total CHECK (total_amount > 0),
+    CONSTRAINT check_settlement_period CHECK (period_end >= period_start)
+);
+
+-- Create settlement_items table (one line per settled PAYOUT ledger entry)
+CREATE TABLE settlement_items (
+    id SERIAL PRIMARY KEY,
+    settlement_id UUID REFERENCES settlements(id) NOT NULL,
+    ledger_entry_id INTEGER REFERENCES ledger_entries(id) NOT NULL UNIQUE, -- An entry can only ever be settled once
+    order_id UUID REFERENCES orders(id) NOT NULL,
+    amount DECIMAL(10,2) NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX idx_settlements_seller_id ON settlements(seller_id);
+CREATE INDEX idx_settlements_created_at ON settlements(created_at);
+CREATE INDEX idx_settlement_items_settlement_id ON settlement_items(settlement_id);
+CREATE INDEX idx_settlement_items_order_id ON settlement_items(order_id);
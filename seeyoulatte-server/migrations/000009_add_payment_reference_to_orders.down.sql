@@ -0,0 +1,2 @@
+-- Drop payment reference from orders
+ALTER TABLE orders DROP COLUMN IF EXISTS payment_reference;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- Provider reference of the captured charge, needed to refund it later
+ALTER TABLE orders ADD COLUMN payment_reference VARCHAR(255);
@@ -0,0 +1,3 @@
+-- Drop webhook_events table
+ALTER TABLE orders DROP COLUMN IF EXISTS refund_settled_at;
+DROP TABLE IF EXISTS webhook_events;
\ No newline at end of file
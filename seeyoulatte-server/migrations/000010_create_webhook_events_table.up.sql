@@ -0,0 +1,17 @@
+-- Create webhook_events table (inbound provider events, used for deduplication)
+CREATE TABLE webhook_events (
+    id VARCHAR(255) PRIMARY KEY,          -- Provider event id, a redelivered event hits the primary key
+    event_type VARCHAR(50) NOT NULL,      -- payment.succeeded, payment.failed, refund.settled
+    order_id UUID,                        -- No FK: events for unknown orders are rejected, not stored
+    status VARCHAR(20) NOT NULL,          -- applied, ignored
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    CONSTRAINT check_webhook_event_status CHECK (status IN ('applied', 'ignored'))
+);
+
+-- Create indexes
+CREATE INDEX idx_webhook_events_order_id ON webhook_events(order_id);
+CREATE INDEX idx_webhook_events_created_at ON webhook_events(created_at);
+
+-- When the provider confirmed a refund reached the buyer
+ALTER TABLE orders ADD COLUMN refund_settled_at TIMESTAMP;
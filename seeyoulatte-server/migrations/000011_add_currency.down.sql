@@ -0,0 +1,11 @@
+-- Remove currency columns
+DROP INDEX IF EXISTS idx_settlements_seller_currency;
+ALTER TABLE ledger_entries DROP CONSTRAINT IF EXISTS fk_ledger_entries_order_currency;
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS uq_orders_id_currency;
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS check_order_currency;
+ALTER TABLE listings DROP CONSTRAINT IF EXISTS check_listing_currency;
+
+ALTER TABLE settlements DROP COLUMN IF EXISTS currency;
+ALTER TABLE ledger_entries DROP COLUMN IF EXISTS currency;
+ALTER TABLE orders DROP COLUMN IF EXISTS currency;
+ALTER TABLE listings DROP COLUMN IF EXISTS currency;
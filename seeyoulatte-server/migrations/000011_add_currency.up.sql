@@ -0,0 +1,16 @@
+-- Amounts were previously in one implicit currency, existing rows are backfilled as USD
+ALTER TABLE listings ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD';
+ALTER TABLE orders ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD';
+ALTER TABLE ledger_entries ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD';
+ALTER TABLE settlements ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD';
+
+ALTER TABLE listings ADD CONSTRAINT check_listing_currency CHECK (currency ~ '^[A-Z]{3}$');
+ALTER TABLE orders ADD CONSTRAINT check_order_currency CHECK (currency ~ '^[A-Z]{3}$');
+
+-- A ledger entry must be in its order's currency, so one escrow never mixes currencies
+ALTER TABLE orders ADD CONSTRAINT uq_orders_id_currency UNIQUE (id, currency);
+ALTER TABLE ledger_entries ADD CONSTRAINT fk_ledger_entries_order_currency
+    FOREIGN KEY (order_id, currency) REFERENCES orders(id, currency);
+
+-- Sellers are settled separately per currency
+CREATE INDEX idx_settlements_seller_currency ON settlements(seller_id, currency);
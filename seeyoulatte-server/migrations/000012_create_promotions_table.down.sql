@@ -0,0 +1,11 @@
+-- Drop promotions tables
+ALTER TABLE ledger_entries DROP CONSTRAINT IF EXISTS check_ledger_discount;
+ALTER TABLE ledger_entries DROP COLUMN IF EXISTS discount_amount;
+
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS check_order_discount;
+ALTER TABLE orders DROP COLUMN IF EXISTS promotion_id;
+ALTER TABLE orders DROP COLUMN IF EXISTS discount_amount;
+ALTER TABLE orders DROP COLUMN IF EXISTS subtotal;
+
+DROP TABLE IF EXISTS promotion_redemptions;
+DROP TABLE IF EXISTS promotions;
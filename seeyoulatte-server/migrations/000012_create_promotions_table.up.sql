@@ -0,0 +1,61 @@
+-- Create promotions table (promo codes applied at order creation)
+CREATE TABLE promotions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code VARCHAR(50) NOT NULL UNIQUE,     -- Stored uppercase, matched case-insensitively
+    discount_type VARCHAR(20) NOT NULL,   -- 'percentage' or 'fixed'
+    discount_value DECIMAL(10,2) NOT NULL, -- Percent off, or amount off in currency
+    currency CHAR(3),                     -- Required for fixed discounts, only orders in this currency qualify
+    seller_id UUID REFERENCES users(id),  -- Optional. Only this seller's listings qualify
+    category VARCHAR(20),                 -- Optional. Only 'product' or 'experience' listings qualify
+    starts_at TIMESTAMP,                  -- Optional validity window
+    ends_at TIMESTAMP,
+    max_redemptions INTEGER,              -- Optional. Total redemptions across all users
+    max_per_user INTEGER,                 -- Optional. Redemptions per buyer
+    redemption_count INTEGER NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by UUID REFERENCES users(id) NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    CONSTRAINT check_promotion_discount_type CHECK (discount_type IN ('percentage', 'fixed')),
+    CONSTRAINT check_promotion_discount_value CHECK (
+        discount_value > 0 AND (discount_type = 'fixed' OR discount_value < 100)
+    ),
+    CONSTRAINT check_promotion_fixed_currency CHECK (discount_type = 'percentage' OR currency IS NOT NULL),
+    CONSTRAINT check_promotion_category CHECK (category IS NULL OR category IN ('product', 'experience')),
+    CONSTRAINT check_promotion_window CHECK (starts_at IS NULL OR ends_at IS NULL OR ends_at > starts_at),
+    CONSTRAINT check_promotion_max_redemptions CHECK (max_redemptions IS NULL OR max_redemptions > 0),
+    CONSTRAINT check_promotion_max_per_user CHECK (max_per_user IS NULL OR max_per_user > 0),
+    -- Last line of defence against over-redemption, the service also checks under a row lock
+    CONSTRAINT check_promotion_redemption_count CHECK (
+        redemption_count >= 0 AND (max_redemptions IS NULL OR redemption_count <= max_redemptions)
+    )
+);
+
+-- Create promotion_redemptions table (one row per order that used a code)
+CREATE TABLE promotion_redemptions (
+    id SERIAL PRIMARY KEY,
+    promotion_id UUID REFERENCES promotions(id) NOT NULL,
+    order_id UUID REFERENCES orders(id) NOT NULL UNIQUE,
+    user_id UUID REFERENCES users(id) NOT NULL,
+    discount_amount DECIMAL(10,2) NOT NULL,
+    currency CHAR(3) NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    CONSTRAINT check_redemption_discount CHECK (discount_amount > 0)
+);
+
+-- Create indexes
+CREATE INDEX idx_promotions_seller_id ON promotions(seller_id);
+CREATE INDEX idx_promotion_redemptions_promotion_user ON promotion_redemptions(promotion_id, user_id);
+
+-- The order amount is now subtotal minus discount, existing orders had no discount
+ALTER TABLE orders ADD COLUMN subtotal DECIMAL(10,2);
+UPDATE orders SET subtotal = amount;
+ALTER TABLE orders ALTER COLUMN subtotal SET NOT NULL;
+ALTER TABLE orders ADD COLUMN discount_amount DECIMAL(10,2) NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN promotion_id UUID REFERENCES promotions(id);
+ALTER TABLE orders ADD CONSTRAINT check_order_discount CHECK (
+    discount_amount >= 0 AND amount = subtotal - discount_amount
+);
+
+-- ESCROW entries record the discount the buyer received as a separate component
+ALTER TABLE ledger_entries ADD COLUMN discount_amount DECIMAL(10,2) NOT NULL DEFAULT 0;
+ALTER TABLE ledger_entries ADD CONSTRAINT check_ledger_discount CHECK (discount_amount >= 0);
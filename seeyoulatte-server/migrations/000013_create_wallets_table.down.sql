@@ -0,0 +1,10 @@
+-- Drop wallets table
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS check_order_refund_to;
+ALTER TABLE orders DROP COLUMN IF EXISTS refund_to;
+
+DROP INDEX IF EXISTS idx_ledger_entries_wallet_id;
+ALTER TABLE ledger_entries DROP CONSTRAINT IF EXISTS check_ledger_wallet_entry_type;
+ALTER TABLE ledger_entries DROP CONSTRAINT IF EXISTS fk_ledger_entries_wallet_currency;
+ALTER TABLE ledger_entries DROP COLUMN IF EXISTS wallet_id;
+
+DROP TABLE IF EXISTS wallets;
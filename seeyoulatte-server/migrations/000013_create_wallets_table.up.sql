@@ -0,0 +1,25 @@
+-- Create wallets table (store credit, one wallet per buyer and currency)
+-- There is deliberately no balance column: the balance is derived from ledger entries.
+-- The row exists so concurrent spends can lock it.
+CREATE TABLE wallets (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES users(id) NOT NULL,
+    currency CHAR(3) NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    CONSTRAINT uq_wallets_user_currency UNIQUE (user_id, currency),
+    CONSTRAINT uq_wallets_id_currency UNIQUE (id, currency),
+    CONSTRAINT check_wallet_currency CHECK (currency ~ '^[A-Z]{3}$')
+);
+
+-- A REFUND with a wallet credits it, an ESCROW with a wallet was paid from it
+ALTER TABLE ledger_entries ADD COLUMN wallet_id UUID;
+ALTER TABLE ledger_entries ADD CONSTRAINT fk_ledger_entries_wallet_currency
+    FOREIGN KEY (wallet_id, currency) REFERENCES wallets(id, currency);
+ALTER TABLE ledger_entries ADD CONSTRAINT check_ledger_wallet_entry_type CHECK (
+    wallet_id IS NULL OR entry_type IN ('ESCROW', 'REFUND')
+);
+CREATE INDEX idx_ledger_entries_wallet_id ON ledger_entries(wallet_id) WHERE wallet_id IS NOT NULL;
+
+-- Where the buyer's refund goes: back to the payment method, or to their wallet
+ALTER TABLE orders ADD COLUMN refund_to VARCHAR(20) NOT NULL DEFAULT 'original';
+ALTER TABLE orders ADD CONSTRAINT check_order_refund_to CHECK (refund_to IN ('original', 'wallet'));
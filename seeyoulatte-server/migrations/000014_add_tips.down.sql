@@ -0,0 +1,10 @@
+-- Remove tips
+ALTER TABLE settlements DROP COLUMN IF EXISTS tip_amount;
+
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS check_order_tip;
+ALTER TABLE orders DROP COLUMN IF EXISTS tip_amount;
+
+ALTER TABLE ledger_entries DROP CONSTRAINT IF EXISTS check_ledger_entry_type;
+ALTER TABLE ledger_entries ADD CONSTRAINT check_ledger_entry_type CHECK (
+    entry_type IN ('ESCROW', 'PAYOUT', 'REFUND', 'REVERSAL')
+);
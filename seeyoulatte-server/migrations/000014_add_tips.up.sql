@@ -0,0 +1,13 @@
+-- Tips for experience hosts, held in escrow apart from the order amount
+-- TIP adds to the tip escrow, TIP_PAYOUT releases it to the seller on the regular payout path
+ALTER TABLE ledger_entries DROP CONSTRAINT check_ledger_entry_type;
+ALTER TABLE ledger_entries ADD CONSTRAINT check_ledger_entry_type CHECK (
+    entry_type IN ('ESCROW', 'PAYOUT', 'REFUND', 'REVERSAL', 'TIP', 'TIP_PAYOUT')
+);
+
+-- One tip per order, kept out of amount so disputes and refunds never include it
+ALTER TABLE orders ADD COLUMN tip_amount DECIMAL(10,2) NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD CONSTRAINT check_order_tip CHECK (tip_amount >= 0);
+
+-- Statements settle TIP_PAYOUT entries with the PAYOUT ones, the tip share is shown separately
+ALTER TABLE settlements ADD COLUMN tip_amount DECIMAL(10,2) NOT NULL DEFAULT 0;
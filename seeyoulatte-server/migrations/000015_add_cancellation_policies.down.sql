@@ -0,0 +1,8 @@
+-- Remove cancellation policies
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS check_order_cancellation_policy;
+ALTER TABLE orders DROP COLUMN IF EXISTS starts_at;
+ALTER TABLE orders DROP COLUMN IF EXISTS cancellation_policy;
+
+ALTER TABLE listings DROP CONSTRAINT IF EXISTS check_listing_cancellation_policy;
+ALTER TABLE listings DROP COLUMN IF EXISTS starts_at;
+ALTER TABLE listings DROP COLUMN IF EXISTS cancellation_policy;
@@ -0,0 +1,13 @@
+-- Per-listing cancellation policies
+ALTER TABLE listings ADD COLUMN cancellation_policy VARCHAR(20) NOT NULL DEFAULT 'flexible';
+ALTER TABLE listings ADD COLUMN starts_at TIMESTAMP; -- Optional. When the experience takes place, policies count down to it
+ALTER TABLE listings ADD CONSTRAINT check_listing_cancellation_policy CHECK (
+    cancellation_policy IN ('flexible', 'moderate', 'strict')
+);
+
+-- Orders keep the terms they were bought under, later listing edits do not change them
+ALTER TABLE orders ADD COLUMN cancellation_policy VARCHAR(20) NOT NULL DEFAULT 'flexible';
+ALTER TABLE orders ADD COLUMN starts_at TIMESTAMP;
+ALTER TABLE orders ADD CONSTRAINT check_order_cancellation_policy CHECK (
+    cancellation_policy IN ('flexible', 'moderate', 'strict')
+);
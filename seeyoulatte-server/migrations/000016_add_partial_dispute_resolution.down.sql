@@ -0,0 +1,17 @@
+-- Remove partial dispute resolutions
+DROP INDEX IF EXISTS idx_disputes_open_order;
+
+ALTER TABLE disputes DROP CONSTRAINT IF EXISTS check_dispute_refund_amount;
+ALTER TABLE disputes DROP COLUMN IF EXISTS resolution_notes;
+ALTER TABLE disputes DROP COLUMN IF EXISTS refund_amount;
+
+ALTER TABLE disputes DROP CONSTRAINT check_dispute_status;
+ALTER TABLE disputes ADD CONSTRAINT check_dispute_status CHECK (
+    status IN ('open', 'resolved_refund', 'resolved_rejected')
+);
+
+ALTER TABLE orders DROP CONSTRAINT check_order_state;
+ALTER TABLE orders ADD CONSTRAINT check_order_state CHECK (state IN (
+    'pending_payment', 'paid', 'accepted', 'fulfilled',
+    'completed', 'cancelled', 'disputed', 'refunded'
+));
@@ -0,0 +1,19 @@
+-- Partial dispute resolutions: part of the order refunded, the remainder paid out
+ALTER TABLE orders DROP CONSTRAINT check_order_state;
+ALTER TABLE orders ADD CONSTRAINT check_order_state CHECK (state IN (
+    'pending_payment', 'paid', 'accepted', 'fulfilled',
+    'completed', 'cancelled', 'disputed', 'refunded', 'partially_refunded'
+));
+
+ALTER TABLE disputes DROP CONSTRAINT check_dispute_status;
+ALTER TABLE disputes ADD CONSTRAINT check_dispute_status CHECK (
+    status IN ('open', 'resolved_refund', 'resolved_rejected', 'resolved_partial')
+);
+
+-- What the buyer got back, set on every resolution so reporting never recomputes it
+ALTER TABLE disputes ADD COLUMN refund_amount DECIMAL(10,2);
+ALTER TABLE disputes ADD COLUMN resolution_notes TEXT;
+ALTER TABLE disputes ADD CONSTRAINT check_dispute_refund_amount CHECK (refund_amount IS NULL OR refund_amount >= 0);
+
+-- One open dispute per order
+CREATE UNIQUE INDEX idx_disputes_open_order ON disputes(order_id) WHERE status = 'open';
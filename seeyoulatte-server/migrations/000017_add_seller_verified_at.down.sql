@@ -0,0 +1,3 @@
+-- Remove seller onboarding
+DROP INDEX IF EXISTS idx_users_seller_verified_at;
+ALTER TABLE users DROP COLUMN IF EXISTS seller_verified_at;
@@ -0,0 +1,4 @@
+-- Set once a user completes seller onboarding, NULL for buyers
+ALTER TABLE users ADD COLUMN seller_verified_at TIMESTAMP;
+
+CREATE INDEX idx_users_seller_verified_at ON users(seller_verified_at) WHERE seller_verified_at IS NOT NULL;
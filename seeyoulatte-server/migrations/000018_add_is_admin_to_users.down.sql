@@ -0,0 +1,2 @@
+-- Remove is_admin from users
+ALTER TABLE users DROP COLUMN IF EXISTS is_admin;
@@ -0,0 +1,2 @@
+-- Platform operators, granted directly in the database
+ALTER TABLE users ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,2 @@
+-- Drop admin audit log
+DROP TABLE IF EXISTS admin_audit_log;
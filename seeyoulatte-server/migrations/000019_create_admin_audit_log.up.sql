@@ -0,0 +1,17 @@
+-- Every request to an admin endpoint, written before the handler runs
+CREATE TABLE admin_audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    admin_id UUID REFERENCES users(id) NOT NULL,
+    action VARCHAR(150) NOT NULL,            -- Method and route, e.g. "POST /api/admin/users/:id/freeze"
+    target JSONB NOT NULL DEFAULT '{}',      -- Route and query parameters, e.g. {"id": "..."}
+    reason TEXT NOT NULL,                    -- Why the admin did it, from the X-Admin-Reason header
+    request_id VARCHAR(64),
+    status_code INTEGER,                     -- Response status, NULL if the request never finished
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT check_audit_reason CHECK (length(trim(reason)) > 0)
+);
+
+-- Create indexes
+CREATE INDEX idx_admin_audit_log_admin_id ON admin_audit_log(admin_id);
+CREATE INDEX idx_admin_audit_log_created_at ON admin_audit_log(created_at);
+CREATE INDEX idx_admin_audit_log_target_id ON admin_audit_log((target->>'id'));
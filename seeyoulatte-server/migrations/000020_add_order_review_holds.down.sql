@@ -0,0 +1,10 @@
+-- Remove the order review queue
+DROP INDEX IF EXISTS idx_orders_under_review;
+ALTER TABLE orders DROP COLUMN IF EXISTS held_reason;
+ALTER TABLE orders DROP COLUMN IF EXISTS held_at;
+
+ALTER TABLE orders DROP CONSTRAINT check_order_state;
+ALTER TABLE orders ADD CONSTRAINT check_order_state CHECK (state IN (
+    'pending_payment', 'paid', 'accepted', 'fulfilled',
+    'completed', 'cancelled', 'disputed', 'refunded', 'partially_refunded'
+));
@@ -0,0 +1,11 @@
+-- Orders of a frozen seller that were already accepted or fulfilled wait for an admin
+ALTER TABLE orders DROP CONSTRAINT check_order_state;
+ALTER TABLE orders ADD CONSTRAINT check_order_state CHECK (state IN (
+    'pending_payment', 'paid', 'accepted', 'fulfilled',
+    'completed', 'cancelled', 'disputed', 'refunded', 'partially_refunded', 'under_review'
+));
+
+ALTER TABLE orders ADD COLUMN held_at TIMESTAMP;   -- When the order entered the review queue
+ALTER TABLE orders ADD COLUMN held_reason TEXT;
+
+CREATE INDEX idx_orders_under_review ON orders(held_at) WHERE state = 'under_review';
@@ -0,0 +1,3 @@
+-- Remove refresh tokens and token revocation
+DROP TABLE IF EXISTS refresh_tokens;
+ALTER TABLE users DROP COLUMN IF EXISTS tokens_valid_after;
@@ -0,0 +1,18 @@
+-- Access tokens issued before this time are rejected, bumped by logout-all and freezing
+ALTER TABLE users ADD COLUMN tokens_valid_after TIMESTAMP;
+
+-- Rotating refresh tokens, only the SHA-256 of the token is stored
+CREATE TABLE refresh_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES users(id) NOT NULL,
+    family_id UUID NOT NULL,                 -- Shared by every token rotated from the same sign-in
+    token_hash CHAR(64) UNIQUE NOT NULL,     -- Hex SHA-256 of the token handed to the client
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP,                    -- Set on rotation, logout or reuse detection
+    replaced_by UUID REFERENCES refresh_tokens(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX idx_refresh_tokens_user_id ON refresh_tokens(user_id) WHERE revoked_at IS NULL;
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens(family_id);
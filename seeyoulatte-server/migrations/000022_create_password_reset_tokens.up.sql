@@ -0,0 +1,12 @@
+-- Single-use password reset tokens, only the SHA-256 of the emailed token is stored
+CREATE TABLE password_reset_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES users(id) NOT NULL,
+    token_hash CHAR(64) UNIQUE NOT NULL,     -- Hex SHA-256 of the token in the reset link
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,                       -- Set when the token is redeemed or superseded by a newer one
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX idx_password_reset_tokens_user_id ON password_reset_tokens(user_id) WHERE used_at IS NULL;
@@ -0,0 +1,2 @@
+-- Remove email verification tokens
+DROP TABLE IF EXISTS email_verification_tokens;
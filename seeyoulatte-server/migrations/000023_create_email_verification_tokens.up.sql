@@ -0,0 +1,12 @@
+-- Single-use email verification tokens sent on signup, only the SHA-256 of the token is stored
+CREATE TABLE email_verification_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES users(id) NOT NULL,
+    token_hash CHAR(64) UNIQUE NOT NULL,     -- Hex SHA-256 of the token in the verification link
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,                       -- Set when the token is redeemed or superseded by a newer one
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX idx_email_verification_tokens_user_id ON email_verification_tokens(user_id) WHERE used_at IS NULL;
@@ -0,0 +1,9 @@
+-- Token buckets shared by every API replica, one row per limited key (e.g. "signin:ip:203.0.113.7")
+CREATE TABLE rate_limit_buckets (
+    key VARCHAR(320) PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,        -- Tokens left as of updated_at, refilled lazily on the next request
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX idx_rate_limit_buckets_updated_at ON rate_limit_buckets(updated_at);
@@ -0,0 +1,15 @@
+-- TOTP two-factor authentication. The secret is set on enrollment and only
+-- enforced once confirmed with a code, which sets totp_enabled_at.
+ALTER TABLE users ADD COLUMN totp_secret VARCHAR(64);
+ALTER TABLE users ADD COLUMN totp_enabled_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN totp_last_step BIGINT; -- Last accepted time step, a code cannot be replayed
+
+-- One-time recovery codes for a lost authenticator, only the SHA-256 of each code is stored
+CREATE TABLE totp_recovery_codes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES users(id) NOT NULL,
+    code_hash CHAR(64) NOT NULL,             -- Hex SHA-256 of the normalized code
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, code_hash)
+);
@@ -0,0 +1,2 @@
+-- Remove account deletion marker
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_at;
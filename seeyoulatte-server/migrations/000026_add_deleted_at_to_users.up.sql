@@ -0,0 +1,2 @@
+-- Deleted accounts keep their row, anonymized, so retained orders and ledger entries still reference a user
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMP;
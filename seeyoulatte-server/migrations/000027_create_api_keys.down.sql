@@ -0,0 +1,2 @@
+-- Remove API keys
+DROP TABLE IF EXISTS api_keys;
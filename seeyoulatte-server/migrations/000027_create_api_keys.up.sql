@@ -0,0 +1,15 @@
+-- Per-user API keys for scripts and integrations, only the SHA-256 of the key is stored
+CREATE TABLE api_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES users(id) NOT NULL,
+    name VARCHAR(100) NOT NULL,              -- Chosen by the user, e.g. "inventory sync"
+    prefix VARCHAR(16) NOT NULL,             -- Start of the key, shown so users can tell keys apart
+    key_hash CHAR(64) UNIQUE NOT NULL,       -- Hex SHA-256 of the full key
+    scopes TEXT[] NOT NULL,                  -- e.g. {listings:write,orders:read}
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX idx_api_keys_user_id ON api_keys(user_id) WHERE revoked_at IS NULL;
@@ -0,0 +1,2 @@
+-- Remove transfers
+DROP TABLE IF EXISTS transfers;
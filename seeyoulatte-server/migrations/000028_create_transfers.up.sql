@@ -0,0 +1,26 @@
+-- Refunds and payouts owed at the provider. Order transitions queue them next to their ledger
+-- entries, the transfer worker makes the provider call after commit, retrying with the same
+-- idempotency key until the provider confirms.
+CREATE TABLE transfers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id UUID REFERENCES orders(id) NOT NULL,
+    operation VARCHAR(20) NOT NULL,              -- refund, payout
+    idempotency_key VARCHAR(100) UNIQUE NOT NULL, -- The ledger entry behind it, e.g. "ledger-entry:42"
+    charge_reference VARCHAR(255),               -- Refunds: the charge to refund
+    seller_id UUID REFERENCES users(id),         -- Payouts: who is paid
+    amount DECIMAL(10,2) NOT NULL,
+    currency CHAR(3) NOT NULL,
+    provider_reference VARCHAR(255),             -- Provider transaction once completed
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    completed_at TIMESTAMP,
+    failed_at TIMESTAMP,                         -- Declined or out of attempts, needs an operator
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT check_transfer_amount CHECK (amount > 0),
+    CONSTRAINT check_transfer_operation CHECK (operation IN ('refund', 'payout'))
+);
+
+-- Create indexes
+CREATE INDEX idx_transfers_pending ON transfers(created_at) WHERE completed_at IS NULL AND failed_at IS NULL;
+CREATE INDEX idx_transfers_order_id ON transfers(order_id);
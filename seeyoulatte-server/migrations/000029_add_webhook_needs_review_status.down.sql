@@ -0,0 +1,4 @@
+-- Remove the needs_review webhook event status
+UPDATE webhook_events SET status = 'ignored' WHERE status = 'needs_review';
+ALTER TABLE webhook_events DROP CONSTRAINT check_webhook_event_status;
+ALTER TABLE webhook_events ADD CONSTRAINT check_webhook_event_status CHECK (status IN ('applied', 'ignored'));
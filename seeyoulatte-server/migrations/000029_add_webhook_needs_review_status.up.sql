@@ -0,0 +1,3 @@
+-- Payment events that captured money no order was waiting for are refunded and held for an operator
+ALTER TABLE webhook_events DROP CONSTRAINT check_webhook_event_status;
+ALTER TABLE webhook_events ADD CONSTRAINT check_webhook_event_status CHECK (status IN ('applied', 'ignored', 'needs_review'));
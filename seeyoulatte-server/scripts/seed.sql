@@ -91,13 +91,15 @@ INSERT INTO listings (seller_id, title, description, category, price, quantity,
     ('a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a14', 'Coffee Storage Workshop', 'Learn proper storage techniques to keep your coffee fresh longer. Practical tips and demonstrations.', 'experience', 28.00, 10, 'Roastery workshop space.', NOW() + INTERVAL '3 weeks', true, NOW() - INTERVAL '7 days');
 
 -- Add some test orders to show activity
-INSERT INTO orders (listing_id, buyer_id, seller_id, quantity, amount, state, created_at)
+INSERT INTO orders (listing_id, buyer_id, seller_id, quantity, subtotal, amount, currency, state, created_at)
 SELECT
     l.id,
     'a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a15', -- Lisa as buyer
     l.seller_id,
     1,
     l.price,
+    l.price,
+    l.currency,
     'completed',
     NOW() - INTERVAL '2 weeks'
 FROM listings l